@@ -0,0 +1,100 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+const (
+	// aeadMessageIdSize is size of random message id, which is prepended to
+	// ciphertext and bound as associated data
+	aeadMessageIdSize = 8
+)
+
+// EncryptorAEAD implements encryption with associated data, which is
+// authenticated but not encrypted
+type EncryptorAEAD interface {
+	EncryptAD(data, additionalData []byte) ([]byte, error)
+}
+
+// DecryptorAEAD implements decryption with associated data, see EncryptorAEAD
+type DecryptorAEAD interface {
+	DecryptAD(data, additionalData []byte) ([]byte, error)
+}
+
+// SetAssociatedData binds version, instance id and random message id to the
+// ciphertext as AEAD associated data, so valid ciphertext can't be moved under
+// another message header. Requires EncryptorDecryptor implementing
+// EncryptorAEAD and DecryptorAEAD, both sides must enable the mode.
+//
+// Serialized format:
+// message_id(8) + ciphertext
+func (a *AirGap) SetAssociatedData(enabled bool) *AirGap {
+	a.associatedData = enabled
+	return a
+}
+
+// associatedData returns version || instance_id || message_id
+func associatedData(version uint8, instanceId, messageId []byte) []byte {
+	result := make([]byte, 0, 1+len(instanceId)+len(messageId))
+	result = append(result, version)
+	result = append(result, instanceId...)
+	return append(result, messageId...)
+}
+
+// encrypt encrypts serialized message body with associated data, when enabled
+func (m *Message) encrypt(data []byte) ([]byte, error) {
+	if !m.associatedData {
+		return m.e.Encrypt(data)
+	}
+
+	e, ok := m.e.(EncryptorAEAD)
+	if !ok {
+		return nil, errors.New("go-airgap encryptor doesn't support associated data")
+	}
+
+	messageId := make([]byte, aeadMessageIdSize)
+	if _, err := io.ReadFull(rand.Reader, messageId); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := e.EncryptAD(data, associatedData(m.Version, m.InstanceId, messageId))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(messageId, encrypted...), nil
+}
+
+// decrypt decrypts message body with associated data, when enabled
+func (a *AirGap) decrypt(d Decryptor, data []byte) ([]byte, error) {
+	if !a.associatedData {
+		return d.Decrypt(data)
+	}
+
+	ad, ok := d.(DecryptorAEAD)
+	if !ok {
+		return nil, errors.New("go-airgap decryptor doesn't support associated data")
+	}
+
+	if len(data) < aeadMessageIdSize {
+		return nil, errors.New("go-airgap message to small")
+	}
+
+	return ad.DecryptAD(data[aeadMessageIdSize:], associatedData(a.version, a.instanceId, data[:aeadMessageIdSize]))
+}
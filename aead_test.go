@@ -0,0 +1,135 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+// plainEncryptorDecryptor doesn't support associated data
+type plainEncryptorDecryptor struct{}
+
+func (plainEncryptorDecryptor) Encrypt(data []byte) ([]byte, error) { return data, nil }
+func (plainEncryptorDecryptor) Decrypt(data []byte) ([]byte, error) { return data, nil }
+
+func TestAirGap_SetAssociatedData(t *testing.T) {
+	instanceIds := make([][]byte, 2)
+	for i := range instanceIds {
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal("cannot generate private key")
+		}
+		instanceIds[i] = elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+	}
+
+	routingKey := []byte("dummy routing key")
+
+	for _, private := range []bool{false, true} {
+		airGap := NewAirGap(VersionDefault, instanceIds[0]).
+			SetEncryptorDecryptor(NewDummyEncryptorDecryptor()).
+			SetAssociatedData(true)
+		other := NewAirGap(VersionDefault, instanceIds[1]).
+			SetEncryptorDecryptor(NewDummyEncryptorDecryptor()).
+			SetAssociatedData(true)
+
+		if private {
+			airGap.SetPrivacyMode(routingKey)
+			other.SetPrivacyMode(routingKey)
+		}
+
+		serialized, err := airGap.CreateMessage().AddOperation(opCodeTest1, []byte("secret message")).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message, err := airGap.Unmarshal(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, []byte("secret message")) {
+			t.Fatal("incorrect received payload")
+		}
+
+		// ciphertext moved under header of another instance
+		swapped := append([]byte{}, serialized...)
+		if private {
+			copy(swapped, RoutingTag(routingKey, instanceIds[1]))
+		}
+
+		if _, err = other.Unmarshal(swapped); err == nil {
+			t.Fatal("ciphertext is accepted for another instance")
+		}
+
+		// message id is authenticated
+		offset := 0
+		if private {
+			offset = RoutingTagSize
+		}
+
+		swapped = append([]byte{}, serialized...)
+		swapped[offset] ^= 1
+		if _, err = airGap.Unmarshal(swapped); err == nil {
+			t.Fatal("message with modified message id is accepted")
+		}
+
+		// associated data isn't expected by receiver
+		airGap.SetAssociatedData(false)
+		if _, err = airGap.Unmarshal(serialized); err == nil {
+			t.Fatal("message with associated data is accepted without it")
+		}
+	}
+
+	airGap := NewAirGap(VersionDefault, instanceIds[0]).
+		SetEncryptorDecryptor(plainEncryptorDecryptor{}).
+		SetAssociatedData(true)
+
+	if _, err := airGap.CreateMessage().AddOperation(opCodeTest1, []byte("secret message")).Marshal(); err == nil {
+		t.Fatal("encryptor without associated data support is accepted")
+	}
+}
+
+func TestSessionEncryptorDecryptor_EncryptAD(t *testing.T) {
+	ed, err := NewSessionEncryptorDecryptor([]byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := ed.EncryptAD([]byte("secret message"), []byte("header"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ed.Rotate(bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := ed.DecryptAD(encrypted, []byte("header"))
+	if err != nil || !bytes.Equal(decrypted, []byte("secret message")) {
+		t.Fatal("incorrect decrypted payload", err)
+	}
+
+	if _, err = ed.DecryptAD(encrypted, []byte("other header")); err == nil {
+		t.Fatal("ciphertext is decrypted with another associated data")
+	}
+
+	if _, err = ed.Decrypt(encrypted); err == nil {
+		t.Fatal("ciphertext is decrypted without associated data")
+	}
+}
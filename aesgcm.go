@@ -0,0 +1,74 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// AESGCMEncryptorDecryptor implements AES-GCM encryption with random nonce,
+// which is generated for every message and prepended to ciphertext.
+//
+// Serialized format:
+// nonce(12) + ciphertext + tag(16)
+type AESGCMEncryptorDecryptor struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMEncryptorDecryptor initiates AES-GCM with 16, 24 or 32 bytes key.
+// Random 96-bit nonces are safe for up to 2^32 messages per key.
+func NewAESGCMEncryptorDecryptor(key []byte) (*AESGCMEncryptorDecryptor, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCMEncryptorDecryptor{aead: aead}, nil
+}
+
+func (ed *AESGCMEncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	return ed.EncryptAD(data, nil)
+}
+
+func (ed *AESGCMEncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	return ed.DecryptAD(data, nil)
+}
+
+func (ed *AESGCMEncryptorDecryptor) EncryptAD(data, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, ed.aead.NonceSize(), ed.aead.NonceSize()+len(data)+ed.aead.Overhead())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return ed.aead.Seal(nonce, nonce, data, additionalData), nil
+}
+
+func (ed *AESGCMEncryptorDecryptor) DecryptAD(data, additionalData []byte) ([]byte, error) {
+	if len(data) < ed.aead.NonceSize()+ed.aead.Overhead() {
+		return nil, errors.New("aes-gcm ciphertext to small")
+	}
+
+	return ed.aead.Open(nil, data[:ed.aead.NonceSize()], data[ed.aead.NonceSize():], additionalData)
+}
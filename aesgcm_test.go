@@ -0,0 +1,65 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAESGCMEncryptorDecryptor(t *testing.T) {
+	ed, err := NewAESGCMEncryptorDecryptor([]byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := ed.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := ed.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(encrypted[:12], again[:12]) || bytes.Equal(encrypted, again) {
+		t.Fatal("nonce is reused")
+	}
+
+	decrypted, err := ed.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	encrypted[len(encrypted)-1] ^= 1
+	if _, err = ed.Decrypt(encrypted); err == nil {
+		t.Fatal("tampered ciphertext accepted")
+	}
+
+	if _, err = ed.Decrypt(encrypted[:27]); err == nil {
+		t.Fatal("truncated ciphertext accepted")
+	}
+
+	if _, err = NewAESGCMEncryptorDecryptor([]byte("short key")); err == nil {
+		t.Fatal("incorrect key size accepted")
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	gcmSIVNonceSize = 12
+	gcmSIVTagSize   = 16
+)
+
+// AESGCMSIVEncryptorDecryptor implements nonce-misuse-resistant AES-GCM-SIV
+// encryption (RFC 8452). Random nonce is prepended to every ciphertext, so poor
+// entropy or repeated marshal of the same message leaks at most their equality.
+type AESGCMSIVEncryptorDecryptor struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMSIVEncryptorDecryptor initiates AES-GCM-SIV with 16 or 32 bytes key
+func NewAESGCMSIVEncryptorDecryptor(key []byte) (*AESGCMSIVEncryptorDecryptor, error) {
+	aead, err := newGCMSIV(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCMSIVEncryptorDecryptor{aead: aead}, nil
+}
+
+func (ed *AESGCMSIVEncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	return ed.EncryptAD(data, nil)
+}
+
+func (ed *AESGCMSIVEncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	return ed.DecryptAD(data, nil)
+}
+
+func (ed *AESGCMSIVEncryptorDecryptor) EncryptAD(data, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, gcmSIVNonceSize, gcmSIVNonceSize+len(data)+gcmSIVTagSize)
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return ed.aead.Seal(nonce, nonce, data, additionalData), nil
+}
+
+func (ed *AESGCMSIVEncryptorDecryptor) DecryptAD(data, additionalData []byte) ([]byte, error) {
+	if len(data) < gcmSIVNonceSize+gcmSIVTagSize {
+		return nil, errors.New("aes-gcm-siv ciphertext to small")
+	}
+
+	return ed.aead.Open(nil, data[:gcmSIVNonceSize], data[gcmSIVNonceSize:], additionalData)
+}
+
+// gcmSIV implements cipher.AEAD for AES-GCM-SIV
+type gcmSIV struct {
+	block  cipher.Block
+	keyLen int
+}
+
+func newGCMSIV(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, errors.New("aes-gcm-siv key size must be 16 or 32 bytes")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcmSIV{block: block, keyLen: len(key)}, nil
+}
+
+func (g *gcmSIV) NonceSize() int {
+	return gcmSIVNonceSize
+}
+
+func (g *gcmSIV) Overhead() int {
+	return gcmSIVTagSize
+}
+
+// deriveKeys returns message authentication and encryption keys for nonce
+func (g *gcmSIV) deriveKeys(nonce []byte) (authKey []byte, encBlock cipher.Block, err error) {
+	var in, out [16]byte
+	copy(in[4:], nonce)
+
+	keys := make([]byte, 0, 16+g.keyLen)
+	for i := uint32(0); len(keys) < 16+g.keyLen; i++ {
+		binary.LittleEndian.PutUint32(in[:4], i)
+		g.block.Encrypt(out[:], in[:])
+		keys = append(keys, out[:8]...)
+	}
+
+	encBlock, err = aes.NewCipher(keys[16:])
+	return keys[:16], encBlock, err
+}
+
+func (g *gcmSIV) tag(authKey []byte, encBlock cipher.Block, nonce, plaintext, additionalData []byte) []byte {
+	var lengthBlock [16]byte
+	binary.LittleEndian.PutUint64(lengthBlock[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengthBlock[8:], uint64(len(plaintext))*8)
+
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+	p.update(lengthBlock[:])
+
+	s := p.sum()
+	for i := range nonce {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+
+	tag := make([]byte, gcmSIVTagSize)
+	encBlock.Encrypt(tag, s[:])
+	return tag
+}
+
+// ctr applies AES-GCM-SIV counter mode with 32-bit little-endian counter
+func (g *gcmSIV) ctr(encBlock cipher.Block, tag, dst, src []byte) {
+	var counter, keyStream [16]byte
+	copy(counter[:], tag)
+	counter[15] |= 0x80
+
+	for len(src) > 0 {
+		encBlock.Encrypt(keyStream[:], counter[:])
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+
+		n := len(src)
+		if n > len(keyStream) {
+			n = len(keyStream)
+		}
+
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ keyStream[i]
+		}
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+func (g *gcmSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("aes-gcm-siv: incorrect nonce length")
+	}
+
+	authKey, encBlock, err := g.deriveKeys(nonce)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	tag := g.tag(authKey, encBlock, nonce, plaintext, additionalData)
+
+	result := append(dst, make([]byte, len(plaintext)+gcmSIVTagSize)...)
+	out := result[len(dst):]
+	g.ctr(encBlock, tag, out, plaintext)
+	copy(out[len(plaintext):], tag)
+
+	return result
+}
+
+func (g *gcmSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSIVNonceSize {
+		return nil, errors.New("aes-gcm-siv: incorrect nonce length")
+	}
+
+	if len(ciphertext) < gcmSIVTagSize {
+		return nil, errors.New("aes-gcm-siv: ciphertext to small")
+	}
+
+	authKey, encBlock, err := g.deriveKeys(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := ciphertext[len(ciphertext)-gcmSIVTagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-gcmSIVTagSize]
+
+	plaintext := make([]byte, len(ciphertext))
+	g.ctr(encBlock, tag, plaintext, ciphertext)
+
+	if subtle.ConstantTimeCompare(tag, g.tag(authKey, encBlock, nonce, plaintext, additionalData)) != 1 {
+		return nil, errors.New("aes-gcm-siv: message authentication failed")
+	}
+
+	return append(dst, plaintext...), nil
+}
+
+// polyval implements POLYVAL universal hash (RFC 8452) in GF(2^128),
+// elements are little-endian 128-bit values
+type polyval struct {
+	h    [2]uint64
+	s    [2]uint64
+	tail []byte
+}
+
+func newPolyval(key []byte) *polyval {
+	return &polyval{
+		h: [2]uint64{binary.LittleEndian.Uint64(key[:8]), binary.LittleEndian.Uint64(key[8:])},
+	}
+}
+
+// update absorbs data padded with zeroes to the block size
+func (p *polyval) update(data []byte) {
+	for len(data) > 0 {
+		var block [16]byte
+		n := copy(block[:], data)
+		data = data[n:]
+
+		p.s[0] ^= binary.LittleEndian.Uint64(block[:8])
+		p.s[1] ^= binary.LittleEndian.Uint64(block[8:])
+		p.s = polyvalDot(p.s, p.h)
+	}
+}
+
+func (p *polyval) sum() [16]byte {
+	var result [16]byte
+	binary.LittleEndian.PutUint64(result[:8], p.s[0])
+	binary.LittleEndian.PutUint64(result[8:], p.s[1])
+	return result
+}
+
+// polyvalDot returns a*b*x^-128 modulo x^128 + x^127 + x^126 + x^121 + 1
+func polyvalDot(a, b [2]uint64) [2]uint64 {
+	// carry-less multiplication, constant time
+	var product [4]uint64
+	for i := 0; i < 128; i++ {
+		mask := -((a[i/64] >> (i % 64)) & 1)
+		shift := uint(i % 64)
+		word := i / 64
+
+		product[word] ^= (b[0] << shift) & mask
+		product[word+1] ^= (b[1] << shift) & mask
+		if shift > 0 {
+			product[word+1] ^= (b[0] >> (64 - shift)) & mask
+			product[word+2] ^= (b[1] >> (64 - shift)) & mask
+		}
+	}
+
+	// montgomery reduction, divides product by x^128 adding modulus
+	// whenever lowest bit is set
+	for i := 0; i < 128; i++ {
+		mask := -(product[0] & 1)
+		product[0] ^= 1 & mask
+		product[1] ^= 0xC200000000000000 & mask // x^121 + x^126 + x^127
+		product[2] ^= 1 & mask                  // x^128
+
+		product[0] = product[0]>>1 | product[1]<<63
+		product[1] = product[1]>>1 | product[2]<<63
+		product[2] = product[2]>>1 | product[3]<<63
+		product[3] >>= 1
+	}
+
+	return [2]uint64{product[0], product[1]}
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestAESGCMSIV_polyval(t *testing.T) {
+	// RFC 8452, appendix A
+	h, _ := hex.DecodeString("25629347589242761d31f826ba4b757b")
+	x, _ := hex.DecodeString("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362")
+	expected, _ := hex.DecodeString("f7a3b47b846119fae5b7866cf5e5b77e")
+
+	p := newPolyval(h)
+	p.update(x)
+	sum := p.sum()
+
+	if !bytes.Equal(sum[:], expected) {
+		t.Fatalf("mismatch polyval %x", sum)
+	}
+}
+
+func TestAESGCMSIV_Seal(t *testing.T) {
+	// RFC 8452, appendix C.1
+	key, _ := hex.DecodeString("01000000000000000000000000000000")
+	nonce, _ := hex.DecodeString("030000000000000000000000")
+
+	vectors := []struct {
+		plaintext string
+		result    string
+	}{
+		{"", "dc20e2d83f25705bb49e439eca56de25"},
+		{"0100000000000000", "b5d839330ac7b786578782fff6013b815b287c22493a364c"},
+	}
+
+	aead, err := newGCMSIV(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vector := range vectors {
+		plaintext, _ := hex.DecodeString(vector.plaintext)
+		expected, _ := hex.DecodeString(vector.result)
+
+		result := aead.Seal(nil, nonce, plaintext, nil)
+		if !bytes.Equal(result, expected) {
+			t.Fatalf("mismatch ciphertext %x", result)
+		}
+
+		opened, err := aead.Open(nil, nonce, result, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(opened, plaintext) {
+			t.Fatal("mismatch plaintext")
+		}
+	}
+}
+
+func TestAESGCMSIV_EncryptorDecryptor(t *testing.T) {
+	ed, err := NewAESGCMSIVEncryptorDecryptor([]byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := ed.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := ed.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	encrypted[len(encrypted)-1] ^= 1
+	if _, err = ed.Decrypt(encrypted); err == nil {
+		t.Fatal("tampered ciphertext accepted")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agecrypt seals go-airgap payloads in the age encryption format
+// (https://age-encryption.org/v1), so received payloads can be decrypted
+// with standard age tooling on the offline machine.
+package agecrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// scryptMaxWorkFactor is default maximum work factor of age scrypt identity
+const scryptMaxWorkFactor = 22
+
+// EncryptorDecryptor implements go_airgap.EncryptorDecryptor with binary age format
+type EncryptorDecryptor struct {
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+// NewEncryptorDecryptor initiates age envelope for recipients, identities are
+// used for decryption and may be empty for encrypt-only instances
+func NewEncryptorDecryptor(recipients []age.Recipient, identities []age.Identity) *EncryptorDecryptor {
+	return &EncryptorDecryptor{
+		recipients: recipients,
+		identities: identities,
+	}
+}
+
+// NewX25519EncryptorDecryptor initiates age envelope with keys in format of
+// age-keygen and age recipients files: identities contains "AGE-SECRET-KEY-1"
+// lines, recipients contains "age1" lines, comments and empty lines are
+// ignored. Empty identities initiate encrypt-only instance, empty recipients
+// are derived from identities, so payloads are recoverable with "age -d -i".
+func NewX25519EncryptorDecryptor(identities, recipients string) (*EncryptorDecryptor, error) {
+	var (
+		ids  []age.Identity
+		recs []age.Recipient
+		err  error
+	)
+
+	if strings.TrimSpace(identities) != "" {
+		ids, err = age.ParseIdentities(strings.NewReader(identities))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(recipients) != "" {
+		recs, err = age.ParseRecipients(strings.NewReader(recipients))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range ids {
+			if id, ok := ids[i].(*age.X25519Identity); ok {
+				recs = append(recs, id.Recipient())
+			}
+		}
+	}
+
+	if len(ids) == 0 && len(recs) == 0 {
+		return nil, errors.New("age keys are not defined")
+	}
+
+	return NewEncryptorDecryptor(recs, ids), nil
+}
+
+// NewPassphraseEncryptorDecryptor initiates age envelope with scrypt passphrase,
+// payloads are recoverable with "age -d" and the same passphrase. workFactor
+// is scrypt log2(N) of encryption, zero uses age default, decryption accepts
+// work factors up to the greater of workFactor and age default.
+func NewPassphraseEncryptorDecryptor(passphrase string, workFactor int) (*EncryptorDecryptor, error) {
+	if workFactor < 0 || workFactor > 30 {
+		return nil, errors.New("age scrypt work factor must be 0-30")
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if workFactor > 0 {
+		recipient.SetWorkFactor(workFactor)
+		if workFactor > scryptMaxWorkFactor {
+			identity.SetMaxWorkFactor(workFactor)
+		}
+	}
+
+	return NewEncryptorDecryptor([]age.Recipient{recipient}, []age.Identity{identity}), nil
+}
+
+func (ed *EncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	if len(ed.recipients) == 0 {
+		return nil, errors.New("age recipients are not defined")
+	}
+
+	var buf bytes.Buffer
+
+	w, err := age.Encrypt(&buf, ed.recipients...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (ed *EncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	if len(ed.identities) == 0 {
+		return nil, errors.New("age identities are not defined")
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(data), ed.identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
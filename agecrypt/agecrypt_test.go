@@ -0,0 +1,173 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agecrypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"filippo.io/age"
+	airgap "github.com/censync/go-airgap"
+)
+
+func TestEncryptorDecryptor(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	airGap := airgap.NewAirGap(airgap.VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+	airGap.SetEncryptorDecryptor(NewEncryptorDecryptor(
+		[]age.Recipient{identity.Recipient()},
+		[]age.Identity{identity},
+	))
+
+	data := []byte(`{"key": "secret message"}`)
+
+	serializedChunks, err := airGap.CreateMessage().AddOperation(1, data).MarshalB64Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := airgap.NewChunks()
+	for i := range serializedChunks {
+		if _, err = chunks.ReadB64Chunk(serializedChunks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	message, err := airGap.Unmarshal(chunks.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(message.Operations[0].Data, data) {
+		t.Fatal("mismatch operation data")
+	}
+
+	stranger, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := NewEncryptorDecryptor([]age.Recipient{identity.Recipient()}, nil).Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewEncryptorDecryptor(nil, []age.Identity{stranger}).Decrypt(encrypted); err == nil {
+		t.Fatal("payload decrypted by stranger")
+	}
+}
+
+func TestNewX25519EncryptorDecryptor(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := "# created by age-keygen\n# public key: " + identity.Recipient().String() + "\n" + identity.String() + "\n"
+
+	sender, err := NewX25519EncryptorDecryptor("", identity.Recipient().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := sender.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = sender.Decrypt(encrypted); err == nil {
+		t.Fatal("payload decrypted by encrypt-only instance")
+	}
+
+	// recipients are derived from identities
+	receiver, err := NewX25519EncryptorDecryptor(keys, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := receiver.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	if _, err = receiver.Encrypt(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewX25519EncryptorDecryptor("AGE-SECRET-KEY-1INVALID", ""); err == nil {
+		t.Fatal("incorrect identity is accepted")
+	}
+
+	if _, err = NewX25519EncryptorDecryptor("", ""); err == nil {
+		t.Fatal("empty keys are accepted")
+	}
+}
+
+func TestNewPassphraseEncryptorDecryptor(t *testing.T) {
+	ed, err := NewPassphraseEncryptorDecryptor("correct horse battery staple", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := ed.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := ed.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	other, err := NewPassphraseEncryptorDecryptor("incorrect passphrase", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = other.Decrypt(encrypted); err == nil {
+		t.Fatal("payload decrypted with incorrect passphrase")
+	}
+
+	if _, err = NewPassphraseEncryptorDecryptor("", 10); err == nil {
+		t.Fatal("empty passphrase is accepted")
+	}
+
+	if _, err = NewPassphraseEncryptorDecryptor("passphrase", 31); err == nil {
+		t.Fatal("incorrect work factor is accepted")
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/censync/go-airgap/agecrypt
+
+go 1.18
+
+require (
+	filippo.io/age v1.0.0
+	github.com/censync/go-airgap v0.1.0
+)
+
+require (
+	golang.org/x/crypto v0.7.0 // indirect
+	golang.org/x/sys v0.6.0 // indirect
+)
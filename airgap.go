@@ -17,6 +17,7 @@ package go_airgap
 import (
 	"bytes"
 	"errors"
+	"time"
 )
 
 const (
@@ -34,6 +35,42 @@ type AirGap struct {
 	instanceId []byte
 
 	chunkSize int
+	// frameSize bounds the final encoded frame length, when defined
+	frameSize int
+	// chunksOpts contains frames serialization options
+	chunksOpts chunksOptions
+	// profile contains applied settings preset
+	profile Profile
+	// routingKey enables privacy mode, when defined
+	routingKey []byte
+	// paddingBuckets enables length hiding, when defined
+	paddingBuckets []int
+	// pairingSecret enables anonymous sender mode, when defined
+	pairingSecret []byte
+	// checksummer defines integrity checksum algorithm
+	checksummer Checksummer
+	// templates contains pre-registered payload templates
+	templates *Templates
+	// magic enables MessageMagic prefix of marshaled messages
+	magic bool
+	// format defines serialization of message body
+	format MessageFormat
+	// compressFirst enables compression of message body before encryption
+	compressFirst bool
+	// signer enables signing of marshaled messages, when defined
+	signer Signer
+	// verifier requires signed messages, when defined
+	verifier Verifier
+	// sequence is the next message sequence number, when enabled
+	sequence *uint64
+	// replayGuard rejects already processed messages, when defined
+	replayGuard ReplayGuard
+	// ttl enables message timestamps, when positive
+	ttl time.Duration
+	// clockSkew is tolerated difference of sender and receiver clocks
+	clockSkew time.Duration
+	// associatedData binds message header to ciphertext, see SetAssociatedData
+	associatedData bool
 
 	ed EncryptorDecryptor
 }
@@ -48,6 +85,16 @@ type Decryptor interface {
 	Decrypt(data []byte) ([]byte, error)
 }
 
+// Signer implements signing method for marshaled messages
+type Signer interface {
+	Sign(data []byte) ([]byte, error)
+}
+
+// Verifier implements signature verification method for marshaled messages
+type Verifier interface {
+	Verify(data, signature []byte) error
+}
+
 // EncryptorDecryptor provides encryption and decryption methods
 // for airgap session security
 type EncryptorDecryptor interface {
@@ -60,8 +107,31 @@ type Message struct {
 	Version    uint8
 	InstanceId []byte
 	Operations []*Operation
+	// Sequence is monotonic message number, see AirGap.SetSequence
+	Sequence uint64
+	// CreatedAt and ExpiresAt are message timestamps, see AirGap.SetMessageTTL
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
 	chunkSize  int
-	e          Encryptor
+	frameSize  int
+	chunksOpts chunksOptions
+	routingKey []byte
+	padding    []int
+	pairing    []byte
+	templates  *Templates
+	magic      bool
+	format     MessageFormat
+	// compressFirst enables compression of message body before encryption
+	compressFirst bool
+	signer        Signer
+	sequence      *uint64
+	ttl           time.Duration
+	// associatedData binds message header to ciphertext
+	associatedData bool
+	e              Encryptor
+	// cached contains chunks of the last marshaling, it is reset by methods,
+	// which change operations, see chunks
+	cached *Chunks
 }
 
 // Operation contains payload data for operation
@@ -70,6 +140,8 @@ type Operation struct {
 	OpCode uint16
 	Size   uint32
 	Data   []byte
+	// SignerId is instance id of verified operation author, see Message.VerifyOperations
+	SignerId []byte
 }
 
 // NewAirGap initiates a new AirGap instance with secp256k1 serialized compressed public key
@@ -92,28 +164,67 @@ func (a *AirGap) SetEncryptorDecryptor(ed EncryptorDecryptor) *AirGap {
 	return a
 }
 
+// EncryptorDecryptor returns current session encryptor, or nil
+func (a *AirGap) EncryptorDecryptor() EncryptorDecryptor {
+	return a.ed
+}
+
 func (a *AirGap) SetVersion(version uint8) {
 	a.version = version
 }
 
 func (a *AirGap) SetChunkSize(chunkSize int) {
 	a.chunkSize = chunkSize
+	a.frameSize = 0
 }
 
 func (a *AirGap) ChunkSize() int {
+	if a.frameSize > 0 {
+		return a.chunksOpts.frameChunkSize(a.frameSize)
+	}
 	return a.chunkSize
 }
 
+// SetFrameSize defines max length of the final encoded frame string, which
+// is what QR code capacity actually constrains. Overrides SetChunkSize.
+func (a *AirGap) SetFrameSize(frameSize int) {
+	a.frameSize = frameSize
+}
+
+// FrameSize returns max encoded frame length, or 0 when chunk size is used
+func (a *AirGap) FrameSize() int {
+	return a.frameSize
+}
+
+// SetFrameTag enables self-describing prefix for serialized frames,
+// see FrameTag
+func (a *AirGap) SetFrameTag(enabled bool) {
+	a.chunksOpts.tagged = enabled
+}
+
 // CreateMessage initiates new builder for AirGap messages batch
 func (a *AirGap) CreateMessage() *Message {
 	if a.instanceId == nil {
 		panic("instance id is not defined")
 	}
 	return &Message{
-		Version:    a.version,
-		InstanceId: a.instanceId,
-		chunkSize:  a.chunkSize,
-		e:          a.ed,
+		Version:        a.version,
+		InstanceId:     a.instanceId,
+		chunkSize:      a.chunkSize,
+		frameSize:      a.frameSize,
+		chunksOpts:     a.chunksOpts,
+		routingKey:     a.routingKey,
+		padding:        a.paddingBuckets,
+		pairing:        a.pairingSecret,
+		templates:      a.templates,
+		magic:          a.magic,
+		format:         a.format,
+		compressFirst:  a.compressFirst,
+		signer:         a.signer,
+		sequence:       a.sequence,
+		ttl:            a.ttl,
+		e:              a.ed,
+		associatedData: a.associatedData,
 	}
 }
 
@@ -123,94 +234,321 @@ func (m *Message) AddOperation(opCode uint16, data []byte) *Message {
 		Size:   uint32(len(data)),
 		Data:   data,
 	})
+	m.cached = nil
 	return m
 }
 
 func (m *Message) Marshal() ([]byte, error) {
-	result := make([]byte, 0)
-	result = append(result, m.Version)
-	result = append(result, m.InstanceId[:]...)
-	for i := range m.Operations {
-		// Allocate memory for serialized chunk
-		payload := make([]byte, operationPayloadOffset+m.Operations[i].Size)
+	result, err := m.marshal()
+	if err != nil {
+		return nil, err
+	}
 
-		// Serialize operation code
-		payload[0] = byte(m.Operations[i].OpCode >> 8)
-		payload[1] = byte(m.Operations[i].OpCode)
+	if m.magic {
+		result = appendMagic(m.Version, result)
+	}
 
-		// Serialize chunk size
-		payload[2] = byte(m.Operations[i].Size >> 24)
-		payload[3] = byte(m.Operations[i].Size >> 16)
-		payload[4] = byte(m.Operations[i].Size >> 8)
-		payload[5] = byte(m.Operations[i].Size)
+	if m.signer != nil {
+		return appendSignature(m.signer, result)
+	}
+	return result, nil
+}
 
-		// Serialize payload
-		copy(payload[operationPayloadOffset:], m.Operations[i].Data)
-		result = append(result, payload...)
+func (m *Message) marshal() ([]byte, error) {
+	instanceId := m.InstanceId
+	if m.pairing != nil {
+		var err error
+		instanceId, err = newAnonymousInstanceId(m.pairing, m.InstanceId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var result []byte
+	switch m.format {
+	case MessageFormatCBOR:
+		result = m.marshalCBOR(instanceId)
+	case MessageFormatProtobuf:
+		result = m.marshalProtobuf(instanceId)
+	default:
+		result = m.marshalBinary(instanceId)
+	}
+
+	if m.sequence != nil {
+		result = m.appendSequence(result)
+	}
+
+	if m.ttl > 0 {
+		result = m.appendTimestamps(result)
+	}
+
+	if int64(len(result)) > m.chunksOpts.payloadLimit() {
+		return nil, ErrPayloadTooLarge
+	}
+
+	var flag byte
+	if m.compressFirst {
+		var err error
+		flag, result, err = m.compressBody(result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := m.seal(result)
+	if err != nil || !m.compressFirst {
+		return result, err
+	}
+	return append([]byte{flag}, result...), nil
+}
+
+// seal pads and encrypts serialized message body, when enabled
+func (m *Message) seal(result []byte) ([]byte, error) {
+	if m.padding != nil {
+		if m.e == nil {
+			return nil, errPaddingWithoutEncryptor
+		}
+		result = padMessage(result, m.padding)
+	}
+
+	if m.routingKey != nil {
+		return m.sealPrivate(result)
 	}
 
 	if m.e != nil {
-		return m.e.Encrypt(result)
+		return m.encrypt(result)
 	}
 	return result, nil
 }
 
+// marshalBinary serializes version, instance id and operations
+func (m *Message) marshalBinary(instanceId []byte) []byte {
+	result := make([]byte, 0)
+	result = append(result, m.Version)
+	result = append(result, instanceId[:]...)
+	for i := range m.Operations {
+		// Serialize operation code and payload size
+		result = appendOperationHeader(result, m.Operations[i].OpCode, m.Operations[i].Size, m.chunksOpts.compact)
+
+		// Serialize payload
+		payload := make([]byte, m.Operations[i].Size)
+		copy(payload, m.Operations[i].Data)
+		result = append(result, payload...)
+	}
+	return result
+}
+
 func (m *Message) MarshalB64Chunks() ([]string, error) {
+	result, err := m.chunks()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.SerializeB64(), nil
+}
+
+// chunks marshals message and splits it to chunks. Chunks are cached until
+// operations are changed with Message methods, so repeated calls don't
+// recompress and re-encrypt and frames stay byte-identical. Exported fields
+// changed directly aren't tracked.
+func (m *Message) chunks() (*Chunks, error) {
+	if m.cached != nil {
+		return m.cached, nil
+	}
+
 	serializedMessages, err := m.Marshal()
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := NewChunks().SetData(serializedMessages, m.chunkSize)
+	chunkSize := m.chunkSize
+	if m.frameSize > 0 {
+		chunkSize = m.chunksOpts.frameChunkSize(m.frameSize)
+	}
 
+	result, err := m.NewChunks().SetData(serializedMessages, chunkSize)
 	if err != nil {
 		return nil, err
 	}
 
-	return result.SerializeB64(), nil
+	m.cached = result
+	return result, nil
+}
+
+// NewChunks returns empty Chunks with frame options of message, e.g. to split
+// payload of Marshal
+func (m *Message) NewChunks() *Chunks {
+	return &Chunks{opts: m.chunksOpts}
 }
 
 func (a *AirGap) Unmarshal(data []byte) (*Message, error) {
+	return a.UnmarshalWithDecryptor(data, a.ed)
+}
+
+// UnmarshalWithDecryptor unmarshals message with d instead of decryptor of
+// AirGap, e.g. decryptor wrapped by instrumentation
+func (a *AirGap) UnmarshalWithDecryptor(data []byte, d Decryptor) (*Message, error) {
+	// sequence of unauthenticated message is chosen by anyone
+	if a.replayGuard != nil && d == nil && a.verifier == nil {
+		return nil, errReplayGuardUnauthenticated
+	}
+
 	var err error
 
-	if a.ed != nil {
-		data, err = a.ed.Decrypt(data)
+	if a.verifier != nil {
+		data, err = a.trimSignature(data)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if len(data) < airGapMessageMinSize {
-		return nil, errors.New("go-airgap message to small")
+	if a.magic {
+		data, err = a.trimMagic(data)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	version := data[0]
-	instanceId := data[1:airGapMessagesOffset]
+	var flag byte
+	if a.compressFirst {
+		flag, data, err = trimPipelineFlag(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.routingKey != nil {
+		data, err = a.openPrivate(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if d != nil {
+		data, err = a.decrypt(d, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.paddingBuckets != nil {
+		if d == nil {
+			return nil, errPaddingWithoutEncryptor
+		}
+		data, err = unpadMessage(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.compressFirst {
+		data, err = a.uncompressBody(flag, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var createdAt, expiresAt time.Time
+	if a.ttl > 0 {
+		createdAt, expiresAt, data, err = a.trimTimestamps(data)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	if version != a.version {
-		if version < a.version {
-			return nil, errors.New("go-airgap message version less than supported")
+	var sequence uint64
+	if a.isSequenced() {
+		sequence, data, err = trimSequence(data)
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	message, err := a.unmarshalBody(data)
+	if err != nil {
+		return nil, err
+	}
 
-		if version > a.version {
-			return nil, errors.New("go-airgap message version greater than supported")
+	message.CreatedAt, message.ExpiresAt = createdAt, expiresAt
+	message.Sequence = sequence
+	if a.replayGuard != nil {
+		if err = a.replayGuard.Accept(a.instanceId, sequence); err != nil {
+			return nil, err
 		}
 	}
 
-	if !bytes.Equal(a.instanceId, instanceId) {
-		return nil, errors.New("go-airgap message has incorrect instance")
+	return message, nil
+}
+
+// unmarshalBody parses serialized message body
+func (a *AirGap) unmarshalBody(data []byte) (*Message, error) {
+	switch a.format {
+	case MessageFormatCBOR:
+		return a.unmarshalCBOR(data)
+	case MessageFormatProtobuf:
+		return a.unmarshalProtobuf(data)
+	}
+
+	minSize := airGapMessageMinSize
+	if a.chunksOpts.compact {
+		minSize = airGapMessagesOffset + 2
+	}
+
+	if len(data) < minSize {
+		return nil, errors.New("go-airgap message to small")
+	}
+
+	if err := a.verifyMessageHeader(data[0], data[1:airGapMessagesOffset]); err != nil {
+		return nil, err
 	}
+
 	message := a.CreateMessage()
 
-	bytesReaded := airGapMessagesOffset
+	for iter := airGapMessagesOffset; iter < len(data); {
+		opCode, size, headerSize, err := parseOperationHeader(data[iter:], a.chunksOpts.compact)
+		if err != nil {
+			return nil, err
+		}
 
-	for iter := bytesReaded; iter < len(data); iter += bytesReaded {
-		opCode := uint16(data[iter+1]) | uint16(data[iter])<<8
-		size := uint32(data[iter+5]) | uint32(data[iter+4])<<8 | uint32(data[iter+3])<<16 | uint32(data[iter+2])<<24
-		bytesReaded = operationPayloadOffset + int(size)
-		message.AddOperation(opCode, data[iter+6:iter+bytesReaded])
+		iter += headerSize
+		if uint64(size) > uint64(len(data)-iter) {
+			return nil, errors.New("go-airgap operation is truncated")
+		}
 
+		message.AddOperation(opCode, data[iter:iter+int(size)])
+		iter += int(size)
 	}
 
 	return message, nil
 }
+
+// verifyVersion checks protocol version of serialized message
+func (a *AirGap) verifyVersion(version uint8) error {
+	if version < a.version {
+		return errors.New("go-airgap message version less than supported")
+	}
+
+	if version > a.version {
+		return errors.New("go-airgap message version greater than supported")
+	}
+
+	return nil
+}
+
+// verifyMessageHeader checks version and instance of serialized message
+func (a *AirGap) verifyMessageHeader(version uint8, instanceId []byte) error {
+	if err := a.verifyVersion(version); err != nil {
+		return err
+	}
+
+	if a.pairingSecret != nil {
+		if !verifyAnonymousInstanceId(a.pairingSecret, a.instanceId, instanceId) {
+			return errors.New("go-airgap message has incorrect instance")
+		}
+	} else if !bytes.Equal(a.instanceId, instanceId) {
+		return errors.New("go-airgap message has incorrect instance")
+	}
+
+	return nil
+}
@@ -15,12 +15,11 @@
 package go_airgap
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"io"
+	"reflect"
 	"testing"
 )
 
@@ -31,51 +30,13 @@ const (
 	opCodeTest3    = 65535
 )
 
-type DummyEncryptorDecryptor struct {
-	key   []byte
-	nonce []byte
-}
-
-func NewDummyEncryptorDecryptor() *DummyEncryptorDecryptor {
-	nonce := make([]byte, 12)
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		panic(err.Error())
-	}
-
-	return &DummyEncryptorDecryptor{
-		key:   []byte(testPassphrase),
-		nonce: nonce,
-	}
-}
-
-func (ed *DummyEncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
-	block, err := aes.NewCipher(ed.key)
-
-	if err != nil {
-		return nil, err
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return aesGCM.Seal(nil, ed.nonce, data, nil), nil
-}
-
-func (ed *DummyEncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
-	block, err := aes.NewCipher(ed.key)
-	if err != nil {
-		panic(err.Error())
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
+// NewDummyEncryptorDecryptor returns AES-GCM encryptor with test passphrase
+func NewDummyEncryptorDecryptor() *AESGCMEncryptorDecryptor {
+	ed, err := NewAESGCMEncryptorDecryptor([]byte(testPassphrase))
 	if err != nil {
 		panic(err.Error())
 	}
-
-	return aesGCM.Open(nil, ed.nonce, data, nil)
+	return ed
 }
 
 func TestAirGap_CreateMessage(t *testing.T) {
@@ -139,3 +100,86 @@ func TestAirGap_CreateMessage(t *testing.T) {
 
 	t.Log(unserializedMessage)
 }
+
+func TestAirGap_SetFrameSize(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+	airGap.SetFrameSize(120)
+	airGap.SetFrameTag(true)
+
+	payload := make([]byte, 1024)
+	if _, err = rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	serializedChunks, err := airGap.CreateMessage().
+		AddOperation(opCodeTest1, payload).
+		MarshalB64Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unserializedChunks := NewChunks()
+
+	for i := range serializedChunks {
+		if len(serializedChunks[i]) > airGap.FrameSize() {
+			t.Fatalf("frame length %d exceeds %d", len(serializedChunks[i]), airGap.FrameSize())
+		}
+
+		if _, err = unserializedChunks.ReadB64Chunk(serializedChunks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unserializedMessage, err := airGap.Unmarshal(unserializedChunks.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(unserializedMessage.Operations[0].Data, payload) {
+		t.Fatal("mismatch operation data")
+	}
+}
+
+func TestMessage_MarshalB64ChunksCache(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	// anonymous instance id is random for every marshaling
+	airGap := NewAirGap(VersionDefault, instanceId).SetAnonymousMode([]byte("secret"))
+
+	message := airGap.CreateMessage().AddOperation(opCodeTest1, []byte("payload"))
+
+	frames, err := message.MarshalB64Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repeated, err := message.MarshalB64Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(frames, repeated) {
+		t.Fatal("frames of repeated marshaling are different")
+	}
+
+	message.AddOperation(opCodeTest2, []byte("payload"))
+
+	changed, err := message.MarshalB64Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(frames, changed) {
+		t.Fatal("frames of changed message are cached")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// ReadChunkAuto reads frame of any text encoding, so receiver doesn't need to
+// know encoding of sender in advance. Encoding is detected by prefix and
+// charset: BC-UR parts, FrameTag or URI prefixed base64, lowercase hex, base45
+// and base64. FrameEncoding and codec of receiver are ignored.
+func (ch *Chunks) ReadChunkAuto(frame string) (wasAdded bool, err error) {
+	frame = strings.NewReplacer("\r", "", "\n", "").Replace(frame)
+
+	if trimmed := strings.TrimSpace(frame); len(trimmed) >= len(urScheme) && strings.EqualFold(trimmed[:len(urScheme)], urScheme) {
+		return ch.ReadURPart(trimmed)
+	}
+
+	if ch.opts.uriScheme != "" {
+		if trimmed, err := ch.opts.trimURIScheme(frame); err == nil {
+			frame = trimmed
+		}
+	}
+
+	chunk, err := detectFrame(frame)
+	if err != nil {
+		ch.frameFailed(err)
+		return wasAdded, err
+	}
+
+	return ch.AddRawChunk(chunk)
+}
+
+// detectFrame decodes frame with encoding detected by prefix and charset
+func detectFrame(frame string) ([]byte, error) {
+	compact := normalizeFrame(frame)
+
+	if strings.HasPrefix(compact, FrameTag) {
+		compact = compact[len(FrameTag):]
+	} else if len(compact)%2 == 0 && isCharset(compact, "0123456789abcdef") {
+		if chunk, err := hex.DecodeString(compact); err == nil {
+			return chunk, nil
+		}
+	} else if isCharset(frame, base45Alphabet) {
+		// base64 frames without lowercase letters are decoded as base64
+		if chunk, err := decodeBase45(frame); err == nil {
+			return chunk, nil
+		}
+	}
+
+	chunk, err := decodeB64(compact)
+	if err != nil {
+		return nil, newFrameError("incorrect go-airgap message", FrameCheckBase64, -1, 0, 0)
+	}
+	return chunk, nil
+}
+
+// isCharset checks that every character of s belongs to charset
+func isCharset(s, charset string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(charset, s[i]) < 0 {
+			return false
+		}
+	}
+	return s != ""
+}
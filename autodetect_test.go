@@ -0,0 +1,68 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_ReadChunkAuto(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetFrameTag(true).SetData(payload, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b64 := sender.SerializeB64()
+	base45 := sender.SetEncoding(EncodingBase45).SerializeText()
+	hexFrames := sender.SetFrameCodec(CodecHex).SerializeText()
+
+	// every frame is received in another encoding
+	receiver := NewChunks()
+	for i := range b64 {
+		frame := []string{b64[i], base45[i], hexFrames[i]}[i%3]
+		if _, err = receiver.ReadChunkAuto(frame); err != nil {
+			t.Fatal(err, frame)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	encoder, err := sender.UREncoder(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver = NewChunks()
+	for !receiver.Complete() {
+		if _, err = receiver.ReadChunkAuto(encoder.NextPart()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	if _, err = NewChunks().ReadChunkAuto("not a frame!"); err == nil {
+		t.Fatal("incorrect frame is accepted")
+	}
+}
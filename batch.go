@@ -0,0 +1,229 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+)
+
+const (
+	batchHeaderOffset = 2 // stream_index(1) + streams_count(1)
+	maxBatchStreams   = 255
+
+	// BatchFrameTag is self-describing prefix of batch frames
+	BatchFrameTag = "AGB1:"
+)
+
+// Batch emits several independent messages as one interleaved frames stream,
+// so operator doesn't have to run separate scan session for every message.
+//
+// Batch frame is stream_index(1) + streams_count(1) + frame of stream chunks
+type Batch struct {
+	streams []batchStream
+}
+
+type batchStream struct {
+	chunks   *Chunks
+	priority int
+}
+
+// BatchReceiver demultiplexes batch frames to chunks of every stream
+type BatchReceiver struct {
+	mu      sync.RWMutex
+	count   uint8
+	streams []*Chunks
+	opts    chunksOptions
+}
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// AddChunks queues chunks of the message, every stream must have
+// the same frames options
+func (b *Batch) AddChunks(ch *Chunks) error {
+	return b.AddChunksWithPriority(ch, 1)
+}
+
+// AddChunksWithPriority queues chunks of the message, which are emitted
+// priority times more frequently than chunks with priority 1
+func (b *Batch) AddChunksWithPriority(ch *Chunks, priority int) error {
+	if len(b.streams) == maxBatchStreams {
+		return errors.New("max batch streams 255")
+	}
+
+	if priority < 1 {
+		return errors.New("batch priority must be positive")
+	}
+
+	b.streams = append(b.streams, batchStream{chunks: ch, priority: priority})
+	return nil
+}
+
+// AddMessage marshals and queues the message
+func (b *Batch) AddMessage(m *Message) error {
+	return b.AddMessageWithPriority(m, 1)
+}
+
+// AddMessageWithPriority marshals and queues the message with priority,
+// see AddChunksWithPriority
+func (b *Batch) AddMessageWithPriority(m *Message, priority int) error {
+	ch, err := m.chunks()
+	if err != nil {
+		return err
+	}
+
+	return b.AddChunksWithPriority(ch, priority)
+}
+
+// SerializeRaw represents interleaved frames of all streams without text encoding.
+// Every round stream emits priority frames, so urgent streams complete first.
+// Loop lasts until every stream emitted all frames, completed streams are repeated.
+func (b *Batch) SerializeRaw() [][]byte {
+	streamsFrames := make([][][]byte, len(b.streams))
+	emitted := make([]int, len(b.streams))
+	for i := range b.streams {
+		streamsFrames[i] = b.streams[i].chunks.SerializeRaw()
+	}
+
+	isLoopCompleted := func() bool {
+		for i := range streamsFrames {
+			if emitted[i] < len(streamsFrames[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var result [][]byte
+	for !isLoopCompleted() {
+		for stream := range streamsFrames {
+			for i := 0; i < b.streams[stream].priority && len(streamsFrames[stream]) > 0; i++ {
+				frame := streamsFrames[stream][emitted[stream]%len(streamsFrames[stream])]
+				emitted[stream]++
+
+				result = append(result, append(
+					[]byte{byte(stream), byte(len(b.streams))},
+					frame...,
+				))
+			}
+		}
+	}
+	return result
+}
+
+// SerializeB64 represents interleaved frames of all streams to strings array
+func (b *Batch) SerializeB64() []string {
+	var result []string
+	for _, frame := range b.SerializeRaw() {
+		encoded := base64.StdEncoding.EncodeToString(frame)
+		if b.streams[0].chunks.opts.tagged {
+			encoded = BatchFrameTag + encoded
+		}
+		result = append(result, encoded)
+	}
+	return result
+}
+
+// NewBatchReceiver initiates batch receiver with default frames options
+func NewBatchReceiver() *BatchReceiver {
+	return &BatchReceiver{}
+}
+
+// NewBatchReceiver initiates batch receiver with frames options of AirGap
+func (a *AirGap) NewBatchReceiver() *BatchReceiver {
+	return &BatchReceiver{opts: a.chunksOpts}
+}
+
+// ReadB64Chunk reads frame serialized with Batch.SerializeB64
+func (r *BatchReceiver) ReadB64Chunk(frame string) (stream uint8, wasAdded bool, err error) {
+	frame = strings.TrimPrefix(normalizeFrame(frame), BatchFrameTag)
+
+	chunk, err := decodeB64(frame)
+	if err != nil {
+		return stream, wasAdded, errors.New("incorrect go-airgap message")
+	}
+
+	return r.AddRawChunk(chunk)
+}
+
+// AddRawChunk ingests batch frame and returns index of its stream
+func (r *BatchReceiver) AddRawChunk(chunk []byte) (stream uint8, wasAdded bool, err error) {
+	if len(chunk) < batchHeaderOffset {
+		return stream, wasAdded, errors.New("go-airgap batch frame to small")
+	}
+
+	stream = chunk[0]
+	count := chunk[1]
+
+	r.mu.Lock()
+	if r.count == 0 {
+		r.count = count
+		r.streams = make([]*Chunks, count)
+		for i := range r.streams {
+			r.streams[i] = &Chunks{opts: r.opts}
+		}
+	}
+
+	if count != r.count || stream >= count {
+		r.mu.Unlock()
+		return stream, wasAdded, errors.New("go-airgap batch frame has incorrect stream")
+	}
+
+	streamChunks := r.streams[stream]
+	r.mu.Unlock()
+
+	wasAdded, err = streamChunks.AddRawChunk(chunk[batchHeaderOffset:])
+	return stream, wasAdded, err
+}
+
+// Count returns streams count of the batch, or 0 before first frame
+func (r *BatchReceiver) Count() uint8 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.count
+}
+
+// Stream returns chunks of the stream
+func (r *BatchReceiver) Stream(stream uint8) *Chunks {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if stream >= r.count {
+		return nil
+	}
+	return r.streams[stream]
+}
+
+// IsFilled checks that all streams are received
+func (r *BatchReceiver) IsFilled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.count == 0 {
+		return false
+	}
+
+	for i := range r.streams {
+		if r.streams[i].Count() == 0 || !r.streams[i].IsFilled() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+	airGap.SetFrameTag(true)
+
+	payloads := make([][]byte, 3)
+	batch := NewBatch()
+
+	for i := range payloads {
+		payloads[i] = make([]byte, 200*(i+1))
+		if _, err = rand.Read(payloads[i]); err != nil {
+			t.Fatal("cannot read random")
+		}
+
+		if err = batch.AddMessage(airGap.CreateMessage().AddOperation(opCodeTest1, payloads[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	receiver := airGap.NewBatchReceiver()
+
+	for _, frame := range batch.SerializeB64() {
+		if _, _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !receiver.IsFilled() || receiver.Count() != 3 {
+		t.Fatal("batch is not filled")
+	}
+
+	for i := range payloads {
+		message, err := airGap.Unmarshal(receiver.Stream(uint8(i)).Data())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, payloads[i]) {
+			t.Fatal("mismatch operation data")
+		}
+	}
+}
+
+func TestBatch_AddChunksWithPriority(t *testing.T) {
+	payload := make([]byte, 4096)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	bulk, err := NewChunks().SetData(payload, defaultChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urgent, err := NewChunks().SetData(payload, defaultChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := NewBatch()
+	if err = batch.AddChunks(bulk); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = batch.AddChunksWithPriority(urgent, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewBatchReceiver()
+
+	urgentCompleted := false
+	for _, frame := range batch.SerializeRaw() {
+		if _, _, err = receiver.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+
+		if receiver.Stream(1).IsFilled() && !urgentCompleted {
+			urgentCompleted = true
+			if receiver.Stream(0).Filled() >= receiver.Stream(0).Count()/2 {
+				t.Fatal("urgent stream is not prioritized")
+			}
+		}
+	}
+
+	if !receiver.IsFilled() {
+		t.Fatal("batch is not filled")
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// checksum constants of BIP-173 and BIP-350
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, value := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	result := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]>>5)
+	}
+	result = append(result, 0)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]&31)
+	}
+	return result
+}
+
+// convertBits regroups bits of data from fromBits to toBits groups
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var (
+		acc    uint32
+		bits   uint
+		result []byte
+	)
+
+	maxValue := uint32(1)<<toBits - 1
+	for _, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, errors.New("bech32 data has incorrect value")
+		}
+
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte(acc>>bits&maxValue))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			result = append(result, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		return nil, errors.New("bech32 data has incorrect padding")
+	}
+
+	return result, nil
+}
+
+// bech32Encode encodes data bytes with human-readable part hrp, checksum
+// constant selects bech32 or bech32m variant
+func bech32Encode(hrp string, data []byte, checksumConst uint32) (string, error) {
+	if len(hrp) < 1 || len(hrp) > 83 {
+		return "", errors.New("bech32 hrp must be 1-83 characters")
+	}
+
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 || (hrp[i] >= 'A' && hrp[i] <= 'Z') {
+			return "", errors.New("bech32 hrp has incorrect character")
+		}
+	}
+
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	polymod := bech32Polymod(append(append(bech32HrpExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ checksumConst
+	for i := 0; i < 6; i++ {
+		values = append(values, byte(polymod>>(5*(5-i))&31))
+	}
+
+	var result strings.Builder
+	result.WriteString(hrp)
+	result.WriteByte('1')
+	for _, value := range values {
+		result.WriteByte(bech32Charset[value])
+	}
+
+	return result.String(), nil
+}
+
+// bech32Decode decodes string of bech32Encode and verifies checksum, length
+// is not limited, so long frames are accepted
+func bech32Decode(encoded string, checksumConst uint32) (hrp string, data []byte, err error) {
+	lower := strings.ToLower(encoded)
+	if lower != encoded && strings.ToUpper(encoded) != encoded {
+		return "", nil, errors.New("bech32 string has mixed case")
+	}
+
+	separator := strings.LastIndexByte(lower, '1')
+	if separator < 1 || separator+7 > len(lower) {
+		return "", nil, errors.New("bech32 string has incorrect separator position")
+	}
+
+	hrp = lower[:separator]
+	values := make([]byte, 0, len(lower)-separator-1)
+	for i := separator + 1; i < len(lower); i++ {
+		value := strings.IndexByte(bech32Charset, lower[i])
+		if value < 0 {
+			return "", nil, errors.New("bech32 string has incorrect character")
+		}
+		values = append(values, byte(value))
+	}
+
+	if bech32Polymod(append(bech32HrpExpand(hrp), values...)) != checksumConst {
+		return "", nil, errors.New("bech32 checksum mismatch")
+	}
+
+	data, err = convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return hrp, data, nil
+}
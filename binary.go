@@ -0,0 +1,101 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// maxBinaryFrameSize limits frame length of ReadBinaryChunk
+const maxBinaryFrameSize = 1 << 20
+
+// SerializeBinary represents data frames as single stream of length-delimited
+// frames, every frame is prefixed with uvarint length. Stream is written as is
+// to files or serial links without base64 overhead.
+func (ch *Chunks) SerializeBinary() []byte {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	var result []byte
+	for _, frame := range ch.framesWithDecoys() {
+		result = appendUvarint(result, uint64(len(frame)))
+		result = append(result, frame...)
+	}
+	return result
+}
+
+// ReadBinaryChunk reads the next frame of SerializeBinary stream, io.EOF is
+// returned at the end of stream. Reader is not read beyond the frame.
+func (ch *Chunks) ReadBinaryChunk(r io.Reader) (wasAdded bool, err error) {
+	size, err := binary.ReadUvarint(byteReader{r})
+	if err == io.EOF {
+		return wasAdded, err
+	}
+
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return wasAdded, err
+		}
+		err = newFrameError("incorrect go-airgap message", FrameCheckLength, -1, 0, 0)
+		ch.frameFailed(err)
+		return wasAdded, err
+	}
+
+	if size > maxBinaryFrameSize {
+		err = newFrameError("go-airgap chunk too large", FrameCheckLength, -1, maxBinaryFrameSize, int(size))
+		ch.frameFailed(err)
+		return wasAdded, err
+	}
+
+	frame := make([]byte, size)
+	if _, err = io.ReadFull(r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return wasAdded, err
+	}
+
+	return ch.AddRawChunk(frame)
+}
+
+// byteReader reads reader byte by byte, so frame boundary is not crossed
+type byteReader struct {
+	r io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	if br, ok := b.r.(io.ByteReader); ok {
+		return br.ReadByte()
+	}
+
+	var buf [1]byte
+	if _, err := io.ReadFull(b.r, buf[:]); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// MarshalBinaryChunks marshals message to stream of length-delimited frames,
+// see Chunks.SerializeBinary
+func (m *Message) MarshalBinaryChunks() ([]byte, error) {
+	result, err := m.chunks()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.SerializeBinary(), nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+func TestChunks_SerializeBinary(t *testing.T) {
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := sender.SerializeBinary()
+
+	var size int
+	for _, frame := range sender.SerializeRaw() {
+		size += len(frame)
+	}
+
+	if len(stream) >= size*4/3 {
+		t.Fatal("incorrect stream size", len(stream))
+	}
+
+	receiver := NewChunks()
+	r := bytes.NewReader(stream)
+	for {
+		if _, err = receiver.ReadBinaryChunk(r); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	if _, err = NewChunks().ReadBinaryChunk(bytes.NewReader(stream[:len(stream)/2])); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewChunks().ReadBinaryChunk(bytes.NewReader(stream[:10])); err != io.ErrUnexpectedEOF {
+		t.Fatal("truncated frame is accepted", err)
+	}
+}
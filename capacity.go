@@ -0,0 +1,105 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// OpCodeScanCapacity is standard handshake operation, which receiver sends
+	// to sender to advertise max scannable frame size
+	OpCodeScanCapacity uint16 = 0xFF04
+
+	scanCapacitySize = 8 // frame_size(4) + chunk_size(4)
+)
+
+// ScanCapacity contains max frame size, which receiver scans reliably
+type ScanCapacity struct {
+	// FrameSize is max length of encoded frame string, 0 when unknown
+	FrameSize uint32
+	// ChunkSize is max size of binary frame for byte-mode transports, 0 when unknown
+	ChunkSize uint32
+}
+
+func (c *ScanCapacity) Marshal() []byte {
+	result := make([]byte, scanCapacitySize)
+	binary.BigEndian.PutUint32(result[0:], c.FrameSize)
+	binary.BigEndian.PutUint32(result[4:], c.ChunkSize)
+	return result
+}
+
+func UnmarshalScanCapacity(data []byte) (*ScanCapacity, error) {
+	if len(data) != scanCapacitySize {
+		return nil, errors.New("go-airgap scan capacity has incorrect size")
+	}
+
+	return &ScanCapacity{
+		FrameSize: binary.BigEndian.Uint32(data[0:]),
+		ChunkSize: binary.BigEndian.Uint32(data[4:]),
+	}, nil
+}
+
+// AddScanCapacity adds OpCodeScanCapacity operation
+func (m *Message) AddScanCapacity(capacity *ScanCapacity) *Message {
+	return m.AddOperation(OpCodeScanCapacity, capacity.Marshal())
+}
+
+// ScanCapacity returns the first OpCodeScanCapacity operation of message
+func (m *Message) ScanCapacity() (*ScanCapacity, error) {
+	for i := range m.Operations {
+		if m.Operations[i].OpCode == OpCodeScanCapacity {
+			return UnmarshalScanCapacity(m.Operations[i].Data)
+		}
+	}
+	return nil, errors.New("go-airgap message has no scan capacity")
+}
+
+// ApplyScanCapacity adjusts frame size or chunk size to capacity advertised
+// by receiver, settings are decreased only
+func (a *AirGap) ApplyScanCapacity(capacity *ScanCapacity) error {
+	if capacity.FrameSize > 0 {
+		frameSize := int(capacity.FrameSize)
+		if frameSize > 0xFFFF {
+			frameSize = 0xFFFF
+		}
+
+		if a.frameSize > 0 && a.frameSize <= frameSize {
+			return nil
+		}
+
+		if a.chunksOpts.frameChunkSize(frameSize) <= a.chunksOpts.frameOverhead() {
+			return errors.New("go-airgap scan capacity is too small")
+		}
+
+		if a.frameSize > 0 || a.chunksOpts.frameChunkSize(frameSize) < a.chunkSize {
+			a.SetFrameSize(frameSize)
+		}
+		return nil
+	}
+
+	if capacity.ChunkSize > 0 {
+		if int(capacity.ChunkSize) <= a.chunksOpts.frameOverhead() {
+			return errors.New("go-airgap scan capacity is too small")
+		}
+
+		if int64(capacity.ChunkSize) < int64(a.ChunkSize()) {
+			a.SetChunkSize(int(capacity.ChunkSize))
+		}
+	}
+
+	return nil
+}
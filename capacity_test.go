@@ -0,0 +1,87 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCapacity_ScanCapacity(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	capacity := &ScanCapacity{FrameSize: 120}
+
+	serialized, err := airGap.CreateMessage().AddScanCapacity(capacity).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := airGap.Unmarshal(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readedCapacity, err := message.ScanCapacity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(capacity, readedCapacity) {
+		t.Fatal("mismatch scan capacity")
+	}
+
+	if err = airGap.ApplyScanCapacity(readedCapacity); err != nil {
+		t.Fatal(err)
+	}
+
+	if airGap.FrameSize() != 120 || airGap.ChunkSize() >= defaultChunkSize {
+		t.Fatal("scan capacity is not applied", airGap.FrameSize(), airGap.ChunkSize())
+	}
+
+	// larger capacity doesn't increase frame size
+	if err = airGap.ApplyScanCapacity(&ScanCapacity{FrameSize: 2000}); err != nil || airGap.FrameSize() != 120 {
+		t.Fatal("frame size is increased")
+	}
+
+	if err = airGap.ApplyScanCapacity(&ScanCapacity{FrameSize: 4}); err == nil {
+		t.Fatal("too small capacity is applied")
+	}
+}
+
+func TestCapacity_ApplyChunkSize(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	if err = airGap.ApplyScanCapacity(&ScanCapacity{ChunkSize: 100}); err != nil || airGap.ChunkSize() != 100 {
+		t.Fatal("chunk size is not applied", airGap.ChunkSize())
+	}
+
+	if err = airGap.ApplyScanCapacity(&ScanCapacity{ChunkSize: 1000}); err != nil || airGap.ChunkSize() != 100 {
+		t.Fatal("chunk size is increased", airGap.ChunkSize())
+	}
+}
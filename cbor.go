@@ -0,0 +1,136 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+)
+
+// MessageFormat is serialization of message body
+type MessageFormat uint8
+
+const (
+	// MessageFormatBinary is default layout of version, instance id and
+	// operations with binary headers
+	MessageFormatBinary MessageFormat = iota
+	// MessageFormatCBOR is canonical CBOR array
+	// [version: uint, instance: bytes, [* [opcode: uint, data: bytes]]]
+	MessageFormatCBOR
+	// MessageFormatProtobuf is protobuf message goairgap.v1.Message defined
+	// in proto/airgap.proto
+	MessageFormatProtobuf
+)
+
+const cborMessageFieldsCount = 3
+
+// SetMessageFormat defines serialization of message body, receiver must use
+// the same format
+func (a *AirGap) SetMessageFormat(format MessageFormat) *AirGap {
+	a.format = format
+	return a
+}
+
+// marshalCBOR serializes message with canonical CBOR, every item has the
+// shortest definite length
+func (m *Message) marshalCBOR(instanceId []byte) []byte {
+	result := cborAppendHead(nil, cborArray, cborMessageFieldsCount)
+	result = cborAppendHead(result, cborUnsigned, uint64(m.Version))
+	result = cborAppendHead(result, cborBytes, uint64(len(instanceId)))
+	result = append(result, instanceId...)
+
+	result = cborAppendHead(result, cborArray, uint64(len(m.Operations)))
+	for _, op := range m.Operations {
+		payload := make([]byte, op.Size)
+		copy(payload, op.Data)
+
+		result = cborAppendHead(result, cborArray, 2)
+		result = cborAppendHead(result, cborUnsigned, uint64(op.OpCode))
+		result = cborAppendHead(result, cborBytes, uint64(len(payload)))
+		result = append(result, payload...)
+	}
+	return result
+}
+
+func (a *AirGap) unmarshalCBOR(data []byte) (*Message, error) {
+	major, count, data, err := cborReadHead(data)
+	if err != nil || major != cborArray || count != cborMessageFieldsCount {
+		return nil, errors.New("go-airgap message has incorrect CBOR header")
+	}
+
+	major, version, data, err := cborReadHead(data)
+	if err != nil || major != cborUnsigned || version > 0xFF {
+		return nil, errors.New("go-airgap message has incorrect version")
+	}
+
+	instanceId, data, err := cborReadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.verifyMessageHeader(uint8(version), instanceId); err != nil {
+		return nil, err
+	}
+
+	major, count, data, err = cborReadHead(data)
+	if err != nil || major != cborArray || count > uint64(len(data)) {
+		return nil, errors.New("go-airgap message has incorrect operations")
+	}
+
+	message := a.CreateMessage()
+
+	for i := uint64(0); i < count; i++ {
+		var (
+			fields uint64
+			opCode uint64
+		)
+
+		major, fields, data, err = cborReadHead(data)
+		if err != nil || major != cborArray || fields != 2 {
+			return nil, errors.New("go-airgap operation has incorrect CBOR header")
+		}
+
+		major, opCode, data, err = cborReadHead(data)
+		if err != nil || major != cborUnsigned || opCode > 0xFFFF {
+			return nil, errors.New("go-airgap operation has incorrect code")
+		}
+
+		var payload []byte
+		if payload, data, err = cborReadBytes(data); err != nil {
+			return nil, err
+		}
+
+		message.AddOperation(uint16(opCode), payload)
+	}
+
+	if len(data) != 0 {
+		return nil, errors.New("go-airgap message has trailing data")
+	}
+
+	return message, nil
+}
+
+// cborReadBytes reads CBOR byte string and returns the rest of data
+func cborReadBytes(data []byte) (value, rest []byte, err error) {
+	major, size, data, err := cborReadHead(data)
+	if err != nil || major != cborBytes {
+		return nil, nil, errors.New("go-airgap CBOR byte string expected")
+	}
+
+	if size > uint64(len(data)) {
+		return nil, nil, errors.New("go-airgap operation is truncated")
+	}
+
+	return data[:size], data[size:], nil
+}
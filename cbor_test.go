@@ -0,0 +1,77 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestAirGap_SetMessageFormat(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	airGap := NewAirGap(VersionDefault, instanceId).SetMessageFormat(MessageFormatCBOR)
+
+	data, err := airGap.CreateMessage().
+		AddOperation(opCodeTest1, []byte("payload")).
+		AddOperation(0xFF01, nil).
+		Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// [1, h'<instance>', [[opCodeTest1, h'payload'], [65281, h'']]]
+	expected := "830158" + "21" + hex.EncodeToString(instanceId) + "82" +
+		"82" + hex.EncodeToString(cborAppendHead(nil, cborUnsigned, uint64(opCodeTest1))) + "47" + hex.EncodeToString([]byte("payload")) +
+		"8219ff0140"
+	if hex.EncodeToString(data) != expected {
+		t.Fatal("incorrect CBOR message", hex.EncodeToString(data))
+	}
+
+	message, err := airGap.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(message.Operations) != 2 || message.Operations[0].OpCode != opCodeTest1 ||
+		!bytes.Equal(message.Operations[0].Data, []byte("payload")) || message.Operations[1].OpCode != 0xFF01 {
+		t.Fatal("incorrect unmarshaled message")
+	}
+
+	if _, err = airGap.Unmarshal(append(data, 0)); err == nil {
+		t.Fatal("message with trailing data is accepted")
+	}
+
+	if _, err = airGap.Unmarshal(data[:len(data)-3]); err == nil {
+		t.Fatal("truncated message is accepted")
+	}
+
+	if _, err = NewAirGap(VersionDefault, instanceId).Unmarshal(data); err == nil {
+		t.Fatal("CBOR message is accepted by binary format receiver")
+	}
+
+	if _, err = airGap.NewProgressiveDispatcher(func(op *Operation) error { return nil }); err == nil {
+		t.Fatal("progressive dispatch of CBOR messages is accepted")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksummer implements checksum algorithm for chunk and message integrity.
+// Additional algorithms are implemented in checksum package.
+type Checksummer interface {
+	// Size returns checksum size in bytes
+	Size() int
+	Checksum(data []byte) []byte
+}
+
+// CRC32C implements Checksummer with CRC-32 Castagnoli, which is hardware
+// accelerated on modern amd64 and arm64 processors
+type CRC32C struct{}
+
+func (CRC32C) Size() int {
+	return crc32.Size
+}
+
+func (CRC32C) Checksum(data []byte) []byte {
+	result := make([]byte, crc32.Size)
+	binary.BigEndian.PutUint32(result, crc32.Checksum(data, crc32cTable))
+	return result
+}
+
+// SetChecksummer defines checksum algorithm of integrity features, CRC32C by default
+func (a *AirGap) SetChecksummer(checksummer Checksummer) *AirGap {
+	a.checksummer = checksummer
+	if a.chunksOpts.checksum != nil {
+		a.chunksOpts.checksum = a.Checksummer()
+	}
+	return a
+}
+
+// SetChunkChecksum enables checksum of every frame with checksummer,
+// so receiver rejects frames garbled by partial scans. Nil disables checksum.
+func (ch *Chunks) SetChunkChecksum(checksummer Checksummer) *Chunks {
+	ch.opts.checksum = checksummer
+	return ch
+}
+
+// SetChunkChecksum enables checksum of every frame with Checksummer
+func (a *AirGap) SetChunkChecksum(enabled bool) *AirGap {
+	a.chunksOpts.checksum = nil
+	if enabled {
+		a.chunksOpts.checksum = a.Checksummer()
+	}
+	return a
+}
+
+// sealFrame encrypts frame with index, prepends transfer id and appends chunk
+// checksum and authentication tag to frame
+func (ch *Chunks) sealFrame(index uint32, frame []byte) []byte {
+	if ch.frameCipher != nil {
+		frame = ch.encryptFrame(index, frame)
+	}
+
+	if ch.opts.transferId {
+		frame = append(append([]byte{}, ch.transferId...), frame...)
+	}
+
+	if ch.opts.checksum != nil {
+		frame = append(frame, ch.opts.checksum.Checksum(frame)...)
+	}
+
+	if ch.opts.frameKey != nil {
+		frame = append(frame, frameAuthTag(ch.opts.frameKey, frame)...)
+	}
+	return frame
+}
+
+func (o chunksOptions) checksumSize() int {
+	if o.checksum == nil {
+		return 0
+	}
+	return o.checksum.Size()
+}
+
+// verifyChunkChecksum returns frame without checksum
+func (o chunksOptions) verifyChunkChecksum(frame []byte) ([]byte, error) {
+	size := o.checksum.Size()
+	payload := frame[:len(frame)-size]
+
+	if !bytes.Equal(frame[len(payload):], o.checksum.Checksum(payload)) {
+		return nil, newFrameError("go-airgap chunk has incorrect checksum",
+			FrameCheckChecksum, -1, 0, 0)
+	}
+	return payload, nil
+}
+
+func (a *AirGap) Checksummer() Checksummer {
+	if a.checksummer == nil {
+		return CRC32C{}
+	}
+	return a.checksummer
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checksum implements additional go-airgap checksum algorithms,
+// e.g. for embedded receivers with hardware acceleration of the algorithm.
+package checksum
+
+import (
+	"encoding/binary"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2s"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+var (
+	_ airgap.Checksummer = XXHash64{}
+	_ airgap.Checksummer = BLAKE2s256Trunc16{}
+)
+
+// XXHash64 implements go_airgap.Checksummer with non-cryptographic xxHash64
+type XXHash64 struct{}
+
+func (XXHash64) Size() int {
+	return 8
+}
+
+func (XXHash64) Checksum(data []byte) []byte {
+	result := make([]byte, 8)
+	binary.BigEndian.PutUint64(result, xxhash.Sum64(data))
+	return result
+}
+
+// BLAKE2s256Trunc16 implements go_airgap.Checksummer with BLAKE2s-256 truncated
+// to 16 bytes. It differs from BLAKE2s with 16 bytes digest, which parameter
+// block defines another digest length, so receiver must truncate BLAKE2s-256.
+type BLAKE2s256Trunc16 struct{}
+
+func (BLAKE2s256Trunc16) Size() int {
+	return 16
+}
+
+func (BLAKE2s256Trunc16) Checksum(data []byte) []byte {
+	digest := blake2s.Sum256(data)
+	return digest[:16]
+}
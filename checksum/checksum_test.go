@@ -0,0 +1,44 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checksum
+
+import (
+	"encoding/hex"
+	"testing"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+func TestChecksummers(t *testing.T) {
+	tests := []struct {
+		checksummer airgap.Checksummer
+		expected    string
+	}{
+		{XXHash64{}, "ef46db3751d8e999"},
+		{BLAKE2s256Trunc16{}, "69217a3079908094e11121d042354a7c"},
+	}
+
+	for _, test := range tests {
+		sum := test.checksummer.Checksum(nil)
+
+		if len(sum) != test.checksummer.Size() {
+			t.Fatal("incorrect checksum size", len(sum))
+		}
+
+		if hex.EncodeToString(sum) != test.expected {
+			t.Fatalf("incorrect checksum %x", sum)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/censync/go-airgap/checksum
+
+go 1.18
+
+require (
+	github.com/censync/go-airgap v0.1.0
+	github.com/cespare/xxhash/v2 v2.1.2
+	golang.org/x/crypto v0.7.0
+)
+
+require golang.org/x/sys v0.6.0 // indirect
@@ -0,0 +1,99 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestCRC32C(t *testing.T) {
+	// RFC 3720 B.4, 32 bytes of zeroes
+	if sum := (CRC32C{}).Checksum(make([]byte, 32)); !bytes.Equal(sum, []byte{0x8a, 0x91, 0x36, 0xaa}) {
+		t.Fatalf("incorrect checksum %x", sum)
+	}
+}
+
+func TestAirGap_SetChecksummer(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	if _, ok := airGap.Checksummer().(CRC32C); !ok {
+		t.Fatal("incorrect default checksummer")
+	}
+
+	airGap.SetProfile(Profile{Name: "test", FrameSize: 600, Checksummer: testChecksummer{}})
+
+	if _, ok := airGap.Checksummer().(testChecksummer); !ok {
+		t.Fatal("profile checksummer is not applied")
+	}
+}
+
+type testChecksummer struct{}
+
+func (testChecksummer) Size() int {
+	return 1
+}
+
+func (testChecksummer) Checksum(data []byte) []byte {
+	var sum byte
+	for _, b := range data {
+		sum ^= b
+	}
+	return []byte{sum}
+}
+
+func TestChunks_SetChunkChecksum(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	for _, parity := range []int{0, 2} {
+		sender, err := NewChunks().SetChunkChecksum(CRC32C{}).SetParity(parity).SetFrameKey([]byte("key")).SetDecoyFrames(2).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewChunks().SetChunkChecksum(CRC32C{}).SetParity(parity).SetFrameKey([]byte("key"))
+		for _, frame := range sender.SerializeRaw() {
+			if _, err = receiver.ReadRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+
+	sender, err := NewChunks().SetChunkChecksum(testChecksummer{}).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := sender.SerializeRaw()[0]
+	frame[10] ^= 0x01
+
+	_, err = NewChunks().SetChunkChecksum(testChecksummer{}).ReadRawChunk(frame)
+	if frameErr, ok := err.(*FrameError); !ok || frameErr.Check != FrameCheckChecksum {
+		t.Fatal("corrupted frame is accepted", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	chunkMessageIdSize = 8
+	chunkNonceSize     = chunkMessageIdSize + 4 // message_id(8) + frame_index(4)
+	chunkTagSize       = 16
+)
+
+// SetChunkKey enables AES-GCM encryption of every frame with key of 16, 24 or
+// 32 bytes, so partially captured animation leaks nothing decryptable and
+// receiver authenticates frames as they arrive. Nonce is random message id
+// and frame index, it's prepended to encrypted frame. Receiver must use the same key.
+func (ch *Chunks) SetChunkKey(key []byte) *Chunks {
+	ch.opts.chunkKey = key
+	ch.frameOpener = nil
+	return ch
+}
+
+// SetChunkKey enables encryption of every frame, see Chunks.SetChunkKey
+func (a *AirGap) SetChunkKey(key []byte) *AirGap {
+	a.chunksOpts.chunkKey = key
+	return a
+}
+
+func (o chunksOptions) chunkCipherSize() int {
+	if o.chunkKey == nil {
+		return 0
+	}
+	return chunkNonceSize + chunkTagSize
+}
+
+func (o chunksOptions) chunkAEAD() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(o.chunkKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newFrameCipher returns frames cipher and random message id of sender, or
+// nil when frames encryption is disabled
+func (o chunksOptions) newFrameCipher() (cipher.AEAD, []byte, error) {
+	if o.chunkKey == nil {
+		return nil, nil, nil
+	}
+
+	aead, err := o.chunkAEAD()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messageId := make([]byte, chunkMessageIdSize)
+	if _, err = io.ReadFull(rand.Reader, messageId); err != nil {
+		return nil, nil, err
+	}
+
+	return aead, messageId, nil
+}
+
+// encryptFrame returns nonce and encrypted frame with index
+func (ch *Chunks) encryptFrame(index uint32, frame []byte) []byte {
+	nonce := make([]byte, chunkNonceSize, chunkNonceSize+len(frame)+chunkTagSize)
+	copy(nonce, ch.messageId)
+	binary.BigEndian.PutUint32(nonce[chunkMessageIdSize:], index)
+
+	return ch.frameCipher.Seal(nonce, nonce, frame, nil)
+}
+
+// decryptFrame authenticates and decrypts frame, returns message id of frame
+func (ch *Chunks) decryptFrame(frame []byte) (result, messageId []byte, err error) {
+	if ch.frameOpener == nil {
+		if ch.frameOpener, err = ch.opts.chunkAEAD(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	result, err = ch.frameOpener.Open(nil, frame[:chunkNonceSize], frame[chunkNonceSize:], nil)
+	if err != nil {
+		return nil, nil, newFrameError("go-airgap chunk cannot be decrypted",
+			FrameCheckDecryption, -1, 0, 0)
+	}
+	return result, frame[:chunkMessageIdSize], nil
+}
+
+// verifyMessageId pins message id of the first decrypted frame, so frames of
+// another message encrypted with the same key are rejected. Message id is
+// changed only by resized sender.
+func (ch *Chunks) verifyMessageId(messageId []byte, isResized bool) error {
+	if messageId == nil {
+		return nil
+	}
+
+	if ch.openedMessageId == nil || isResized {
+		ch.openedMessageId = append([]byte{}, messageId...)
+	} else if !bytes.Equal(ch.openedMessageId, messageId) {
+		return newFrameError("go-airgap chunk has incorrect message id",
+			FrameCheckDecryption, -1, 0, 0)
+	}
+	return nil
+}
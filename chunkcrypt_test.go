@@ -0,0 +1,122 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetChunkKey(t *testing.T) {
+	key := []byte(testPassphrase)
+
+	payload := bytes.Repeat([]byte("secret payload "), 300)
+
+	senders := []*Chunks{
+		NewChunks().SetChunkKey(key).SetCompressor(CompressorNone),
+		NewChunks().SetChunkKey(key).SetCompressor(CompressorNone).SetTransferId(true).SetChunkChecksum(CRC32C{}),
+		NewChunks().SetChunkKey(key).SetCompressor(CompressorNone).SetParity(4).SetCompactHeaders(true),
+		NewChunks().SetChunkKey(key).SetCompressor(CompressorNone).SetFrameKey(key).SetDecoyFrames(5),
+	}
+
+	for _, sender := range senders {
+		chunks, err := sender.SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frames := chunks.SerializeRaw()
+		receiver := &Chunks{opts: sender.opts}
+
+		for i, frame := range frames {
+			if len(frame) > 200 {
+				t.Fatal("frame exceeds chunk size", len(frame))
+			}
+
+			if bytes.Contains(frame, []byte("secret")) {
+				t.Fatal("frame is not encrypted")
+			}
+
+			// parity frames recover the first chunk
+			if i == 0 && sender.opts.parity > 0 {
+				continue
+			}
+
+			if _, err = receiver.AddRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+
+	chunks, err := NewChunks().SetChunkKey(key).SetCompressor(CompressorNone).SetTransferId(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stranger := make([]byte, 32)
+	_, _ = rand.Read(stranger)
+
+	_, err = NewChunks().SetChunkKey(stranger).SetTransferId(true).AddRawChunk(chunks.SerializeRaw()[0])
+	if frameErr, ok := err.(*FrameError); !ok || frameErr.Check != FrameCheckDecryption {
+		t.Fatal("frame is decrypted with incorrect key", err)
+	}
+
+	// resized frames get another message id, so nonces aren't reused
+	resized, err := chunks.Resize(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(resized.SerializeRaw()[0][:chunkNonceSize], chunks.SerializeRaw()[0][:chunkNonceSize]) {
+		t.Fatal("nonce is reused by resized chunks")
+	}
+
+	// frames of another message encrypted with the same key are rejected
+	another, err := NewChunks().SetChunkKey(key).SetCompressor(CompressorNone).SetTransferId(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	another.transferId = chunks.transferId
+
+	receiver := NewChunks().SetChunkKey(key).SetCompressor(CompressorNone).SetTransferId(true)
+	if _, err = receiver.AddRawChunk(chunks.SerializeRaw()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = receiver.AddRawChunk(another.SerializeRaw()[1])
+	if frameErr, ok := err.(*FrameError); !ok || frameErr.Check != FrameCheckDecryption {
+		t.Fatal("frame of another message is accepted", err)
+	}
+
+	// receiver continues transfer of resized sender
+	for _, frame := range resized.SerializeRaw()[2:] {
+		if _, err = receiver.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect payload of resized transfer")
+	}
+
+	if _, err = NewChunks().SetChunkKey([]byte("short key")).SetData(payload, 200); err == nil {
+		t.Fatal("incorrect chunk key is accepted")
+	}
+}
@@ -15,37 +15,173 @@
 package go_airgap
 
 import (
-	"bytes"
-	"compress/gzip"
+	"crypto/cipher"
 	"encoding/base64"
+	"encoding/binary"
 	"errors"
-	"fmt"
-	"io"
+	"strings"
 	"sync"
+	"time"
 )
 
 const (
-	chunkHeaderOffset = 6 // chunk_index(2) + chunks_count(2) + chunk_size(2)
+	chunkHeaderOffset = 6  // chunk_index(2) + chunks_count(2) + chunk_size(2)
+	wideHeaderOffset  = 10 // chunk_index(4) + chunks_count(4) + chunk_size(2)
+	maxChunksCount    = 0xFFFF
 	minChunkSize      = chunkHeaderOffset
 	defaultChunkSize  = 192 // best size for terminal
 
-	maxPayloadSize = (2<<15 - 1) * (2<<15 - 1) // ~ 12.58Mb
+	maxPayloadSize int64 = (2<<15 - 1) * (2<<15 - 1) // ~ 4Gb
+
+	// FrameTag is self-describing prefix of go-airgap frames v1 in base64 encoding
+	FrameTag = "AG1:"
+
+	frameTagMarker    = "AG"
+	frameTagSeparator = ":"
+)
+
+var (
+	// ErrIncomplete is returned for payload, which chunks are not received yet
+	ErrIncomplete = errors.New("go-airgap chunks are incomplete")
+	// ErrCorrupted is returned for payload, which cannot be reassembled
+	ErrCorrupted = errors.New("go-airgap payload is corrupted")
 )
 
 type Chunks struct {
 	mu     sync.RWMutex
-	count  uint16
+	count  uint32
 	size   uint16
-	filled uint16
-	data   [][]byte
+	filled uint32
+	opts   chunksOptions
+
+	storage ChunkStorage
+	// received is size of stored chunks
+	received int
+	// parityFrames contains received parity frames of incomplete groups
+	parityFrames map[int]parityFrame
+	// fountain contains state of fountain frames decoding
+	fountain *fountainDecoder
+	// transferId is random id of transfer, when enabled
+	transferId []byte
+	// frameCipher and messageId encrypt frames of sender, when enabled
+	frameCipher cipher.AEAD
+	messageId   []byte
+	// frameOpener and openedMessageId decrypt frames of receiver, message id
+	// of the first decrypted frame is pinned
+	frameOpener     cipher.AEAD
+	openedMessageId []byte
+
+	// ingest contains recent ingested chunks for throughput estimation
+	ingest []ingestEvent
+	clock  func() time.Time
+
+	observer Observer
+	// onChunkReceived is called after every new chunk
+	onChunkReceived func(received, total uint32)
+	// stats contains counters of received frames
+	stats ReceiverStats
+	// merkleTree contains levels of merkle tree of sender
+	merkleTree [][][]byte
+	// merkleRoot is trusted or the first received merkle root
+	merkleRoot    []byte
+	merkleTrusted bool
+	// ur contains state of BC-UR parts decoding
+	ur *URDecoder
+}
+
+// chunksOptions defines frames serialization, shared by sender and receiver
+type chunksOptions struct {
+	// tagged prefixes frames with FrameTag
+	tagged bool
+	// frameKey enables frames authentication tag
+	frameKey []byte
+	// decoys is count of dummy frames injected by sender
+	decoys int
+	// compact enables varint encoding of frame and operation headers
+	compact bool
+	// encoding defines text encoding of SerializeText frames
+	encoding FrameEncoding
+	// multibase prefixes SerializeText frames with encoding identifier
+	multibase bool
+	// codec overrides encoding of SerializeText frames, when defined
+	codec FrameCodec
+	// base64URL enables URL-safe base64 without padding
+	base64URL bool
+	// compressor defines payload compression, gzip when undefined
+	compressor Compressor
+	// skipIncompressible prefixes payload with compression flag
+	skipIncompressible bool
+	// uriScheme prefixes frames with URI, when defined
+	uriScheme string
+	// redundancy is count of every chunk copies per loop
+	redundancy int
+	// parity is count of chunks in XOR parity group
+	parity int
+	// fountain enables fountain coding of frames
+	fountain bool
+	// checksum enables per-chunk integrity checksum, when defined
+	checksum Checksummer
+	// digest enables SHA-256 digest of compressed payload
+	digest bool
+	// transferId enables random transfer id in frames
+	transferId bool
+	// wide enables 32-bit chunk index and chunks count
+	wide bool
+	// shuffle enables pseudo-random frames order
+	shuffle bool
+	// maxPayload limits payload size, when positive
+	maxPayload int64
+	// maxDecompressed limits decompressed payload size, when positive
+	maxDecompressed int64
+	// merkle enables merkle proofs in data frames
+	merkle bool
+	// chunkKey enables encryption of every frame, when defined
+	chunkKey []byte
+}
+
+// frameOverhead returns min size of frame fields besides chunk payload
+func (o chunksOptions) frameOverhead() int {
+	overhead := chunkHeaderOffset
+	if o.wide {
+		overhead = wideHeaderOffset
+	}
+	if o.compact {
+		overhead = compactHeaderMinSize
+	}
+	if o.fountain {
+		overhead = fountainHeaderSize
+	}
+	overhead += o.transferIdSize() + o.checksumSize() + o.chunkCipherSize()
+	if o.frameKey != nil {
+		overhead += frameAuthTagSize
+	}
+	return overhead
 }
 
 func NewChunks() *Chunks {
 	return &Chunks{}
 }
 
+// SetFrameTag enables FrameTag prefix for frames serialized with SerializeB64
+func (ch *Chunks) SetFrameTag(enabled bool) *Chunks {
+	ch.opts.tagged = enabled
+	return ch
+}
+
+// HasFrameTag checks that frame has go-airgap self-describing prefix,
+// which distinguishes it from arbitrary QR content
+func HasFrameTag(frame string) bool {
+	return strings.HasPrefix(frame, frameTagMarker) && strings.Contains(frame, frameTagSeparator)
+}
+
+// ChunkSizeForFrame returns max chunk size, which serialized with SerializeB64
+// fits to frameSize characters
+func ChunkSizeForFrame(frameSize int) int {
+	return frameSize / 4 * 3
+}
+
 func (ch *Chunks) SetData(src []byte, chunkSize int) (*Chunks, error) {
-	if chunkSize < minChunkSize {
+	if chunkSize <= ch.opts.frameOverhead() {
 		return nil, errors.New("min chunk size 32")
 	}
 
@@ -53,105 +189,207 @@ func (ch *Chunks) SetData(src []byte, chunkSize int) (*Chunks, error) {
 		return nil, errors.New("max chunk size 65531")
 	}
 
-	chunkSize -= chunkHeaderOffset
+	if int64(len(src)) > ch.opts.payloadLimit() {
+		return nil, ErrPayloadTooLarge
+	}
+
+	if err := ch.opts.verifyMerkleOptions(); err != nil {
+		return nil, err
+	}
 
-	compressedData, err := compress(src)
+	compressedData, err := ch.opts.compress(src)
 
 	if err != nil {
 		return nil, err
 	}
 
+	compressedData = ch.opts.appendPayloadDigest(compressedData)
+
+	chunkSize = ch.opts.chunkPayloadSize(len(compressedData), chunkSize)
+
+	if chunkSize <= 0 {
+		return nil, errors.New("min chunk size 32")
+	}
+
+	data := splitChunks(compressedData, chunkSize)
+
+	if err = ch.opts.verifyChunksCount(len(data)); err != nil {
+		return nil, err
+	}
+
+	var transferId []byte
+	if ch.opts.transferId {
+		if transferId, err = newTransferId(); err != nil {
+			return nil, err
+		}
+	}
+
+	frameCipher, messageId, err := ch.opts.newFrameCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Chunks{
+		count:      uint32(len(data)),
+		filled:     uint32(len(data)),
+		size:       uint16(chunkSize),
+		opts:       ch.opts,
+		storage:    &memoryStorage{data: data},
+		transferId: transferId,
+
+		frameCipher: frameCipher,
+		messageId:   messageId,
+	}
+
+	if err := result.buildMerkleTree(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// splitChunks splits data to chunks of chunkSize, the last chunk may be shorter
+func splitChunks(src []byte, chunkSize int) [][]byte {
 	data := make([][]byte, 0)
-	for iter := 0; iter < len(compressedData); iter += chunkSize {
+	for iter := 0; iter < len(src); iter += chunkSize {
 
-		payloadSize := len(compressedData[iter:])
+		payloadSize := len(src[iter:])
 
 		chunk := make([]byte, 0)
 		if payloadSize >= chunkSize {
 			chunk = make([]byte, chunkSize)
-			copy(chunk, compressedData[iter:iter+chunkSize])
+			copy(chunk, src[iter:iter+chunkSize])
 		} else {
 			chunk = make([]byte, payloadSize)
-			copy(chunk, compressedData[iter:])
+			copy(chunk, src[iter:])
 		}
 
 		data = append(data, chunk)
 	}
-
-	return &Chunks{
-		count: uint16(len(data)),
-		size:  uint16(chunkSize),
-		data:  data,
-	}, nil
+	return data
 }
 
-func compress(src []byte) ([]byte, error) {
-	var buf bytes.Buffer
-	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
-
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot compress data: %s", err.Error()))
+func (ch *Chunks) getChunkWithHeader(index uint32) []byte {
+	if ch.opts.fountain {
+		// the first count fountain frames contain single chunks
+		return ch.fountainFrame(index)
 	}
 
-	_, err = zw.Write(src)
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot write compressed data: %s", err.Error()))
-	}
+	data, _ := ch.storage.Get(int(index))
+
+	header := ch.chunkHeader(index)
+	chunk := make([]byte, len(header)+int(ch.size))
+	copy(chunk, header)
+	copy(chunk[len(header):], data)
 
-	if err = zw.Close(); err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot close writer: %s", err.Error()))
+	if ch.opts.merkle {
+		chunk = append(chunk, ch.merkleProof(index)...)
 	}
 
-	return buf.Bytes(), nil
+	return ch.sealFrame(index, chunk)
 }
 
-func uncompress(src []byte) ([]byte, error) {
-	reader := bytes.NewReader(src)
-
-	zr, err := gzip.NewReader(reader)
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot uncompress data: %s", err.Error()))
+// chunkHeader returns header of frame index, which is serialized by getChunkWithHeader
+func (ch *Chunks) chunkHeader(index uint32) []byte {
+	if ch.opts.fountain {
+		return ch.fountainHeader(index)
 	}
 
-	defer zr.Close()
-
-	uncompressedBytes, err := io.ReadAll(zr)
+	data, _ := ch.storage.Get(int(index))
+	return ch.frameHeader(index, uint16(len(data)))
+}
 
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("cannot read uncompressed data: %s", err.Error()))
+// frameHeader returns frame header with chunk index, chunks count and chunk size
+func (ch *Chunks) frameHeader(index uint32, size uint16) []byte {
+	if ch.opts.compact {
+		return compactFrameHeader(index, ch.count, ch.size-size)
 	}
 
-	return uncompressedBytes, nil
-}
+	if ch.opts.wide {
+		header := make([]byte, wideHeaderOffset)
+		binary.LittleEndian.PutUint32(header[0:], index)
+		binary.LittleEndian.PutUint32(header[4:], ch.count)
+		binary.LittleEndian.PutUint16(header[8:], size)
+		return header
+	}
 
-func (ch *Chunks) getChunkWithHeader(index uint16) []byte {
-	size := len(ch.data[index])
-	chunk := make([]byte, ch.size+chunkHeaderOffset)
+	header := make([]byte, chunkHeaderOffset)
 	// chunk_index
-	chunk[0] = byte(index)
-	chunk[1] = byte(index >> 8)
+	header[0] = byte(index)
+	header[1] = byte(index >> 8)
 	// chunk_count
-	chunk[2] = byte(ch.count)
-	chunk[3] = byte(ch.count >> 8)
+	header[2] = byte(ch.count)
+	header[3] = byte(ch.count >> 8)
 	// chunk_size
-	chunk[4] = byte(size)
-	chunk[5] = byte(size >> 8)
+	header[4] = byte(size)
+	header[5] = byte(size >> 8)
+	return header
+}
+
+// parseFrameHeader returns chunk index, chunks count, chunk size and header size
+func (ch *Chunks) parseFrameHeader(chunk []byte) (index, count uint32, size uint16, headerSize int, err error) {
+	if ch.opts.compact {
+		return parseCompactFrameHeader(chunk, ch.opts.wide)
+	}
+
+	if ch.opts.wide {
+		index = binary.LittleEndian.Uint32(chunk[0:])
+		count = binary.LittleEndian.Uint32(chunk[4:])
+		size = binary.LittleEndian.Uint16(chunk[8:])
+		headerSize = wideHeaderOffset
+	} else {
+		index = uint32(chunk[0]) | uint32(chunk[1])<<8
+		count = uint32(chunk[2]) | uint32(chunk[3])<<8
+		size = uint16(chunk[4]) | uint16(chunk[5])<<8
+		headerSize = chunkHeaderOffset
+	}
 
-	copy(chunk[chunkHeaderOffset:], ch.data[index])
+	if int(size) > len(chunk)-headerSize {
+		return index, count, size, headerSize, newFrameError("go-airgap chunk has incorrect size",
+			FrameCheckSize, int(index), len(chunk)-headerSize, int(size))
+	}
 
-	return chunk
+	return index, count, size, headerSize, nil
 }
 
+// Data returns received payload, or nil when payload is incomplete or corrupted,
+// see DataE
 func (ch *Chunks) Data() []byte {
+	result, _ := ch.DataE()
+	return result
+}
+
+// DataE returns received payload, ErrIncomplete is returned before all chunks
+// are received and ErrCorrupted is returned when payload cannot be decompressed
+// or doesn't match payload digest
+func (ch *Chunks) DataE() ([]byte, error) {
 	ch.mu.RLock()
 	defer ch.mu.RUnlock()
 
+	if ch.count == 0 || ch.filled != ch.count {
+		return nil, ErrIncomplete
+	}
+
 	var result []byte
-	for index := uint16(0); index < ch.count; index++ {
-		result = append(result, ch.data[index]...)
+	for index := 0; index < int(ch.count); index++ {
+		data, err := ch.storage.Get(index)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data...)
 	}
-	result, _ = uncompress(result)
-	return result
+
+	result, err := ch.opts.trimPayloadDigest(result)
+	if err != nil {
+		return nil, err
+	}
+
+	if result, err = ch.opts.uncompress(result); err == ErrDecompressionLimit {
+		return nil, err
+	} else if err != nil {
+		return nil, ErrCorrupted
+	}
+	return result, nil
 }
 
 // SerializeB64 represents data frames to strings array, ready for generate QR code animation frames
@@ -160,49 +398,267 @@ func (ch *Chunks) SerializeB64() []string {
 	defer ch.mu.RUnlock()
 
 	var chunksB64 []string
-	for i := uint16(0); i < ch.count; i++ {
-		chunksB64 = append(chunksB64, base64.StdEncoding.EncodeToString(ch.getChunkWithHeader(i)))
+	for _, chunk := range ch.framesWithDecoys() {
+		chunksB64 = append(chunksB64, ch.encodeB64(chunk))
 	}
 	return chunksB64
 }
 
+func (ch *Chunks) encodeB64(chunk []byte) string {
+	if ch.opts.uriScheme != "" {
+		return ch.opts.uriScheme + base64.RawURLEncoding.EncodeToString(chunk)
+	}
+	encoding := base64.StdEncoding
+	if ch.opts.base64URL {
+		encoding = base64.RawURLEncoding
+	}
+
+	if ch.opts.tagged {
+		return FrameTag + encoding.EncodeToString(chunk)
+	}
+	return encoding.EncodeToString(chunk)
+}
+
+// trimFrameTag removes self-describing prefix, legacy frames are returned as is
+func trimFrameTag(frame string) (string, error) {
+	if !HasFrameTag(frame) {
+		return frame, nil
+	}
+
+	if !strings.HasPrefix(frame, FrameTag) {
+		return "", newFrameError("unsupported go-airgap frame tag", FrameCheckTag, -1, 0, 0)
+	}
+
+	return frame[len(FrameTag):], nil
+}
+
+// Count returns chunks count, see Progress for transfers with wide headers
 func (ch *Chunks) Count() uint16 {
-	return ch.count
+	return uint16(ch.count)
 }
 
+// Filled returns received chunks count, see Progress for transfers with wide headers
 func (ch *Chunks) Filled() uint16 {
-	return ch.filled
+	return uint16(ch.filled)
+}
+
+// SerializeRaw represents data frames as framed bytes without text encoding,
+// for transports like NFC, serial links or files
+func (ch *Chunks) SerializeRaw() [][]byte {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return ch.framesWithDecoys()
 }
 
+// ReadB64Chunk reads frame serialized with SerializeB64, both tagged and legacy
+// frames are accepted. Whitespace, padding and URL-safe alphabet are tolerated.
 func (ch *Chunks) ReadB64Chunk(frame string) (wasAdded bool, err error) {
+	frame, err = ch.opts.trimURIScheme(frame)
+	if err == nil {
+		frame, err = trimFrameTag(normalizeFrame(frame))
+	}
+
+	if err != nil {
+		ch.frameFailed(err)
+		return wasAdded, err
+	}
+
+	chunk, err := decodeB64(frame)
+
+	if err != nil {
+		offset := 0
+		if corrupted, ok := err.(base64.CorruptInputError); ok {
+			offset = int(corrupted)
+		}
+		err = newFrameError("incorrect go-airgap message", FrameCheckBase64, -1, 0, offset)
+		ch.frameFailed(err)
+		return wasAdded, err
+	}
+
+	return ch.AddRawChunk(chunk)
+}
+
+// ReadRawChunk reads frame serialized with SerializeRaw
+func (ch *Chunks) ReadRawChunk(chunk []byte) (wasAdded bool, err error) {
+	return ch.AddRawChunk(chunk)
+}
+
+// AddRawChunk ingests already decoded binary frame, e.g. from QR byte-mode
+// scanner or NFC stack. Frame is copied, so caller may reuse the buffer.
+func (ch *Chunks) AddRawChunk(chunk []byte) (wasAdded bool, err error) {
+	wasAdded, payloadSize, err := ch.addRawChunk(chunk)
+	ch.recordFrame(wasAdded, err)
+
+	if ch.observer != nil {
+		if err != nil {
+			ch.observer.FrameFailed(err)
+		} else {
+			ch.observer.FrameProcessed(wasAdded)
+		}
+
+		if payloadSize > 0 {
+			ch.observer.TransmissionCompleted(payloadSize)
+		}
+	}
+
+	if wasAdded && ch.onChunkReceived != nil {
+		received, total, _ := ch.Progress()
+		ch.onChunkReceived(received, total)
+	}
+
+	return wasAdded, err
+}
+
+// addRawChunk ingests frame and returns payload size, when the chunk completes transmission
+func (ch *Chunks) addRawChunk(chunk []byte) (wasAdded bool, payloadSize int, err error) {
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
 
-	chunk, err := base64.StdEncoding.DecodeString(frame)
+	if len(chunk) < ch.opts.frameOverhead() {
+		return wasAdded, payloadSize, newFrameError("go-airgap chunk to small",
+			FrameCheckLength, -1, ch.opts.frameOverhead(), len(chunk))
+	}
+
+	if ch.opts.frameKey != nil {
+		// decoy frames are ignored
+		if !verifyFrameAuthTag(ch.opts.frameKey, chunk) {
+			return wasAdded, payloadSize, nil
+		}
+		chunk = chunk[:len(chunk)-frameAuthTagSize]
+	}
+
+	if ch.opts.checksum != nil {
+		if chunk, err = ch.opts.verifyChunkChecksum(chunk); err != nil {
+			return wasAdded, payloadSize, err
+		}
+	}
+
+	if ch.opts.transferId {
+		if chunk, err = ch.verifyTransferId(chunk); err != nil {
+			return wasAdded, payloadSize, err
+		}
+	}
+
+	var messageId []byte
+	if ch.opts.chunkKey != nil {
+		if chunk, messageId, err = ch.decryptFrame(chunk); err != nil {
+			return wasAdded, payloadSize, err
+		}
+	}
+
+	if ch.opts.fountain {
+		if err = ch.verifyMessageId(messageId, false); err != nil {
+			return wasAdded, payloadSize, err
+		}
+
+		wasAdded, err = ch.addFountainFrame(chunk)
+		if wasAdded && ch.filled == ch.count {
+			payloadSize = ch.received
+		}
+		return wasAdded, payloadSize, err
+	}
+
+	index, count, size, headerSize, err := ch.parseFrameHeader(chunk)
 
 	if err != nil {
-		return wasAdded, errors.New("incorrect go-airgap message")
+		return wasAdded, payloadSize, err
 	}
 
-	if ch.count == 0 {
-		ch.count = uint16(chunk[2]) | uint16(chunk[3])<<8
-		ch.data = make([][]byte, ch.count)
+	var proof []byte
+	if ch.opts.merkle {
+		if err = ch.opts.verifyMerkleOptions(); err != nil {
+			return wasAdded, payloadSize, err
+		}
+
+		proofSize := merkleProofSize(count)
+		if len(chunk)-headerSize < proofSize {
+			return wasAdded, payloadSize, newFrameError("go-airgap chunk to small",
+				FrameCheckLength, int(index), headerSize+proofSize, len(chunk))
+		}
+
+		proof = chunk[len(chunk)-proofSize:]
+		chunk = chunk[:len(chunk)-proofSize]
+
+		// chunk size of compact header depends on frame length
+		if index, count, size, headerSize, err = ch.parseFrameHeader(chunk); err != nil {
+			return wasAdded, payloadSize, err
+		}
+	}
+
+	capacity := uint16(len(chunk) - headerSize)
+
+	if err = ch.opts.verifyPayloadCapacity(count, capacity); err != nil {
+		return wasAdded, payloadSize, err
+	}
+
+	// frames of resized sender have another message id
+	if err = ch.verifyMessageId(messageId, ch.isResized(count, capacity)); err != nil {
+		return wasAdded, payloadSize, err
 	}
 
-	index := uint16(chunk[0]) | uint16(chunk[1])<<8
+	if ch.count == 0 {
+		if ch.storage == nil {
+			ch.storage = &memoryStorage{}
+		}
 
-	size := uint16(chunk[4]) | uint16(chunk[5])<<8
+		if err = ch.storage.Reset(int(count), int(capacity)); err != nil {
+			return wasAdded, payloadSize, err
+		}
+
+		ch.count = count
+		ch.size = capacity
+	} else if count != ch.count {
+		if !ch.isResized(count, capacity) {
+			return wasAdded, payloadSize, newFrameError("go-airgap chunk has incorrect count",
+				FrameCheckCount, int(index), int(ch.count), int(count))
+		}
+		// sender has changed chunk size during transfer
+		if err = ch.resize(count, capacity); err != nil {
+			return wasAdded, payloadSize, err
+		}
+	}
 
-	if ch.data[index] == nil {
-		ch.data[index] = make([]byte, size)
-		copy(ch.data[index], chunk[chunkHeaderOffset:chunkHeaderOffset+size])
+	if ch.opts.parity > 0 && index >= ch.count && int64(index) < int64(ch.count)+int64(ch.opts.parityGroups(ch.count)) {
+		wasAdded, err = ch.addParityFrame(int(index)-int(ch.count), size, chunk[headerSize:])
+	} else if index >= ch.count {
+		return wasAdded, payloadSize, newFrameError("go-airgap chunk index out of range",
+			FrameCheckIndex, int(index), int(ch.count), int(index))
+	} else if ch.storage.Has(int(index)) {
+		ch.stats.Duplicates++
+	} else {
+		if proof != nil {
+			if err = ch.verifyMerkleProof(index, chunk[headerSize:headerSize+int(size)], proof); err != nil {
+				return wasAdded, payloadSize, err
+			}
+		}
+
+		if err = ch.storage.Put(int(index), chunk[headerSize:headerSize+int(size)]); err != nil {
+			return wasAdded, payloadSize, err
+		}
 		ch.filled++
+		ch.received += int(size)
+		ch.recordIngest(int(size))
 		wasAdded = true
+
+		if ch.opts.parity > 0 {
+			_, err = ch.recoverParityGroup(int(index) / ch.opts.parity)
+		}
+	}
+
+	if err != nil {
+		return wasAdded, payloadSize, err
+	}
+
+	if wasAdded && ch.filled == ch.count {
+		payloadSize = ch.received
 	}
 
-	return wasAdded, nil
+	return wasAdded, payloadSize, nil
 }
 
+// IsFilled checks that received chunks count equals chunks count, it is true
+// for receiver without frames too, see Complete
 func (ch *Chunks) IsFilled() bool {
 	ch.mu.RLock()
 	defer ch.mu.RUnlock()
@@ -0,0 +1,42 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package go_airgap
+
+import (
+	"iter"
+)
+
+// Frames returns iterator over base64 frames of SerializeB64, including
+// parity, redundant and decoy frames. Frames are encoded lazily, so caller
+// can break early without encoding whole frames slice.
+func (ch *Chunks) Frames() iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		ch.mu.RLock()
+		frames := ch.framesWithDecoys()
+		ch.mu.RUnlock()
+
+		for i, chunk := range frames {
+			ch.mu.RLock()
+			frame := ch.encodeB64(chunk)
+			ch.mu.RUnlock()
+
+			if !yield(i, frame) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestChunks_Frames(t *testing.T) {
+	payload := make([]byte, 2048)
+
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	chunks, err := NewChunks().SetData(payload, defaultChunkSize)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frames []string
+	for i, frame := range chunks.Frames() {
+		if i != len(frames) {
+			t.Fatal("incorrect frame index")
+		}
+		frames = append(frames, frame)
+	}
+
+	if !reflect.DeepEqual(frames, chunks.SerializeB64()) {
+		t.Fatal("mismatch frames")
+	}
+
+	// parity and redundant frames are yielded as with SerializeB64
+	chunks.SetParity(4).SetRedundancy(2)
+
+	frames = nil
+	for _, frame := range chunks.Frames() {
+		frames = append(frames, frame)
+	}
+
+	if !reflect.DeepEqual(frames, chunks.SerializeB64()) {
+		t.Fatal("mismatch frames with parity and redundancy")
+	}
+
+	for i := range chunks.Frames() {
+		if i > 0 {
+			t.Fatal("iteration is not stopped")
+		}
+		break
+	}
+}
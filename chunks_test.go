@@ -16,6 +16,7 @@ package go_airgap
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"reflect"
 	"testing"
 )
@@ -52,10 +53,14 @@ func TestChunks_NewChunks(t *testing.T) {
 	}
 
 	result := make([]byte, 0)
-	for i := 0; i < len(readedChunks.data); i++ {
-		result = append(result, readedChunks.data[i]...)
+	for i := 0; i < int(readedChunks.Count()); i++ {
+		data, err := readedChunks.storage.Get(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, data...)
 	}
-	uncompressedResult, err := uncompress(result)
+	uncompressedResult, err := readedChunks.opts.uncompress(result)
 
 	if err != nil {
 		t.Fatal("cannot uncompress data", err)
@@ -65,3 +70,177 @@ func TestChunks_NewChunks(t *testing.T) {
 		t.Fatal("mismatch marshalled data")
 	}
 }
+
+func TestChunks_ChunkSizeForFrame(t *testing.T) {
+	payload := make([]byte, 4096)
+
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	for _, frameSize := range []int{64, 100, 191, 192, 500} {
+		chunks, err := NewChunks().SetData(payload, ChunkSizeForFrame(frameSize))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, frame := range chunks.SerializeB64() {
+			if len(frame) > frameSize {
+				t.Fatalf("frame length %d exceeds %d", len(frame), frameSize)
+			}
+		}
+	}
+}
+
+func TestChunks_SerializeRaw(t *testing.T) {
+	payload := make([]byte, 2048)
+
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	chunks, err := NewChunks().SetData(payload, defaultChunkSize)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readedChunks := NewChunks()
+
+	for _, frame := range chunks.SerializeRaw() {
+		if _, err = readedChunks.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !readedChunks.IsFilled() {
+		t.Fatal("chunks are not filled")
+	}
+
+	if !reflect.DeepEqual(payload, readedChunks.Data()) {
+		t.Fatal("mismatch marshalled data")
+	}
+
+	if _, err = readedChunks.ReadRawChunk([]byte{0, 0}); err == nil {
+		t.Fatal("short chunk accepted")
+	}
+}
+
+func TestChunks_AddRawChunk(t *testing.T) {
+	payload := make([]byte, 1024)
+
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	chunks, err := NewChunks().SetData(payload, defaultChunkSize)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readedChunks := NewChunks()
+	buf := make([]byte, defaultChunkSize)
+
+	for _, frame := range chunks.SerializeB64() {
+		// emulate scanner, which reuses frame buffer
+		n, err := base64.StdEncoding.Decode(buf, []byte(frame))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = readedChunks.AddRawChunk(buf[:n]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reflect.DeepEqual(payload, readedChunks.Data()) {
+		t.Fatal("mismatch marshalled data")
+	}
+
+	if _, err = readedChunks.AddRawChunk([]byte{0xFF, 0xFF, 0, 0, 0, 0}); err == nil {
+		t.Fatal("chunk with incorrect header accepted")
+	}
+}
+
+func TestChunks_FrameTag(t *testing.T) {
+	payload := make([]byte, 1024)
+
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	chunks, err := NewChunks().SetFrameTag(true).SetData(payload, ChunkSizeForFrame(defaultChunkSize))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readedChunks := NewChunks()
+
+	for _, frame := range chunks.SerializeB64() {
+		if !HasFrameTag(frame) {
+			t.Fatal("frame is not tagged")
+		}
+
+		if _, err = readedChunks.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reflect.DeepEqual(payload, readedChunks.Data()) {
+		t.Fatal("mismatch marshalled data")
+	}
+
+	if HasFrameTag("https://example.com") {
+		t.Fatal("arbitrary content detected as frame")
+	}
+
+	if _, err = NewChunks().ReadB64Chunk("AG9:AAAA"); err == nil {
+		t.Fatal("unsupported frame tag accepted")
+	}
+}
+
+func TestChunks_DataE(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks()
+	if _, err = receiver.DataE(); err != ErrIncomplete {
+		t.Fatal("incorrect error of empty receiver", err)
+	}
+
+	frames := sender.SerializeRaw()
+	for _, frame := range frames[1:] {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = receiver.DataE(); err != ErrIncomplete {
+		t.Fatal("incorrect error of incomplete receiver", err)
+	}
+
+	if _, err = receiver.ReadRawChunk(frames[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := receiver.DataE()
+	if err != nil || !reflect.DeepEqual(data, payload) {
+		t.Fatal("incorrect received payload", err)
+	}
+
+	// chunk is corrupted after receiving
+	chunk, _ := receiver.storage.Get(1)
+	chunk[0] ^= 0x01
+
+	if _, err = receiver.DataE(); err != ErrCorrupted {
+		t.Fatal("incorrect error of corrupted payload", err)
+	}
+}
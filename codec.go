@@ -0,0 +1,218 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// FrameCodec is text codec of frames serialized with Chunks.SerializeText,
+// it overrides FrameEncoding and multibase prefix
+type FrameCodec interface {
+	Encode(frame []byte) string
+	Decode(frame string) ([]byte, error)
+}
+
+// Built-in frame codecs
+var (
+	// CodecBase64 is standard base64 codec, decoder accepts URL-safe alphabet
+	CodecBase64 FrameCodec = base64Codec{encoding: base64.StdEncoding}
+	// CodecBase64URL is URL-safe base64 codec without padding
+	CodecBase64URL FrameCodec = base64Codec{encoding: base64.RawURLEncoding}
+	// CodecBase45 is RFC 9285 base45 codec, see EncodingBase45
+	CodecBase45 FrameCodec = base45Codec{}
+	// CodecBase58 is base58 codec with bitcoin alphabet
+	CodecBase58 FrameCodec = base58Codec{}
+	// CodecHex is lowercase hex codec, decoder accepts uppercase
+	CodecHex FrameCodec = hexCodec{}
+)
+
+// NewBech32mCodec returns BIP-350 bech32m codec with human-readable part hrp,
+// decoder verifies checksum and hrp. Frames exceed 90 characters limit of
+// addresses, checksum detects errors reliably up to 1023 characters.
+func NewBech32mCodec(hrp string) (FrameCodec, error) {
+	if _, err := bech32Encode(hrp, nil, bech32mConst); err != nil {
+		return nil, err
+	}
+	return bech32mCodec{hrp: hrp}, nil
+}
+
+// SetFrameCodec defines text codec of SerializeText and ReadTextChunk frames,
+// nil restores FrameEncoding
+func (ch *Chunks) SetFrameCodec(codec FrameCodec) *Chunks {
+	ch.opts.codec = codec
+	return ch
+}
+
+// SetFrameCodec defines text codec of MarshalTextChunks frames, see Chunks.SetFrameCodec
+func (a *AirGap) SetFrameCodec(codec FrameCodec) *AirGap {
+	a.chunksOpts.codec = codec
+	return a
+}
+
+// codecChunkSize returns max chunk size, which encoded with codec fits to
+// frameSize characters
+func codecChunkSize(codec FrameCodec, frameSize int) int {
+	low, high := 0, frameSize
+	for low < high {
+		size := (low + high + 1) / 2
+		frame := make([]byte, size)
+		for i := range frame {
+			frame[i] = 0xFF
+		}
+		if len(codec.Encode(frame)) <= frameSize {
+			low = size
+		} else {
+			high = size - 1
+		}
+	}
+	return low
+}
+
+type base64Codec struct {
+	encoding *base64.Encoding
+}
+
+func (c base64Codec) Encode(frame []byte) string {
+	return c.encoding.EncodeToString(frame)
+}
+
+func (c base64Codec) Decode(frame string) ([]byte, error) {
+	chunk, err := decodeB64(normalizeFrame(frame))
+	if err != nil {
+		offset := 0
+		if corrupted, ok := err.(base64.CorruptInputError); ok {
+			offset = int(corrupted)
+		}
+		return nil, newFrameError("incorrect go-airgap message", FrameCheckBase64, -1, 0, offset)
+	}
+	return chunk, nil
+}
+
+type base45Codec struct{}
+
+func (base45Codec) Encode(frame []byte) string {
+	return encodeBase45(frame)
+}
+
+func (base45Codec) Decode(frame string) ([]byte, error) {
+	return decodeBase45(strings.NewReplacer("\r", "", "\n", "").Replace(frame))
+}
+
+type hexCodec struct{}
+
+func (hexCodec) Encode(frame []byte) string {
+	return hex.EncodeToString(frame)
+}
+
+func (hexCodec) Decode(frame string) ([]byte, error) {
+	chunk, err := hex.DecodeString(normalizeFrame(frame))
+	if err != nil {
+		return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, 0)
+	}
+	return chunk, nil
+}
+
+// base58Alphabet is bitcoin alphabet without 0, O, I and l
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+type base58Codec struct{}
+
+// Encode encodes frame as big-endian number, every leading zero byte is
+// encoded with '1'
+func (base58Codec) Encode(frame []byte) string {
+	zeros := 0
+	for zeros < len(frame) && frame[zeros] == 0 {
+		zeros++
+	}
+
+	// log(256) / log(58) < 1.37
+	digits := make([]byte, 0, (len(frame)-zeros)*137/100+1)
+	for _, b := range frame[zeros:] {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for ; carry > 0; carry /= 58 {
+			digits = append(digits, byte(carry%58))
+		}
+	}
+
+	result := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		result[i] = base58Alphabet[0]
+	}
+	for i, digit := range digits {
+		result[len(result)-1-i] = base58Alphabet[digit]
+	}
+	return string(result)
+}
+
+func (base58Codec) Decode(frame string) ([]byte, error) {
+	frame = normalizeFrame(frame)
+
+	zeros := 0
+	for zeros < len(frame) && frame[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	// little-endian bytes of number
+	var data []byte
+	for offset := zeros; offset < len(frame); offset++ {
+		carry := strings.IndexByte(base58Alphabet, frame[offset])
+		if carry < 0 {
+			return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset)
+		}
+		for i := range data {
+			carry += int(data[i]) * 58
+			data[i] = byte(carry)
+			carry >>= 8
+		}
+		for ; carry > 0; carry >>= 8 {
+			data = append(data, byte(carry))
+		}
+	}
+
+	result := make([]byte, zeros+len(data))
+	for i, b := range data {
+		result[len(result)-1-i] = b
+	}
+	return result, nil
+}
+
+type bech32mCodec struct {
+	hrp string
+}
+
+func (c bech32mCodec) Encode(frame []byte) string {
+	encoded, _ := bech32Encode(c.hrp, frame, bech32mConst)
+	return encoded
+}
+
+func (c bech32mCodec) Decode(frame string) ([]byte, error) {
+	hrp, chunk, err := bech32Decode(normalizeFrame(frame), bech32mConst)
+	if err != nil {
+		return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, 0)
+	}
+
+	if hrp != c.hrp {
+		return nil, newFrameError("unsupported go-airgap frame tag", FrameCheckTag, -1, 0, 0)
+	}
+	return chunk, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestFrameCodec(t *testing.T) {
+	if CodecBase58.Encode([]byte("Hello World!")) != "2NEpo7TZRRrLZSi2U" {
+		t.Fatal("incorrect base58 encoding", CodecBase58.Encode([]byte("Hello World!")))
+	}
+
+	if CodecBase58.Encode([]byte{0, 0, 0x28, 0x7f, 0xb4, 0xcd}) != "11233QC4" {
+		t.Fatal("incorrect base58 leading zeros encoding", CodecBase58.Encode([]byte{0, 0, 0x28, 0x7f, 0xb4, 0xcd}))
+	}
+
+	data := make([]byte, 300)
+	_, _ = rand.Read(data)
+	data[0] = 0
+
+	for _, codec := range []FrameCodec{CodecBase64, CodecBase64URL, CodecBase45, CodecBase58, CodecHex} {
+		decoded, err := codec.Decode(codec.Encode(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(decoded, data) {
+			t.Fatal("incorrect decoded frame")
+		}
+
+		if size := codecChunkSize(codec, 250); len(codec.Encode(bytes.Repeat([]byte{0xFF}, size))) > 250 {
+			t.Fatal("incorrect chunk size", size)
+		}
+	}
+
+	if _, err := CodecBase58.Decode("2NEpo7TZRRrLZSi0U"); err == nil {
+		t.Fatal("incorrect base58 frame is accepted")
+	}
+
+	if _, err := CodecHex.Decode("0g"); err == nil {
+		t.Fatal("incorrect hex frame is accepted")
+	}
+}
+
+func TestChunks_SetFrameCodec(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	for _, codec := range []FrameCodec{CodecBase64URL, CodecBase58, CodecHex} {
+		opts := &chunksOptions{codec: codec}
+		sender, err := NewChunks().SetFrameCodec(codec).SetData(payload, opts.frameChunkSize(250))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewChunks().SetFrameCodec(codec)
+		for _, frame := range sender.SerializeText() {
+			if len(frame) > 250 {
+				t.Fatal("frame exceeds frame size", len(frame))
+			}
+
+			if _, err = receiver.ReadTextChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+}
+
+func TestNewBech32mCodec(t *testing.T) {
+	for _, valid := range []string{"a1lqfn3a", "A1LQFN3A", "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx"} {
+		if _, _, err := bech32Decode(valid, bech32mConst); err != nil {
+			t.Fatal(err, valid)
+		}
+	}
+
+	for _, invalid := range []string{"A1lqfn3a", "a1lqfn3b", "a1g7sgd8", "1lqfn3a"} {
+		if _, _, err := bech32Decode(invalid, bech32mConst); err == nil {
+			t.Fatal("invalid bech32m string is accepted", invalid)
+		}
+	}
+
+	if _, err := NewBech32mCodec("AG"); err == nil {
+		t.Fatal("uppercase hrp is accepted")
+	}
+
+	codec, err := NewBech32mCodec("ag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetFrameCodec(codec).SetData(payload, codecChunkSize(codec, 250))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetFrameCodec(codec)
+	for _, frame := range sender.SerializeText() {
+		if len(frame) > 250 {
+			t.Fatal("frame exceeds frame size", len(frame))
+		}
+
+		if _, err = receiver.ReadTextChunk(strings.ToUpper(frame)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	other, _ := NewBech32mCodec("xx")
+	if _, err = NewChunks().SetFrameCodec(other).ReadTextChunk(sender.SerializeText()[0]); err == nil {
+		t.Fatal("frame with another hrp is accepted")
+	}
+}
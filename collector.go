@@ -0,0 +1,194 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	// DefaultMaxPendingTransfers is default count of incomplete transfers of
+	// ChunkCollector, see ChunkCollector.SetMaxPending
+	DefaultMaxPendingTransfers = 64
+
+	// maxCompletedTransfers bounds count of remembered completed transfer ids
+	maxCompletedTransfers = 1024
+)
+
+// CompletedTransfer contains payload of completed transfer
+type CompletedTransfer struct {
+	TransferId []byte
+	Data       []byte
+	// Err is returned by Chunks.DataE, e.g. ErrCorrupted, Data is nil then
+	Err error
+}
+
+// ChunkCollector ingests frames of several concurrent transfers, e.g. kiosk
+// scanning many devices, frames are demultiplexed by transfer id, see SetTransferId.
+// Completed payloads are emitted to Completed channel, which must be read.
+type ChunkCollector struct {
+	mu        sync.Mutex
+	opts      chunksOptions
+	transfers map[string]*Chunks
+	// pending contains ids of incomplete transfers in order of opening
+	pending    []string
+	maxPending int
+	// completed contains ids of recently emitted transfers in order of completion
+	completed      map[string]bool
+	completedOrder []string
+	output         chan CompletedTransfer
+}
+
+// NewChunkCollector initiates collector with default frames options,
+// buffer is capacity of Completed channel
+func NewChunkCollector(buffer int) *ChunkCollector {
+	return newChunkCollector(chunksOptions{}, buffer)
+}
+
+// NewChunkCollector initiates collector with frames options of AirGap
+func (a *AirGap) NewChunkCollector(buffer int) *ChunkCollector {
+	return newChunkCollector(a.chunksOpts, buffer)
+}
+
+func newChunkCollector(opts chunksOptions, buffer int) *ChunkCollector {
+	opts.transferId = true
+	return &ChunkCollector{
+		opts:       opts,
+		transfers:  make(map[string]*Chunks),
+		maxPending: DefaultMaxPendingTransfers,
+		completed:  make(map[string]bool),
+		output:     make(chan CompletedTransfer, buffer),
+	}
+}
+
+// SetMaxPending limits count of incomplete transfers, the oldest transfer is
+// dropped, when a new one exceeds limit
+func (c *ChunkCollector) SetMaxPending(n int) *ChunkCollector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n < 1 {
+		n = 1
+	}
+	c.maxPending = n
+	return c
+}
+
+// Completed returns channel of completed transfers
+func (c *ChunkCollector) Completed() <-chan CompletedTransfer {
+	return c.output
+}
+
+// Pending returns count of incomplete transfers
+func (c *ChunkCollector) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.transfers)
+}
+
+// ReadB64Chunk reads frame serialized with SerializeB64
+func (c *ChunkCollector) ReadB64Chunk(frame string) (wasAdded bool, err error) {
+	frame, err = c.opts.trimURIScheme(frame)
+	if err == nil {
+		frame, err = trimFrameTag(normalizeFrame(frame))
+	}
+	if err != nil {
+		return wasAdded, err
+	}
+
+	chunk, err := decodeB64(frame)
+	if err != nil {
+		return wasAdded, errors.New("incorrect go-airgap message")
+	}
+
+	return c.AddRawChunk(chunk)
+}
+
+// AddRawChunk ingests frame of any transfer, frames of already completed
+// transfers are ignored
+func (c *ChunkCollector) AddRawChunk(chunk []byte) (wasAdded bool, err error) {
+	if len(chunk) < c.opts.frameOverhead() {
+		return wasAdded, newFrameError("go-airgap chunk to small",
+			FrameCheckLength, -1, c.opts.frameOverhead(), len(chunk))
+	}
+
+	id := string(chunk[:transferIdSize])
+
+	c.mu.Lock()
+	if c.completed[id] {
+		c.mu.Unlock()
+		return wasAdded, nil
+	}
+
+	transfer, ok := c.transfers[id]
+	if !ok {
+		transfer = &Chunks{opts: c.opts}
+		c.transfers[id] = transfer
+		c.pending = append(c.pending, id)
+
+		if len(c.pending) > c.maxPending {
+			c.removePending(c.pending[0])
+		}
+	}
+	c.mu.Unlock()
+
+	wasAdded, err = transfer.AddRawChunk(chunk)
+
+	c.mu.Lock()
+	if !ok && transfer.ReceivedCount() == 0 && c.transfers[id] == transfer {
+		// unauthenticated or corrupted frame doesn't open transfer
+		c.removePending(id)
+	}
+
+	isCompleted := wasAdded && !c.completed[id] && transfer.Complete()
+	if isCompleted {
+		c.removePending(id)
+		c.addCompleted(id)
+	}
+	c.mu.Unlock()
+
+	if isCompleted {
+		completed := CompletedTransfer{TransferId: []byte(id)}
+		completed.Data, completed.Err = transfer.DataE()
+		c.output <- completed
+	}
+
+	return wasAdded, err
+}
+
+// removePending drops incomplete transfer
+func (c *ChunkCollector) removePending(id string) {
+	delete(c.transfers, id)
+	for i := range c.pending {
+		if c.pending[i] == id {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			break
+		}
+	}
+}
+
+// addCompleted remembers id of completed transfer, the oldest id is
+// forgotten, when maxCompletedTransfers is exceeded
+func (c *ChunkCollector) addCompleted(id string) {
+	c.completed[id] = true
+	c.completedOrder = append(c.completedOrder, id)
+
+	if len(c.completedOrder) > maxCompletedTransfers {
+		delete(c.completed, c.completedOrder[0])
+		c.completedOrder = c.completedOrder[1:]
+	}
+}
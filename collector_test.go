@@ -0,0 +1,140 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunkCollector(t *testing.T) {
+	payloads := make([][]byte, 3)
+	frames := make([][]string, 3)
+	for i := range payloads {
+		payloads[i] = make([]byte, 1000+i*500)
+		_, _ = rand.Read(payloads[i])
+
+		sender, err := NewChunks().SetTransferId(true).SetData(payloads[i], 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames[i] = sender.SerializeB64()
+	}
+
+	collector := NewChunkCollector(len(payloads))
+
+	// frames of all transfers are interleaved
+	for round := 0; round < len(frames[2]); round++ {
+		for i := range frames {
+			if round >= len(frames[i]) {
+				continue
+			}
+			if _, err := collector.ReadB64Chunk(frames[i][round]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if collector.Pending() != 0 {
+		t.Fatal("incorrect pending transfers", collector.Pending())
+	}
+
+	for i := range payloads {
+		completed := <-collector.Completed()
+		if !bytes.Equal(completed.Data, payloads[i]) {
+			t.Fatal("incorrect completed payload", i)
+		}
+	}
+
+	// frames of completed transfer are ignored
+	if wasAdded, err := collector.ReadB64Chunk(frames[0][0]); err != nil || wasAdded {
+		t.Fatal("frame of completed transfer is added")
+	}
+}
+
+func TestChunkCollector_Corrupted(t *testing.T) {
+	payload := make([]byte, 1000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetTransferId(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collector := NewChunkCollector(1)
+
+	for i, frame := range sender.SerializeRaw() {
+		if i == 1 {
+			frame = append([]byte{}, frame...)
+			frame[len(frame)-1] ^= 0xFF
+		}
+
+		if _, err = collector.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	completed := <-collector.Completed()
+	if completed.Err != ErrCorrupted || completed.Data != nil {
+		t.Fatal("corrupted transfer is completed without error", completed.Err)
+	}
+}
+
+func TestChunkCollector_SetMaxPending(t *testing.T) {
+	collector := NewChunkCollector(1).SetMaxPending(2)
+
+	senders := make([]*Chunks, 3)
+	for i := range senders {
+		payload := make([]byte, 1000)
+		_, _ = rand.Read(payload)
+
+		var err error
+		if senders[i], err = NewChunks().SetTransferId(true).SetData(payload, 200); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = collector.AddRawChunk(senders[i].SerializeRaw()[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		if collector.Pending() > 2 {
+			t.Fatal("pending transfers aren't bounded", collector.Pending())
+		}
+	}
+
+	// the oldest transfer is dropped
+	if _, ok := collector.transfers[string(senders[0].transferId)]; ok {
+		t.Fatal("the oldest transfer isn't dropped")
+	}
+
+	for _, frame := range senders[2].SerializeRaw()[1:] {
+		if _, err := collector.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if completed := <-collector.Completed(); completed.Err != nil || !bytes.Equal(completed.TransferId, senders[2].transferId) {
+		t.Fatal("incorrect completed transfer")
+	}
+
+	for i := 0; i < maxCompletedTransfers+1; i++ {
+		collector.addCompleted(string(rune(i)))
+	}
+
+	if len(collector.completed) != maxCompletedTransfers || len(collector.completedOrder) != maxCompletedTransfers {
+		t.Fatal("completed transfers aren't bounded", len(collector.completed))
+	}
+}
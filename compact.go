@@ -0,0 +1,156 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	compactHeaderMinSize = 3 // chunk_index(1..3) + chunks_count(1..3) + chunk_padding(1..3)
+)
+
+// SetCompactHeaders enables varint encoding of frame headers, see AirGap.SetCompactHeaders
+func (ch *Chunks) SetCompactHeaders(enabled bool) *Chunks {
+	ch.opts.compact = enabled
+	return ch
+}
+
+// SetCompactHeaders enables varint encoding of frame headers and operation headers,
+// which saves several bytes per frame and per operation. Receiver must enable
+// compact headers too.
+func (a *AirGap) SetCompactHeaders(enabled bool) *AirGap {
+	a.chunksOpts.compact = enabled
+	return a
+}
+
+// chunkPayloadSize returns max chunk payload size of frames with chunkSize bytes
+func (o chunksOptions) chunkPayloadSize(dataSize, chunkSize int) int {
+	size := chunkSize - o.frameOverhead()
+	if !o.compact && !o.merkle {
+		return size
+	}
+
+	tagSize := o.frameOverhead() - compactHeaderMinSize
+
+	// varint header and merkle proof grow with chunks count, so payload is
+	// shrunk until frame fits
+	for size > 0 {
+		count := (dataSize + size - 1) / size
+		if count == 0 {
+			count = 1
+		}
+
+		next := chunkSize - o.frameOverhead()
+		if o.compact {
+			next = chunkSize - tagSize - uvarintSize(uint64(count-1)) - uvarintSize(uint64(count)) - uvarintSize(uint64(size))
+		}
+		if o.merkle {
+			next -= merkleProofSize(uint32(count))
+		}
+
+		if next >= size {
+			return size
+		}
+		size = next
+	}
+
+	return size
+}
+
+// compactFrameHeader serializes chunk index, chunks count and padding of chunk as varints
+func compactFrameHeader(index, count uint32, padding uint16) []byte {
+	header := make([]byte, 0, compactHeaderMinSize)
+	header = appendUvarint(header, uint64(index))
+	header = appendUvarint(header, uint64(count))
+	return appendUvarint(header, uint64(padding))
+}
+
+func parseCompactFrameHeader(chunk []byte, wide bool) (index, count uint32, size uint16, headerSize int, err error) {
+	var fields [3]uint64
+	for i := range fields {
+		limit := uint64(maxChunksCount)
+		if wide && i < 2 {
+			limit = 0xFFFFFFFF
+		}
+
+		value, n := binary.Uvarint(chunk[headerSize:])
+		if n <= 0 || value > limit {
+			return index, count, size, headerSize, newFrameError("go-airgap chunk has incorrect header",
+				FrameCheckHeader, -1, 0, 0)
+		}
+		fields[i] = value
+		headerSize += n
+	}
+
+	// chunk size is encoded as padding, which must fit frame capacity
+	capacity := uint64(len(chunk) - headerSize)
+	if fields[2] > capacity {
+		return index, count, size, headerSize, newFrameError("go-airgap chunk has incorrect size",
+			FrameCheckSize, int(fields[0]), int(capacity), int(fields[2]))
+	}
+
+	return uint32(fields[0]), uint32(fields[1]), uint16(capacity - fields[2]), headerSize, nil
+}
+
+// appendOperationHeader serializes operation code and size
+func appendOperationHeader(dst []byte, opCode uint16, size uint32, compact bool) []byte {
+	if compact {
+		dst = appendUvarint(dst, uint64(opCode))
+		return appendUvarint(dst, uint64(size))
+	}
+
+	return append(dst,
+		byte(opCode>>8),
+		byte(opCode),
+		byte(size>>24),
+		byte(size>>16),
+		byte(size>>8),
+		byte(size),
+	)
+}
+
+// parseOperationHeader returns operation code, size and header size
+func parseOperationHeader(data []byte, compact bool) (opCode uint16, size uint32, headerSize int, err error) {
+	if !compact {
+		if len(data) < operationPayloadOffset {
+			return 0, 0, 0, errors.New("go-airgap operation header is truncated")
+		}
+		opCode = uint16(data[1]) | uint16(data[0])<<8
+		size = uint32(data[5]) | uint32(data[4])<<8 | uint32(data[3])<<16 | uint32(data[2])<<24
+		return opCode, size, operationPayloadOffset, nil
+	}
+
+	code, n := binary.Uvarint(data)
+	if n <= 0 || code > 0xFFFF {
+		return 0, 0, 0, errors.New("go-airgap operation header is truncated")
+	}
+
+	length, m := binary.Uvarint(data[n:])
+	if m <= 0 || length > 0xFFFFFFFF {
+		return 0, 0, 0, errors.New("go-airgap operation header is truncated")
+	}
+
+	return uint16(code), uint32(length), n + m, nil
+}
+
+func uvarintSize(value uint64) int {
+	size := 1
+	for ; value >= 0x80; value >>= 7 {
+		size++
+	}
+	return size
+}
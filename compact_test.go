@@ -0,0 +1,165 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAirGap_SetCompactHeaders(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	legacy := NewAirGap(VersionDefault, instanceId)
+	compact := NewAirGap(VersionDefault, instanceId).SetCompactHeaders(true)
+
+	message := func(airGap *AirGap) *Message {
+		m := airGap.CreateMessage()
+		for i := 0; i < 10; i++ {
+			m.AddOperation(opCodeTest1, []byte{byte(i)})
+		}
+		return m.AddOperation(opCodeTest3, []byte("payload"))
+	}
+
+	legacyData, err := message(legacy).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compactData, err := message(compact).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 11 operations, 4 bytes saved per operation, opCodeTest3 takes 3 bytes
+	if len(legacyData)-len(compactData) != 11*4-2 {
+		t.Fatal("incorrect compact message size", len(legacyData), len(compactData))
+	}
+
+	received, err := compact.Unmarshal(compactData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(received.Operations, message(compact).Operations) {
+		t.Fatal("incorrect compact operations")
+	}
+
+	if _, err = compact.Unmarshal(compactData[:len(compactData)-1]); err == nil {
+		t.Fatal("truncated message is accepted")
+	}
+}
+
+func TestChunks_SetCompactHeaders(t *testing.T) {
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	for _, frameKey := range [][]byte{nil, []byte("frame key")} {
+		sender, err := NewChunks().SetCompactHeaders(true).SetFrameKey(frameKey).SetDecoyFrames(3).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		legacy, err := NewChunks().SetFrameKey(frameKey).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sender.size <= legacy.size {
+			t.Fatal("compact chunk payload is not larger", sender.size, legacy.size)
+		}
+
+		frames := sender.SerializeRaw()
+		for _, frame := range frames {
+			if len(frame) > 200 {
+				t.Fatal("frame exceeds chunk size", len(frame))
+			}
+		}
+
+		receiver := NewChunks().SetCompactHeaders(true).SetFrameKey(frameKey)
+		for _, frame := range frames {
+			if _, err = receiver.ReadRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !receiver.IsFilled() || !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+}
+
+func TestChunks_SetCompactHeadersResize(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetCompactHeaders(true).SetTransferId(true).SetData(payload, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetCompactHeaders(true).SetTransferId(true)
+	for _, frame := range sender.SerializeRaw()[:5] {
+		if _, err = receiver.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resized, err := sender.Resize(150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, frame := range resized.SerializeRaw() {
+		if _, err = receiver.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
+
+func TestParseCompactFrameHeader(t *testing.T) {
+	for _, chunk := range [][]byte{
+		{},
+		{0x01, 0x02},
+		{0xFF, 0xFF, 0xFF, 0x01, 0x02, 0x00},
+		{0x01, 0x02, 0x05, 0x00},
+	} {
+		if _, _, _, _, err := parseCompactFrameHeader(chunk, false); err == nil {
+			t.Fatal("incorrect header is accepted", chunk)
+		}
+	}
+
+	index, count, size, headerSize, err := parseCompactFrameHeader([]byte{0x01, 0x02, 0x01, 0xAA, 0xBB}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if index != 1 || count != 2 || size != 1 || headerSize != 3 {
+		t.Fatal("incorrect header", index, count, size, headerSize)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// Complete checks that chunks count is known and every chunk is received
+func (ch *Chunks) Complete() bool {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return ch.count > 0 && ch.filled == ch.count
+}
+
+// ReceivedCount returns count of received chunks
+func (ch *Chunks) ReceivedCount() uint32 {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return ch.filled
+}
+
+// ReceivedBitmap returns bitmap of received chunks, bit index%8 of byte
+// index/8 is set when chunk index is received
+func (ch *Chunks) ReceivedBitmap() []byte {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	bitmap := make([]byte, (int(ch.count)+7)/8)
+	for index := 0; index < int(ch.count); index++ {
+		if ch.storage.Has(index) {
+			bitmap[index/8] |= 1 << (index % 8)
+		}
+	}
+	return bitmap
+}
+
+// IsReceived checks that chunk with index is received
+func (ch *Chunks) IsReceived(index uint32) bool {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return index < ch.count && ch.storage.Has(int(index))
+}
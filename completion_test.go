@@ -0,0 +1,63 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_Complete(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks()
+	if receiver.Complete() || !receiver.IsFilled() {
+		t.Fatal("empty receiver is complete")
+	}
+
+	frames := sender.SerializeRaw()
+	for i, frame := range frames {
+		if i == 9 {
+			continue
+		}
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if receiver.Complete() || receiver.ReceivedCount() != uint32(sender.Count())-1 || receiver.IsReceived(9) || !receiver.IsReceived(8) {
+		t.Fatal("incorrect incomplete state")
+	}
+
+	bitmap := receiver.ReceivedBitmap()
+	if len(bitmap) != (int(sender.Count())+7)/8 || bitmap[1] != 0xFF&^(1<<1)&(1<<(sender.Count()-8)-1) {
+		t.Fatal("incorrect received bitmap", bitmap)
+	}
+
+	if _, err = receiver.ReadRawChunk(frames[9]); err != nil {
+		t.Fatal(err)
+	}
+
+	if !receiver.Complete() || !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect complete state")
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Compressor implements payload compression, receiver must use the same compressor
+type Compressor interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Built-in compressors
+var (
+	// CompressorGzip is gzip with best compression, the default compressor
+	CompressorGzip Compressor = gzipCompressor{}
+	// CompressorNone passes payload as is, e.g. for already compressed or
+	// encrypted payloads
+	CompressorNone Compressor = noneCompressor{}
+)
+
+// SetCompressor defines payload compression, nil restores CompressorGzip
+func (ch *Chunks) SetCompressor(compressor Compressor) *Chunks {
+	ch.opts.compressor = compressor
+	return ch
+}
+
+// SetCompressor defines payload compression, see Chunks.SetCompressor
+func (a *AirGap) SetCompressor(compressor Compressor) *AirGap {
+	a.chunksOpts.compressor = compressor
+	return a
+}
+
+// NewDeflateDictCompressor returns deflate compressor with preset dictionary,
+// e.g. typical JSON or PSBT payload, so small payloads are compressed better.
+// gzip doesn't support preset dictionaries, so payload is raw deflate stream.
+// Receiver must use the same dictionary.
+func NewDeflateDictCompressor(dict []byte) Compressor {
+	return deflateDictCompressor{dict: append([]byte{}, dict...)}
+}
+
+// compression flags of payloads with SetSkipIncompressible
+const (
+	compressionFlagCompressed = 0
+	compressionFlagRaw        = 1
+)
+
+// SetSkipIncompressible prefixes payload with compression flag, payload is
+// sent uncompressed, when compression doesn't reduce its size, e.g. for
+// encrypted messages. Receiver must enable it too.
+func (ch *Chunks) SetSkipIncompressible(enabled bool) *Chunks {
+	ch.opts.skipIncompressible = enabled
+	return ch
+}
+
+// SetSkipIncompressible prefixes payload with compression flag, see Chunks.SetSkipIncompressible
+func (a *AirGap) SetSkipIncompressible(enabled bool) *AirGap {
+	a.chunksOpts.skipIncompressible = enabled
+	return a
+}
+
+func (o chunksOptions) getCompressor() Compressor {
+	if o.compressor == nil {
+		return CompressorGzip
+	}
+	return o.compressor
+}
+
+// newWriter returns compressing writer, compression flag is written first,
+// when enabled
+func (o chunksOptions) newWriter(w io.Writer) (io.WriteCloser, error) {
+	if o.skipIncompressible {
+		if _, err := w.Write([]byte{compressionFlagCompressed}); err != nil {
+			return nil, err
+		}
+	}
+	return o.getCompressor().NewWriter(w)
+}
+
+// newReader returns uncompressing reader limited with decompressionLimit,
+// compression flag is read first, when enabled
+func (o chunksOptions) newReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := o.newUnlimitedReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limitedReadCloser{
+		limitedReader: limitedReader{r: zr, limit: o.decompressionLimit(), err: ErrDecompressionLimit},
+		Closer:        zr,
+	}, nil
+}
+
+func (o chunksOptions) newUnlimitedReader(r io.Reader) (io.ReadCloser, error) {
+	if o.skipIncompressible {
+		var flag [1]byte
+		if _, err := io.ReadFull(r, flag[:]); err != nil {
+			return nil, err
+		}
+
+		switch flag[0] {
+		case compressionFlagRaw:
+			return io.NopCloser(r), nil
+		case compressionFlagCompressed:
+		default:
+			return nil, errors.New("go-airgap payload has incorrect compression flag")
+		}
+	}
+	return o.getCompressor().NewReader(r)
+}
+
+func (o chunksOptions) compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := o.newWriter(&buf)
+
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot compress data: %s", err.Error()))
+	}
+
+	_, err = zw.Write(src)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot write compressed data: %s", err.Error()))
+	}
+
+	if err = zw.Close(); err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot close writer: %s", err.Error()))
+	}
+
+	if o.skipIncompressible && buf.Len() > len(src)+1 {
+		return append([]byte{compressionFlagRaw}, src...), nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (o chunksOptions) uncompress(src []byte) ([]byte, error) {
+	zr, err := o.newReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot uncompress data: %s", err.Error()))
+	}
+
+	defer zr.Close()
+
+	uncompressedBytes, err := io.ReadAll(zr)
+
+	if err != nil {
+		if err == ErrDecompressionLimit {
+			return nil, err
+		}
+		return nil, errors.New(fmt.Sprintf("cannot read uncompressed data: %s", err.Error()))
+	}
+
+	return uncompressedBytes, nil
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, gzip.BestCompression)
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	zr.Multistream(false)
+	return zr, nil
+}
+
+type deflateDictCompressor struct {
+	dict []byte
+}
+
+func (c deflateDictCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriterDict(w, flate.BestCompression, c.dict)
+}
+
+func (c deflateDictCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReaderDict(r, c.dict), nil
+}
+
+type noneCompressor struct{}
+
+func (noneCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (noneCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// trailerReader reads r except the last size bytes, e.g. payload digest
+type trailerReader struct {
+	r    io.Reader
+	size int
+	buf  []byte
+	err  error
+}
+
+func (t *trailerReader) Read(p []byte) (int, error) {
+	for len(t.buf) <= t.size && t.err == nil {
+		chunk := make([]byte, len(p)+t.size)
+		n, err := t.r.Read(chunk)
+		t.buf = append(t.buf, chunk[:n]...)
+		t.err = err
+	}
+
+	if len(t.buf) <= t.size {
+		return 0, t.err
+	}
+
+	n := copy(p, t.buf[:len(t.buf)-t.size])
+	t.buf = t.buf[n:]
+	return n, nil
+}
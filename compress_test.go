@@ -0,0 +1,161 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetCompressor(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	for _, digest := range []bool{false, true} {
+		sender, err := NewChunks().SetCompressor(CompressorNone).SetPayloadDigest(digest).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stored, _ := sender.storage.Get(0)
+		if !bytes.Equal(stored, payload[:len(stored)]) {
+			t.Fatal("payload is compressed")
+		}
+
+		receiver := NewChunks().SetCompressor(CompressorNone).SetPayloadDigest(digest)
+		for _, frame := range sender.SerializeB64() {
+			if _, err = receiver.ReadB64Chunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+
+		var streamed bytes.Buffer
+		if _, err = receiver.WriteDataTo(&streamed, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(streamed.Bytes(), payload) {
+			t.Fatal("incorrect streamed payload")
+		}
+
+		// receiver with default compressor cannot uncompress payload
+		receiver = NewChunks().SetPayloadDigest(digest)
+		for _, frame := range sender.SerializeB64() {
+			if _, err = receiver.ReadB64Chunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err = receiver.DataE(); err == nil {
+			t.Fatal("payload of another compressor is accepted")
+		}
+	}
+}
+
+func TestTrailerReader(t *testing.T) {
+	data := make([]byte, 1000)
+	_, _ = rand.Read(data)
+
+	var result bytes.Buffer
+	if _, err := result.ReadFrom(&trailerReader{r: bytes.NewReader(data), size: 32}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result.Bytes(), data[:len(data)-32]) {
+		t.Fatal("incorrect data without trailer")
+	}
+}
+
+func TestChunks_SetSkipIncompressible(t *testing.T) {
+	random := make([]byte, 3000)
+	_, _ = rand.Read(random)
+
+	for _, payload := range [][]byte{random, bytes.Repeat([]byte("payload"), 500)} {
+		sender, err := NewChunks().SetSkipIncompressible(true).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var size int
+		for i := 0; i < int(sender.Count()); i++ {
+			chunk, _ := sender.storage.Get(i)
+			size += len(chunk)
+		}
+
+		if size > len(payload)+1 {
+			t.Fatal("payload is inflated", size)
+		}
+
+		stored, _ := sender.storage.Get(0)
+		if isRandom := &payload[0] == &random[0]; isRandom != (stored[0] == compressionFlagRaw) {
+			t.Fatal("incorrect compression flag", stored[0])
+		}
+
+		receiver := NewChunks().SetSkipIncompressible(true)
+		for _, frame := range sender.SerializeB64() {
+			if _, err = receiver.ReadB64Chunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+
+		var streamed bytes.Buffer
+		if _, err = receiver.WriteDataTo(&streamed, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(streamed.Bytes(), payload) {
+			t.Fatal("incorrect streamed payload")
+		}
+	}
+}
+
+func TestNewDeflateDictCompressor(t *testing.T) {
+	dict := []byte(`{"jsonrpc":"2.0","method":"eth_sendTransaction","params":[{"from":"0x","to":"0x","value":"0x","gas":"0x"}]}`)
+	payload := []byte(`{"jsonrpc":"2.0","method":"eth_sendTransaction","params":[{"from":"0x01","to":"0x02","value":"0x10","gas":"0x5208"}]}`)
+
+	compressor := NewDeflateDictCompressor(dict)
+
+	compressed, err := (chunksOptions{compressor: compressor}).compress(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := (chunksOptions{}).compress(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(compressed) >= len(plain)/2 {
+		t.Fatal("payload isn't compressed with dictionary", len(compressed), len(plain))
+	}
+
+	uncompressed, err := (chunksOptions{compressor: compressor}).uncompress(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(uncompressed, payload) {
+		t.Fatal("incorrect uncompressed payload")
+	}
+}
@@ -0,0 +1,459 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	configVersion    = 1
+	configHeaderSize = 1 + 1 + compressedPubKeySize // config_version(1) + version(1) + instance_id(33)
+)
+
+// config fields are serialized as field(1) + length(uvarint) + value, numbers
+// are uvarint values
+const (
+	configFieldChunkSize = iota + 1
+	configFieldFrameSize
+	configFieldFlags
+	configFieldDecoys
+	configFieldFrameInterval
+	configFieldEncoding
+	configFieldParity
+	configFieldRedundancy
+	configFieldMaxPayload
+	configFieldMaxDecompressed
+	configFieldURIScheme
+	configFieldCompressor
+	configFieldCodec
+	configFieldChecksummer
+	configFieldFormat
+	configFieldTTL
+	configFieldClockSkew
+	configFieldPaddingBuckets
+	configFieldProfile
+)
+
+// config flags of boolean settings
+const (
+	configFlagFrameTag = 1 << iota
+	configFlagFountain
+	configFlagCompact
+	configFlagWide
+	configFlagMultibase
+	configFlagBase64URL
+	configFlagSkipIncompressible
+	configFlagDigest
+	configFlagTransferId
+	configFlagShuffle
+	configFlagMerkle
+	configFlagChunkChecksum
+	configFlagMagic
+	configFlagCompressFirst
+	configFlagAssociatedData
+	configFlagSequence
+)
+
+// identifiers of built-in compressors
+const (
+	configCompressorGzip = iota + 1
+	configCompressorNone
+	configCompressorDeflateDict
+)
+
+// identifiers of built-in codecs
+const (
+	configCodecBase64 = iota + 1
+	configCodecBase64URL
+	configCodecBase45
+	configCodecBase58
+	configCodecHex
+	configCodecBech32m
+)
+
+// configChecksummerCRC32C is identifier of CRC32C checksummer
+const configChecksummerCRC32C = 1
+
+// ExportConfig serializes instance settings, so another device can be provisioned
+// with identical settings. Keys and secrets, e.g. encryptor, frame key, chunk key,
+// routing key and pairing secret, are not exported and must be defined separately,
+// as well as signer, verifier, templates and replay guard. Custom compressors,
+// codecs and checksummers cannot be exported.
+func (a *AirGap) ExportConfig() ([]byte, error) {
+	result := make([]byte, configHeaderSize)
+	result[0] = configVersion
+	result[1] = a.version
+	copy(result[2:], a.instanceId)
+
+	opts := a.chunksOpts
+
+	var flags uint64
+	for i, enabled := range []bool{
+		opts.tagged, opts.fountain, opts.compact, opts.wide, opts.multibase,
+		opts.base64URL, opts.skipIncompressible, opts.digest, opts.transferId,
+		opts.shuffle, opts.merkle, opts.checksum != nil, a.magic, a.compressFirst,
+		a.associatedData, a.sequence != nil,
+	} {
+		// flags are ordered like configFlag constants
+		if enabled {
+			flags |= 1 << i
+		}
+	}
+
+	for _, field := range []struct {
+		field byte
+		value int64
+	}{
+		{configFieldChunkSize, int64(a.chunkSize)},
+		{configFieldFrameSize, int64(a.frameSize)},
+		{configFieldFlags, int64(flags)},
+		{configFieldDecoys, int64(opts.decoys)},
+		{configFieldFrameInterval, int64(a.profile.FrameInterval / time.Millisecond)},
+		{configFieldEncoding, int64(opts.encoding)},
+		{configFieldParity, int64(opts.parity)},
+		{configFieldRedundancy, int64(opts.redundancy)},
+		{configFieldMaxPayload, opts.maxPayload},
+		{configFieldMaxDecompressed, opts.maxDecompressed},
+		{configFieldFormat, int64(a.format)},
+		{configFieldTTL, int64(a.ttl / time.Millisecond)},
+		{configFieldClockSkew, int64(a.clockSkew / time.Millisecond)},
+	} {
+		if field.value < 0 {
+			return nil, errors.New("go-airgap config has incorrect value")
+		}
+		if field.value > 0 {
+			result = appendConfigField(result, field.field, appendUvarint(nil, uint64(field.value)))
+		}
+	}
+
+	if opts.uriScheme != "" {
+		result = appendConfigField(result, configFieldURIScheme, []byte(opts.uriScheme))
+	}
+
+	for _, field := range []struct {
+		field  byte
+		encode func() ([]byte, error)
+	}{
+		{configFieldCompressor, opts.marshalConfigCompressor},
+		{configFieldCodec, opts.marshalConfigCodec},
+		{configFieldChecksummer, a.marshalConfigChecksummer},
+	} {
+		value, err := field.encode()
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			result = appendConfigField(result, field.field, value)
+		}
+	}
+
+	if len(a.paddingBuckets) > 0 {
+		var buckets []byte
+		for _, bucket := range a.paddingBuckets {
+			buckets = appendUvarint(buckets, uint64(bucket))
+		}
+		result = appendConfigField(result, configFieldPaddingBuckets, buckets)
+	}
+
+	if a.profile.Name != "" {
+		result = appendConfigField(result, configFieldProfile, []byte(a.profile.Name))
+	}
+
+	return result, nil
+}
+
+// ExportConfigB64 represents config as string, ready for QR code
+func (a *AirGap) ExportConfigB64() (string, error) {
+	data, err := a.ExportConfig()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ImportConfig initiates AirGap instance with exported settings
+func ImportConfig(data []byte) (*AirGap, error) {
+	if len(data) < configHeaderSize {
+		return nil, errors.New("go-airgap config has incorrect size")
+	}
+
+	if data[0] != configVersion {
+		return nil, errors.New("go-airgap config version is not supported")
+	}
+
+	a := NewAirGap(data[1], append([]byte{}, data[2:configHeaderSize]...))
+
+	var (
+		flags         int64
+		profileName   string
+		frameInterval int64
+	)
+
+	for data = data[configHeaderSize:]; len(data) > 0; {
+		field := data[0]
+		size, n := binary.Uvarint(data[1:])
+		if n <= 0 || size > uint64(len(data)-1-n) {
+			return nil, errors.New("go-airgap config has incorrect size")
+		}
+
+		value := data[1+n : 1+n+int(size)]
+		data = data[1+n+int(size):]
+
+		var err error
+		switch field {
+		case configFieldFlags:
+			flags, err = configNumber(value)
+		case configFieldFrameInterval:
+			frameInterval, err = configNumber(value)
+		case configFieldURIScheme:
+			a.chunksOpts.uriScheme = string(value)
+		case configFieldCompressor:
+			a.chunksOpts.compressor, err = unmarshalConfigCompressor(value)
+		case configFieldCodec:
+			a.chunksOpts.codec, err = unmarshalConfigCodec(value)
+		case configFieldChecksummer:
+			if len(value) != 1 || value[0] != configChecksummerCRC32C {
+				err = errors.New("go-airgap config has unsupported checksummer")
+			}
+			a.checksummer = CRC32C{}
+		case configFieldPaddingBuckets:
+			a.paddingBuckets, err = unmarshalConfigBuckets(value)
+		case configFieldProfile:
+			profileName = string(value)
+		default:
+			err = a.setConfigNumber(field, value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a.applyConfigFlags(uint64(flags))
+	a.restoreConfigProfile(profileName, time.Duration(frameInterval)*time.Millisecond)
+
+	return a, nil
+}
+
+// ImportConfigB64 initiates AirGap instance with config exported by ExportConfigB64
+func ImportConfigB64(frame string) (*AirGap, error) {
+	data, err := base64.StdEncoding.DecodeString(frame)
+	if err != nil {
+		return nil, errors.New("incorrect go-airgap config")
+	}
+	return ImportConfig(data)
+}
+
+func appendConfigField(dst []byte, field byte, value []byte) []byte {
+	dst = append(dst, field)
+	dst = appendUvarint(dst, uint64(len(value)))
+	return append(dst, value...)
+}
+
+// configNumber parses uvarint value of numeric config field
+func configNumber(value []byte) (int64, error) {
+	number, n := binary.Uvarint(value)
+	if n != len(value) || number > 1<<62 {
+		return 0, errors.New("go-airgap config has incorrect value")
+	}
+	return int64(number), nil
+}
+
+// setConfigNumber applies numeric config field
+func (a *AirGap) setConfigNumber(field byte, value []byte) error {
+	number, err := configNumber(value)
+	if err != nil {
+		return err
+	}
+
+	if field != configFieldMaxPayload && field != configFieldMaxDecompressed && number > 0xFFFFFFFF {
+		return errors.New("go-airgap config has incorrect value")
+	}
+
+	switch field {
+	case configFieldChunkSize:
+		a.chunkSize = int(number)
+	case configFieldFrameSize:
+		a.frameSize = int(number)
+	case configFieldDecoys:
+		a.chunksOpts.decoys = int(number)
+	case configFieldEncoding:
+		a.chunksOpts.encoding = FrameEncoding(number)
+	case configFieldParity:
+		a.chunksOpts.parity = int(number)
+	case configFieldRedundancy:
+		a.chunksOpts.redundancy = int(number)
+	case configFieldMaxPayload:
+		a.chunksOpts.maxPayload = number
+	case configFieldMaxDecompressed:
+		a.chunksOpts.maxDecompressed = number
+	case configFieldFormat:
+		a.format = MessageFormat(number)
+	case configFieldTTL:
+		a.ttl = time.Duration(number) * time.Millisecond
+	case configFieldClockSkew:
+		a.clockSkew = time.Duration(number) * time.Millisecond
+	default:
+		return errors.New("go-airgap config has unsupported field")
+	}
+	return nil
+}
+
+// applyConfigFlags applies boolean settings, chunk checksum is applied after checksummer
+func (a *AirGap) applyConfigFlags(flags uint64) {
+	opts := &a.chunksOpts
+	opts.tagged = flags&configFlagFrameTag != 0
+	opts.fountain = flags&configFlagFountain != 0
+	opts.compact = flags&configFlagCompact != 0
+	opts.wide = flags&configFlagWide != 0
+	opts.multibase = flags&configFlagMultibase != 0
+	opts.base64URL = flags&configFlagBase64URL != 0
+	opts.skipIncompressible = flags&configFlagSkipIncompressible != 0
+	opts.digest = flags&configFlagDigest != 0
+	opts.transferId = flags&configFlagTransferId != 0
+	opts.shuffle = flags&configFlagShuffle != 0
+	opts.merkle = flags&configFlagMerkle != 0
+	a.SetChunkChecksum(flags&configFlagChunkChecksum != 0)
+
+	a.magic = flags&configFlagMagic != 0
+	a.compressFirst = flags&configFlagCompressFirst != 0
+	a.associatedData = flags&configFlagAssociatedData != 0
+	if flags&configFlagSequence != 0 {
+		a.SetSequence(1)
+	}
+}
+
+// restoreConfigProfile restores applied profile, preset profiles are restored
+// by name and custom profiles are restored from imported settings
+func (a *AirGap) restoreConfigProfile(name string, frameInterval time.Duration) {
+	if name == "" {
+		a.profile.FrameInterval = frameInterval
+		return
+	}
+
+	profile, err := ProfileByName(name)
+	if err != nil {
+		profile = Profile{
+			Name:        name,
+			FrameSize:   a.frameSize,
+			FrameTag:    a.chunksOpts.tagged,
+			Encoding:    a.chunksOpts.encoding,
+			Compressor:  a.chunksOpts.compressor,
+			Parity:      a.chunksOpts.parity,
+			Redundancy:  a.chunksOpts.redundancy,
+			Fountain:    a.chunksOpts.fountain,
+			Checksummer: a.checksummer,
+		}
+	}
+
+	profile.FrameInterval = frameInterval
+	a.profile = profile
+}
+
+func (o chunksOptions) marshalConfigCompressor() ([]byte, error) {
+	switch compressor := o.compressor.(type) {
+	case nil:
+		return nil, nil
+	case gzipCompressor:
+		return []byte{configCompressorGzip}, nil
+	case noneCompressor:
+		return []byte{configCompressorNone}, nil
+	case deflateDictCompressor:
+		return append([]byte{configCompressorDeflateDict}, compressor.dict...), nil
+	}
+	return nil, errors.New("go-airgap config doesn't support custom compressor")
+}
+
+func unmarshalConfigCompressor(value []byte) (Compressor, error) {
+	if len(value) > 0 {
+		switch value[0] {
+		case configCompressorGzip:
+			return CompressorGzip, nil
+		case configCompressorNone:
+			return CompressorNone, nil
+		case configCompressorDeflateDict:
+			return NewDeflateDictCompressor(value[1:]), nil
+		}
+	}
+	return nil, errors.New("go-airgap config has unsupported compressor")
+}
+
+func (o chunksOptions) marshalConfigCodec() ([]byte, error) {
+	switch codec := o.codec.(type) {
+	case nil:
+		return nil, nil
+	case base64Codec:
+		if codec.encoding == base64.RawURLEncoding {
+			return []byte{configCodecBase64URL}, nil
+		}
+		return []byte{configCodecBase64}, nil
+	case base45Codec:
+		return []byte{configCodecBase45}, nil
+	case base58Codec:
+		return []byte{configCodecBase58}, nil
+	case hexCodec:
+		return []byte{configCodecHex}, nil
+	case bech32mCodec:
+		return append([]byte{configCodecBech32m}, codec.hrp...), nil
+	}
+	return nil, errors.New("go-airgap config doesn't support custom codec")
+}
+
+func unmarshalConfigCodec(value []byte) (FrameCodec, error) {
+	if len(value) > 0 {
+		switch value[0] {
+		case configCodecBase64:
+			return CodecBase64, nil
+		case configCodecBase64URL:
+			return CodecBase64URL, nil
+		case configCodecBase45:
+			return CodecBase45, nil
+		case configCodecBase58:
+			return CodecBase58, nil
+		case configCodecHex:
+			return CodecHex, nil
+		case configCodecBech32m:
+			return NewBech32mCodec(string(value[1:]))
+		}
+	}
+	return nil, errors.New("go-airgap config has unsupported codec")
+}
+
+func (a *AirGap) marshalConfigChecksummer() ([]byte, error) {
+	switch a.checksummer.(type) {
+	case nil:
+		return nil, nil
+	case CRC32C:
+		return []byte{configChecksummerCRC32C}, nil
+	}
+	return nil, errors.New("go-airgap config doesn't support custom checksummer")
+}
+
+func unmarshalConfigBuckets(value []byte) ([]int, error) {
+	var buckets []int
+	for len(value) > 0 {
+		bucket, n := binary.Uvarint(value)
+		if n <= 0 || bucket == 0 || bucket > uint64(maxPayloadSize) {
+			return nil, errors.New("go-airgap config has incorrect padding bucket")
+		}
+		buckets = append(buckets, int(bucket))
+		value = value[n:]
+	}
+	return buckets, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAirGap_ExportConfig(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetProfile(ProfileEInk).
+		SetPaddingBuckets(256, 1024).
+		SetDecoyFrames(3)
+
+	config, err := airGap.ExportConfigB64()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := ImportConfigB64(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(imported, airGap) {
+		t.Fatal("incorrect imported config")
+	}
+
+	plain := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	data, err := plain.ExportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err = ImportConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(imported, plain) {
+		t.Fatal("incorrect imported default config")
+	}
+
+	for _, size := range []int{0, configHeaderSize - 1} {
+		if _, err = ImportConfig(data[:size]); err == nil {
+			t.Fatal("truncated config is accepted", size)
+		}
+	}
+}
+
+func TestAirGap_ExportConfigAllOptions(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codec, err := NewBech32mCodec("ag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetProfile(ProfilePrint).
+		SetChecksummer(CRC32C{}).
+		SetChunkChecksum(true).
+		SetCompactHeaders(true).
+		SetWideHeaders(true).
+		SetTransferId(true).
+		SetEncoding(EncodingBase32).
+		SetMultibase(true).
+		SetBase64URL(true).
+		SetFrameCodec(codec).
+		SetCompressor(NewDeflateDictCompressor([]byte("dictionary"))).
+		SetSkipIncompressible(true).
+		SetPayloadDigest(true).
+		SetMerkleProofs(true).
+		SetFountain(true).
+		SetParity(3).
+		SetRedundancy(2).
+		SetShuffle(true).
+		SetDecoyFrames(4).
+		SetURIScheme("airgap:").
+		SetMaxPayloadSize(1<<40).
+		SetMaxDecompressedSize(1<<20).
+		SetMagic(true).
+		SetMessageFormat(MessageFormatCBOR).
+		SetCompressBeforeEncrypt(true).
+		SetAssociatedData(true).
+		SetSequence(1).
+		SetMessageTTL(time.Minute).
+		SetClockSkew(5*time.Second).
+		SetPaddingBuckets(256, 1024)
+
+	data, err := airGap.ExportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := ImportConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(imported, airGap) {
+		t.Fatalf("incorrect imported config\n%+v\n%+v", imported, airGap)
+	}
+
+	for size := configHeaderSize + 1; size < len(data); size++ {
+		if imported, err = ImportConfig(data[:size]); err == nil && reflect.DeepEqual(imported, airGap) {
+			t.Fatal("truncated config is imported", size)
+		}
+	}
+
+	if _, err = airGap.SetCompressor(customCompressor{}).ExportConfig(); err == nil {
+		t.Fatal("custom compressor is exported")
+	}
+}
+
+// customCompressor is compressor unknown to config
+type customCompressor struct {
+	noneCompressor
+}
+
+func TestAirGap_ExportConfigSecrets(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetFrameKey([]byte("frame key")).
+		SetPrivacyMode([]byte("routing key")).
+		SetEncryptorDecryptor(NewDummyEncryptorDecryptor())
+
+	data, err := airGap.ExportConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := ImportConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.chunksOpts.frameKey != nil || imported.routingKey != nil || imported.ed != nil {
+		t.Fatal("secrets are exported")
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cosecrypt wraps go-airgap payloads in COSE envelopes (RFC 9052):
+// COSE_Encrypt0 with AES-GCM and optional COSE_Sign1 with ES256, for
+// embedded and IoT receivers which already ship COSE libraries.
+package cosecrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	tagEncrypt0 = 16
+	tagSign1    = 18
+
+	headerAlg = 1
+	headerIV  = 5
+
+	algA128GCM = 1
+	algA256GCM = 3
+	algES256   = -7
+
+	es256ComponentSize = 32
+)
+
+type encrypt0 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[int]interface{}
+	Ciphertext  []byte
+}
+
+type sign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[int]interface{}
+	Payload     []byte
+	Signature   []byte
+}
+
+// EncryptorDecryptor implements go_airgap.EncryptorDecryptor with COSE_Encrypt0,
+// optionally wrapped into COSE_Sign1
+type EncryptorDecryptor struct {
+	key      []byte
+	alg      int
+	signer   *ecdsa.PrivateKey
+	verifier *ecdsa.PublicKey
+}
+
+// NewEncryptorDecryptor initiates COSE_Encrypt0 envelope with 16 or 32 bytes
+// AES-GCM key
+func NewEncryptorDecryptor(key []byte) (*EncryptorDecryptor, error) {
+	ed := &EncryptorDecryptor{key: key}
+
+	switch len(key) {
+	case 16:
+		ed.alg = algA128GCM
+	case 32:
+		ed.alg = algA256GCM
+	default:
+		return nil, errors.New("cose key size must be 16 or 32 bytes")
+	}
+
+	return ed, nil
+}
+
+// SetSigner wraps encrypted payloads into COSE_Sign1 signed with P-256 key,
+// Encrypt returns error for key on another curve
+func (ed *EncryptorDecryptor) SetSigner(signer *ecdsa.PrivateKey) *EncryptorDecryptor {
+	ed.signer = signer
+	return ed
+}
+
+// SetVerifier requires payloads wrapped into COSE_Sign1, signed by P-256 key
+func (ed *EncryptorDecryptor) SetVerifier(verifier *ecdsa.PublicKey) *EncryptorDecryptor {
+	ed.verifier = verifier
+	return ed
+}
+
+func (ed *EncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	aead, err := ed.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	protected, err := cbor.Marshal(map[int]int{headerAlg: ed.alg})
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	aad, err := encStructure(protected)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cbor.Marshal(cbor.Tag{
+		Number: tagEncrypt0,
+		Content: encrypt0{
+			Protected:   protected,
+			Unprotected: map[int]interface{}{headerIV: iv},
+			Ciphertext:  aead.Seal(nil, iv, data, aad),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ed.signer != nil {
+		return ed.sign(result)
+	}
+	return result, nil
+}
+
+func (ed *EncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	var err error
+
+	if ed.verifier != nil {
+		data, err = ed.verify(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var msg encrypt0
+	if err = unmarshalTagged(data, tagEncrypt0, &msg); err != nil {
+		return nil, err
+	}
+
+	var protected map[int]int
+	if err = cbor.Unmarshal(msg.Protected, &protected); err != nil {
+		return nil, err
+	}
+
+	if protected[headerAlg] != ed.alg {
+		return nil, errors.New("cose message has unsupported algorithm")
+	}
+
+	iv, ok := msg.Unprotected[headerIV].([]byte)
+	if !ok {
+		return nil, errors.New("cose message iv is not defined")
+	}
+
+	aead, err := ed.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aead.NonceSize() {
+		return nil, errors.New("cose message has incorrect iv")
+	}
+
+	aad, err := encStructure(msg.Protected)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, iv, msg.Ciphertext, aad)
+}
+
+func (ed *EncryptorDecryptor) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(ed.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (ed *EncryptorDecryptor) sign(payload []byte) ([]byte, error) {
+	if ed.signer.Curve != elliptic.P256() {
+		return nil, errors.New("cose signer has unsupported curve")
+	}
+
+	protected, err := cbor.Marshal(map[int]int{headerAlg: algES256})
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := sigStructure(protected, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	r, s, err := ecdsa.Sign(rand.Reader, ed.signer, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := make([]byte, 2*es256ComponentSize)
+	r.FillBytes(signature[:es256ComponentSize])
+	s.FillBytes(signature[es256ComponentSize:])
+
+	return cbor.Marshal(cbor.Tag{
+		Number: tagSign1,
+		Content: sign1{
+			Protected:   protected,
+			Unprotected: map[int]interface{}{},
+			Payload:     payload,
+			Signature:   signature,
+		},
+	})
+}
+
+func (ed *EncryptorDecryptor) verify(data []byte) ([]byte, error) {
+	var msg sign1
+	if err := unmarshalTagged(data, tagSign1, &msg); err != nil {
+		return nil, err
+	}
+
+	var protected map[int]int
+	if err := cbor.Unmarshal(msg.Protected, &protected); err != nil {
+		return nil, err
+	}
+
+	if protected[headerAlg] != algES256 || ed.verifier.Curve != elliptic.P256() {
+		return nil, errors.New("cose signature has unsupported algorithm")
+	}
+
+	if len(msg.Signature) != 2*es256ComponentSize {
+		return nil, errors.New("cose signature has incorrect size")
+	}
+
+	digest, err := sigStructure(msg.Protected, msg.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(big.Int).SetBytes(msg.Signature[:es256ComponentSize])
+	s := new(big.Int).SetBytes(msg.Signature[es256ComponentSize:])
+
+	if !ecdsa.Verify(ed.verifier, digest, r, s) {
+		return nil, errors.New("cose signature verification failed")
+	}
+
+	return msg.Payload, nil
+}
+
+// encStructure returns additional authenticated data of COSE_Encrypt0
+func encStructure(protected []byte) ([]byte, error) {
+	return cbor.Marshal([]interface{}{"Encrypt0", protected, []byte{}})
+}
+
+// sigStructure returns SHA-256 digest of COSE_Sign1 signature structure
+func sigStructure(protected, payload []byte) ([]byte, error) {
+	structure, err := cbor.Marshal([]interface{}{"Signature1", protected, []byte{}, payload})
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(structure)
+	return digest[:], nil
+}
+
+func unmarshalTagged(data []byte, number uint64, v interface{}) error {
+	var tag cbor.RawTag
+	if err := cbor.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+
+	if tag.Number != number {
+		return errors.New("cose message has unexpected tag")
+	}
+
+	return cbor.Unmarshal(tag.Content, v)
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cosecrypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+const (
+	testPassphrase = "My dummy AES256 password 0123456"
+)
+
+func TestEncryptorDecryptor(t *testing.T) {
+	ed, err := NewEncryptorDecryptor([]byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := ed.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encrypted[0] != 0xD0 {
+		t.Fatal("message is not tagged as COSE_Encrypt0")
+	}
+
+	decrypted, err := ed.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+}
+
+func TestEncryptorDecryptor_Sign1(t *testing.T) {
+	signer, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	ed, err := NewEncryptorDecryptor([]byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ed.SetSigner(signer).SetVerifier(&signer.PublicKey)
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := ed.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encrypted[0] != 0xD2 {
+		t.Fatal("message is not tagged as COSE_Sign1")
+	}
+
+	decrypted, err := ed.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	stranger, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	ed.SetVerifier(&stranger.PublicKey)
+	if _, err = ed.Decrypt(encrypted); err == nil {
+		t.Fatal("message with foreign signature accepted")
+	}
+
+	// ES256 requires P-256 key
+	signer, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	if _, err = ed.SetSigner(signer).Encrypt(data); err == nil {
+		t.Fatal("message is signed with P-384 key")
+	}
+}
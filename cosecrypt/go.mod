@@ -0,0 +1,7 @@
+module github.com/censync/go-airgap/cosecrypt
+
+go 1.18
+
+require github.com/fxamacker/cbor/v2 v2.4.0
+
+require github.com/x448/float16 v0.8.4 // indirect
@@ -0,0 +1,157 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	frameAuthTagSize = 8
+)
+
+// SetFrameKey enables truncated HMAC-SHA256 tag for every frame. Receiver with
+// the same key ignores frames with incorrect tag, e.g. decoy frames.
+func (ch *Chunks) SetFrameKey(key []byte) *Chunks {
+	ch.opts.frameKey = key
+	return ch
+}
+
+// SetDecoyFrames injects count of dummy frames to random positions of serialized
+// frames. Decoys are indistinguishable without frame key, so the true transmission
+// length and timing are obscured. Requires frame key.
+func (ch *Chunks) SetDecoyFrames(count int) *Chunks {
+	ch.opts.decoys = count
+	return ch
+}
+
+// SetFrameKey enables frames authentication, see Chunks.SetFrameKey
+func (a *AirGap) SetFrameKey(key []byte) *AirGap {
+	a.chunksOpts.frameKey = key
+	return a
+}
+
+// SetDecoyFrames injects dummy frames to serialized messages, see Chunks.SetDecoyFrames
+func (a *AirGap) SetDecoyFrames(count int) *AirGap {
+	a.chunksOpts.decoys = count
+	return a
+}
+
+func frameAuthTag(key, frame []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(frame)
+	return mac.Sum(nil)[:frameAuthTagSize]
+}
+
+func verifyFrameAuthTag(key, frame []byte) bool {
+	payload := frame[:len(frame)-frameAuthTagSize]
+	return hmac.Equal(frame[len(frame)-frameAuthTagSize:], frameAuthTag(key, payload))
+}
+
+// decoyFrame returns frame with random index and payload, which has the same
+// header, size and checksum as real frames, only auth tag is incorrect
+func (ch *Chunks) decoyFrame() ([]byte, error) {
+	index, err := randomIndex(int(ch.count))
+	if err != nil {
+		return nil, err
+	}
+
+	header := ch.chunkHeader(uint32(index))
+
+	size := int(ch.size)
+	if ch.opts.merkle && !ch.opts.fountain {
+		size += merkleProofSize(ch.count)
+	}
+
+	frame := make([]byte, len(header)+size)
+	copy(frame, header)
+	if _, err = io.ReadFull(rand.Reader, frame[len(header):]); err != nil {
+		return nil, err
+	}
+
+	// encrypted frame is nonce of the same message id and random ciphertext
+	if ch.frameCipher != nil {
+		encrypted := make([]byte, chunkNonceSize+len(frame)+chunkTagSize)
+		copy(encrypted, ch.messageId)
+		binary.BigEndian.PutUint32(encrypted[chunkMessageIdSize:], uint32(index))
+		if _, err = io.ReadFull(rand.Reader, encrypted[chunkNonceSize:]); err != nil {
+			return nil, err
+		}
+		frame = encrypted
+	}
+
+	if ch.opts.transferId {
+		frame = append(append([]byte{}, ch.transferId...), frame...)
+	}
+
+	if ch.opts.checksum != nil {
+		frame = append(frame, ch.opts.checksum.Checksum(frame)...)
+	}
+
+	tag := make([]byte, frameAuthTagSize)
+	if _, err = io.ReadFull(rand.Reader, tag); err != nil {
+		return nil, err
+	}
+	return append(frame, tag...), nil
+}
+
+// framesWithDecoys returns serialized frames with injected decoys
+func (ch *Chunks) framesWithDecoys() [][]byte {
+	var frames [][]byte
+	for i := uint32(0); i < ch.count; i++ {
+		frames = append(frames, ch.getChunkWithHeader(i))
+	}
+	frames = append(frames, ch.parityFramesWithHeader()...)
+	frames = ch.opts.withRedundancy(ch.withShuffle(frames))
+
+	if ch.opts.frameKey == nil || ch.count == 0 {
+		return frames
+	}
+
+	for i := 0; i < ch.opts.decoys; i++ {
+		decoy, err := ch.decoyFrame()
+		if err != nil {
+			break
+		}
+
+		position, err := randomIndex(len(frames) + 1)
+		if err != nil {
+			break
+		}
+
+		frames = append(frames[:position], append([][]byte{decoy}, frames[position:]...)...)
+	}
+
+	return frames
+}
+
+// randomIndex returns uniform random value in [0, n)
+func randomIndex(n int) (int, error) {
+	if n <= 0 {
+		return 0, errors.New("incorrect random range")
+	}
+
+	var buf [8]byte
+	if _, err := io.ReadFull(rand.Reader, buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int(binary.BigEndian.Uint64(buf[:]) % uint64(n)), nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestDecoy_SetDecoyFrames(t *testing.T) {
+	frameKey := []byte("dummy frame key")
+
+	payload := make([]byte, 1024)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	chunks, err := NewChunks().SetFrameKey(frameKey).SetDecoyFrames(5).SetData(payload, defaultChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := chunks.SerializeB64()
+	if len(frames) != int(chunks.Count())+5 {
+		t.Fatalf("incorrect frames count %d", len(frames))
+	}
+
+	for i := range frames {
+		if len(frames[i]) != len(frames[0]) {
+			t.Fatal("decoy frame is distinguishable by size")
+		}
+	}
+
+	readedChunks := NewChunks().SetFrameKey(frameKey)
+
+	for i := range frames {
+		if _, err = readedChunks.ReadB64Chunk(frames[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !readedChunks.IsFilled() {
+		t.Fatal("chunks are not filled")
+	}
+
+	if !reflect.DeepEqual(payload, readedChunks.Data()) {
+		t.Fatal("mismatch marshalled data")
+	}
+}
+
+func TestDecoy_Headers(t *testing.T) {
+	payload := make([]byte, 1000)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	for name, chunks := range map[string]*Chunks{
+		"default":  NewChunks(),
+		"compact":  NewChunks().SetCompactHeaders(true),
+		"wide":     NewChunks().SetWideHeaders(true),
+		"fountain": NewChunks().SetFountain(true),
+	} {
+		chunks, err := chunks.SetCompressor(CompressorNone).
+			SetChunkChecksum(CRC32C{}).
+			SetFrameKey([]byte("dummy frame key")).
+			SetDecoyFrames(20).
+			SetData(payload, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		frames := chunks.framesWithDecoys()
+		if len(frames) != int(chunks.Count())+20 {
+			t.Fatal("incorrect frames count", name, len(frames))
+		}
+
+		for _, frame := range frames {
+			// checksum of decoy is correct, only auth tag differs
+			frame, err = chunks.opts.verifyChunkChecksum(frame[:len(frame)-frameAuthTagSize])
+			if err != nil {
+				t.Fatal(name, err)
+			}
+
+			isReal := false
+			for index := 0; index < int(chunks.Count()); index++ {
+				if bytes.HasPrefix(frame, chunks.chunkHeader(uint32(index))) {
+					isReal = true
+					break
+				}
+			}
+
+			if !isReal {
+				t.Fatal("decoy frame is distinguishable by header", name)
+			}
+		}
+	}
+}
+
+func TestDecoy_EncryptedFrames(t *testing.T) {
+	payload := make([]byte, 1000)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	key := make([]byte, 32)
+	frameKey := []byte("dummy frame key")
+
+	chunks, err := NewChunks().SetCompressor(CompressorNone).
+		SetChunkKey(key).
+		SetFrameKey(frameKey).
+		SetDecoyFrames(20).
+		SetData(payload, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := chunks.framesWithDecoys()
+	if len(frames) != int(chunks.Count())+20 {
+		t.Fatal("incorrect frames count", len(frames))
+	}
+
+	readedChunks := NewChunks().SetCompressor(CompressorNone).SetChunkKey(key).SetFrameKey(frameKey)
+
+	for _, frame := range frames {
+		if len(frame) != len(frames[0]) {
+			t.Fatal("decoy frame is distinguishable by size")
+		}
+
+		// nonce of decoy has message id and index of real frames
+		if !bytes.Equal(frame[:chunkMessageIdSize], chunks.messageId) ||
+			binary.BigEndian.Uint32(frame[chunkMessageIdSize:chunkNonceSize]) >= uint32(chunks.Count()) {
+			t.Fatal("decoy frame is distinguishable by nonce")
+		}
+
+		if _, err = readedChunks.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !readedChunks.IsFilled() || !reflect.DeepEqual(payload, readedChunks.Data()) {
+		t.Fatal("mismatch marshalled data")
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// OpCodeDeltaPatch is standard operation with binary delta of the operation
+	// payload against base payload, which receiver already holds
+	OpCodeDeltaPatch uint16 = 0xFF02
+	// OpCodeDeltaMessage is standard operation with binary delta of message
+	// operations against previously acknowledged message, see Message.Delta
+	OpCodeDeltaMessage uint16 = 0xFF05
+
+	deltaBlockSize = 16
+
+	deltaCopy   = 0
+	deltaInsert = 1
+)
+
+// PayloadLookup returns payload by SHA-256 digest, e.g. from the receiver history
+type PayloadLookup func(digest []byte) ([]byte, bool)
+
+// Diff returns binary delta, which restores target from base with Patch.
+// Delta contains SHA-256 digest of base, so receiver can find it.
+func Diff(base, target []byte) []byte {
+	digest := sha256.Sum256(base)
+
+	result := append([]byte{}, digest[:]...)
+	result = appendUvarint(result, uint64(len(target)))
+
+	blocks := make(map[string]int)
+	for offset := 0; offset+deltaBlockSize <= len(base); offset += deltaBlockSize {
+		if _, ok := blocks[string(base[offset:offset+deltaBlockSize])]; !ok {
+			blocks[string(base[offset:offset+deltaBlockSize])] = offset
+		}
+	}
+
+	literal := 0
+	for iter := 0; iter+deltaBlockSize <= len(target); {
+		offset, ok := blocks[string(target[iter:iter+deltaBlockSize])]
+		if !ok {
+			iter++
+			continue
+		}
+
+		// Extend match backward over pending literal and forward
+		start := iter
+		for start > literal && offset > 0 && base[offset-1] == target[start-1] {
+			start--
+			offset--
+		}
+
+		end := iter + deltaBlockSize
+		for end < len(target) && offset+end-start < len(base) && base[offset+end-start] == target[end] {
+			end++
+		}
+
+		if start > literal {
+			result = appendDeltaInsert(result, target[literal:start])
+		}
+
+		result = append(result, deltaCopy)
+		result = appendUvarint(result, uint64(offset))
+		result = appendUvarint(result, uint64(end-start))
+
+		iter, literal = end, end
+	}
+
+	if literal < len(target) {
+		result = appendDeltaInsert(result, target[literal:])
+	}
+
+	return result
+}
+
+// Patch restores target from base and delta produced by Diff, target size is
+// limited with limit, maxPayloadSize is used when limit isn't positive
+func Patch(base, delta []byte, limit int64) ([]byte, error) {
+	if len(delta) < sha256.Size {
+		return nil, errors.New("go-airgap delta to small")
+	}
+
+	digest := sha256.Sum256(base)
+	if !bytes.Equal(digest[:], delta[:sha256.Size]) {
+		return nil, errors.New("go-airgap delta has incorrect base")
+	}
+
+	delta = delta[sha256.Size:]
+
+	size, n := binary.Uvarint(delta)
+	if n <= 0 {
+		return nil, errors.New("go-airgap delta is corrupted")
+	}
+	delta = delta[n:]
+
+	if limit <= 0 {
+		limit = maxPayloadSize
+	}
+
+	if size > uint64(limit) {
+		return nil, ErrDecompressionLimit
+	}
+
+	// declared size is untrusted, copies are bounded by base and inserts by delta
+	capacity := size
+	if bound := uint64(len(base) + len(delta)); capacity > bound {
+		capacity = bound
+	}
+	result := make([]byte, 0, capacity)
+
+	for len(delta) > 0 {
+		instruction := delta[0]
+		delta = delta[1:]
+
+		switch instruction {
+		case deltaCopy:
+			offset, n := binary.Uvarint(delta)
+			if n <= 0 {
+				return nil, errors.New("go-airgap delta is corrupted")
+			}
+			delta = delta[n:]
+
+			length, n := binary.Uvarint(delta)
+			if n <= 0 || offset > uint64(len(base)) || length > uint64(len(base))-offset {
+				return nil, errors.New("go-airgap delta is corrupted")
+			}
+			delta = delta[n:]
+
+			result = append(result, base[offset:offset+length]...)
+		case deltaInsert:
+			length, n := binary.Uvarint(delta)
+			if n <= 0 || length > uint64(len(delta)-n) {
+				return nil, errors.New("go-airgap delta is corrupted")
+			}
+			delta = delta[n:]
+
+			result = append(result, delta[:length]...)
+			delta = delta[length:]
+		default:
+			return nil, errors.New("go-airgap delta is corrupted")
+		}
+
+		if uint64(len(result)) > size {
+			return nil, errors.New("go-airgap delta is corrupted")
+		}
+	}
+
+	if uint64(len(result)) != size {
+		return nil, errors.New("go-airgap delta is corrupted")
+	}
+
+	return result, nil
+}
+
+// DeltaBase returns SHA-256 digest of delta base payload
+func DeltaBase(delta []byte) []byte {
+	if len(delta) < sha256.Size {
+		return nil
+	}
+	return delta[:sha256.Size]
+}
+
+// AddDeltaOperation adds OpCodeDeltaPatch operation, which is restored to opCode
+// operation with data by receiver, who holds base payload, see Message.ApplyDeltas
+func (m *Message) AddDeltaOperation(opCode uint16, base, data []byte) *Message {
+	payload := make([]byte, 2, 2+sha256.Size)
+	binary.BigEndian.PutUint16(payload, opCode)
+	return m.AddOperation(OpCodeDeltaPatch, append(payload, Diff(base, data)...))
+}
+
+// ApplyDeltas replaces OpCodeDeltaPatch operations with restored operations,
+// base payloads are requested with lookup. Restored payloads are limited with
+// AirGap.SetMaxDecompressedSize.
+func (m *Message) ApplyDeltas(lookup PayloadLookup) error {
+	for i, op := range m.Operations {
+		if op.OpCode != OpCodeDeltaPatch {
+			continue
+		}
+
+		if len(op.Data) < 2+sha256.Size {
+			return errors.New("go-airgap delta to small")
+		}
+
+		base, ok := lookup(DeltaBase(op.Data[2:]))
+		if !ok {
+			return errors.New("go-airgap delta base is not found")
+		}
+
+		data, err := Patch(base, op.Data[2:], m.chunksOpts.decompressionLimit())
+		if err != nil {
+			return err
+		}
+
+		m.Operations[i] = &Operation{
+			OpCode: binary.BigEndian.Uint16(op.Data),
+			Size:   uint32(len(data)),
+			Data:   data,
+		}
+		m.cached = nil
+	}
+	return nil
+}
+
+// Id returns SHA-256 digest of serialized operations, which identifies
+// message as delta base
+func (m *Message) Id() []byte {
+	digest := sha256.Sum256(m.serializeOperations())
+	return digest[:]
+}
+
+// Delta returns message with single OpCodeDeltaMessage operation, which
+// encodes operations of m against base message, already acknowledged by
+// receiver. Receiver restores operations with Message.ApplyDeltaMessage.
+func (m *Message) Delta(base *Message) *Message {
+	result := *m
+	result.Operations = nil
+	result.Sequence = 0
+	result.CreatedAt, result.ExpiresAt = time.Time{}, time.Time{}
+	result.cached = nil
+	return result.AddOperation(OpCodeDeltaMessage, Diff(base.serializeOperations(), m.serializeOperations()))
+}
+
+// ApplyDeltaMessage replaces OpCodeDeltaMessage operation with restored
+// operations, base message operations are requested with lookup by message Id,
+// e.g. from MessageHistory. Restored operations are limited with
+// AirGap.SetMaxDecompressedSize.
+func (m *Message) ApplyDeltaMessage(lookup PayloadLookup) error {
+	if len(m.Operations) != 1 || m.Operations[0].OpCode != OpCodeDeltaMessage {
+		return nil
+	}
+
+	delta := m.Operations[0].Data
+
+	base, ok := lookup(DeltaBase(delta))
+	if !ok {
+		return errors.New("go-airgap delta base message is not found")
+	}
+
+	data, err := Patch(base, delta, m.chunksOpts.decompressionLimit())
+	if err != nil {
+		return err
+	}
+
+	var operations []*Operation
+	for iter := 0; iter < len(data); {
+		opCode, size, headerSize, err := parseOperationHeader(data[iter:], false)
+		if err != nil {
+			return err
+		}
+
+		iter += headerSize
+		if uint64(size) > uint64(len(data)-iter) {
+			return errors.New("go-airgap operation is truncated")
+		}
+
+		operations = append(operations, &Operation{
+			OpCode: opCode,
+			Size:   size,
+			Data:   data[iter : iter+int(size)],
+		})
+		iter += int(size)
+	}
+
+	m.Operations = operations
+	m.cached = nil
+	return nil
+}
+
+// serializeOperations serializes operations regardless of message format
+func (m *Message) serializeOperations() []byte {
+	var result []byte
+	for _, op := range m.Operations {
+		result = appendOperationHeader(result, op.OpCode, uint32(len(op.Data)), false)
+		result = append(result, op.Data...)
+	}
+	return result
+}
+
+// MessageHistory keeps operations of received or acknowledged messages, which
+// are delta bases of the next messages
+type MessageHistory struct {
+	mu       sync.RWMutex
+	messages map[[sha256.Size]byte][]byte
+}
+
+func NewMessageHistory() *MessageHistory {
+	return &MessageHistory{messages: make(map[[sha256.Size]byte][]byte)}
+}
+
+// Add saves message and returns its Id
+func (h *MessageHistory) Add(m *Message) []byte {
+	data := m.serializeOperations()
+	id := sha256.Sum256(data)
+
+	h.mu.Lock()
+	h.messages[id] = data
+	h.mu.Unlock()
+
+	return id[:]
+}
+
+// Remove deletes message with id
+func (h *MessageHistory) Remove(id []byte) {
+	var key [sha256.Size]byte
+	copy(key[:], id)
+
+	h.mu.Lock()
+	delete(h.messages, key)
+	h.mu.Unlock()
+}
+
+// Lookup returns serialized operations of message with id, it implements PayloadLookup
+func (h *MessageHistory) Lookup(id []byte) ([]byte, bool) {
+	if len(id) != sha256.Size {
+		return nil, false
+	}
+
+	var key [sha256.Size]byte
+	copy(key[:], id)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	data, ok := h.messages[key]
+	return data, ok
+}
+
+func appendDeltaInsert(dst, data []byte) []byte {
+	dst = append(dst, deltaInsert)
+	dst = appendUvarint(dst, uint64(len(data)))
+	return append(dst, data...)
+}
+
+func appendUvarint(dst []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], value)
+	return append(dst, buf[:n]...)
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	base := make([]byte, 10000)
+	_, _ = rand.Read(base)
+
+	target := append([]byte{}, base[:3000]...)
+	target = append(target, []byte("inserted paragraph")...)
+	target = append(target, base[3100:]...)
+	target[7000] ^= 0xFF
+
+	delta := Diff(base, target)
+
+	if len(delta) > 200 {
+		t.Fatal("delta is too large", len(delta))
+	}
+
+	restored, err := Patch(base, delta, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(restored, target) {
+		t.Fatal("incorrect restored payload")
+	}
+
+	if _, err = Patch(base, delta, int64(len(target)-1)); err != ErrDecompressionLimit {
+		t.Fatal("delta exceeding limit is accepted", err)
+	}
+
+	if _, err = Patch(target, delta, 0); err == nil {
+		t.Fatal("delta is applied to incorrect base")
+	}
+
+	for _, tc := range [][2][]byte{{nil, nil}, {nil, []byte("new")}, {[]byte("short base"), []byte("short target")}} {
+		restored, err = Patch(tc[0], Diff(tc[0], tc[1]), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(restored, tc[1]) {
+			t.Fatal("incorrect restored payload")
+		}
+	}
+
+	for size := sha256.Size; size < len(delta); size++ {
+		if _, err = Patch(base, delta[:size], 0); err == nil {
+			t.Fatal("truncated delta is accepted", size)
+		}
+	}
+
+	// crafted declared size must not be preallocated
+	digest := sha256.Sum256(base)
+	for _, size := range []uint64{1 << 62, uint64(maxPayloadSize), 1 << 30} {
+		crafted := appendUvarint(append([]byte{}, digest[:]...), size)
+		crafted = appendDeltaInsert(crafted, []byte("payload"))
+
+		if _, err = Patch(base, crafted, 0); err == nil {
+			t.Fatal("delta with incorrect size is accepted", size)
+		}
+	}
+}
+
+func TestMessage_ApplyDeltas(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	base := bytes.Repeat([]byte("document line\n"), 200)
+	target := append(append([]byte{}, base...), []byte("appended line\n")...)
+
+	data, err := airGap.CreateMessage().
+		AddOperation(opCodeTest1, []byte("plain")).
+		AddDeltaOperation(opCodeTest2, base, target).
+		Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := airGap.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Operations[1].OpCode != OpCodeDeltaPatch || int(message.Operations[1].Size) >= len(target) {
+		t.Fatal("incorrect delta operation")
+	}
+
+	history := map[[sha256.Size]byte][]byte{sha256.Sum256(base): base}
+	lookup := func(digest []byte) ([]byte, bool) {
+		var key [sha256.Size]byte
+		copy(key[:], digest)
+		payload, ok := history[key]
+		return payload, ok
+	}
+
+	limited, err := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetMaxDecompressedSize(int64(len(target) - 1)).
+		Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = limited.ApplyDeltas(lookup); err != ErrDecompressionLimit {
+		t.Fatal("restored payload exceeding limit is accepted", err)
+	}
+
+	if err = message.ApplyDeltas(lookup); err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Operations[1].OpCode != opCodeTest2 || !bytes.Equal(message.Operations[1].Data, target) {
+		t.Fatal("incorrect restored operation")
+	}
+
+	if !bytes.Equal(message.Operations[0].Data, []byte("plain")) {
+		t.Fatal("incorrect plain operation")
+	}
+}
+
+func TestMessage_Delta(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	tx := make([]byte, 4000)
+	_, _ = rand.Read(tx)
+
+	base := airGap.CreateMessage().
+		AddOperation(opCodeTest1, []byte("unsigned tx")).
+		AddOperation(opCodeTest2, tx)
+
+	updated := append(append([]byte{}, tx[:2000]...), []byte("updated input")...)
+	updated = append(updated, tx[2000:]...)
+
+	target := airGap.CreateMessage().
+		AddOperation(opCodeTest1, []byte("unsigned tx")).
+		AddOperation(opCodeTest2, updated)
+
+	full, err := target.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := target.Delta(base).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) >= len(full)/10 {
+		t.Fatal("delta message is not shrunk", len(data), len(full))
+	}
+
+	message, err := airGap.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history := NewMessageHistory()
+	if err = message.ApplyDeltaMessage(history.Lookup); err == nil {
+		t.Fatal("delta message is applied without base")
+	}
+
+	if id := history.Add(base); !bytes.Equal(id, base.Id()) {
+		t.Fatal("incorrect message id")
+	}
+
+	if err = message.ApplyDeltaMessage(history.Lookup); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(message.Id(), target.Id()) || !reflect.DeepEqual(message.Operations, target.Operations) {
+		t.Fatal("incorrect restored operations")
+	}
+
+	history.Remove(base.Id())
+	if _, ok := history.Lookup(base.Id()); ok {
+		t.Fatal("removed message is found")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"fmt"
+)
+
+// FrameCheck is name of received frame check
+type FrameCheck string
+
+const (
+	// FrameCheckTag verifies self-describing frame prefix
+	FrameCheckTag FrameCheck = "tag"
+	// FrameCheckBase64 verifies text encoding, Actual is offset of illegal character
+	FrameCheckBase64 FrameCheck = "base64"
+	// FrameCheckEncoding verifies other text encodings, Actual is offset of illegal group
+	FrameCheckEncoding FrameCheck = "encoding"
+	// FrameCheckLength verifies that frame fits header, Expected is min frame length
+	FrameCheckLength FrameCheck = "length"
+	// FrameCheckHeader verifies encoding of compact header
+	FrameCheckHeader FrameCheck = "header"
+	// FrameCheckSize verifies chunk size, Expected is max chunk size
+	FrameCheckSize FrameCheck = "size"
+	// FrameCheckCount verifies chunks count of transmission
+	FrameCheckCount FrameCheck = "count"
+	// FrameCheckIndex verifies chunk index, Expected is chunks count
+	FrameCheckIndex FrameCheck = "index"
+	// FrameCheckChecksum verifies chunk checksum
+	FrameCheckChecksum FrameCheck = "checksum"
+	// FrameCheckMerkle verifies merkle proof of chunk
+	FrameCheckMerkle FrameCheck = "merkle"
+	// FrameCheckDecryption verifies authentication tag of encrypted frame
+	FrameCheckDecryption FrameCheck = "decryption"
+)
+
+// FrameError is diagnostic of received frame, which failed the check
+type FrameError struct {
+	// Index of chunk, -1 when frame header is not decoded
+	Index    int
+	Check    FrameCheck
+	Expected int
+	Actual   int
+
+	message string
+}
+
+func newFrameError(message string, check FrameCheck, index, expected, actual int) *FrameError {
+	return &FrameError{
+		Index:    index,
+		Check:    check,
+		Expected: expected,
+		Actual:   actual,
+		message:  message,
+	}
+}
+
+func (e *FrameError) Error() string {
+	return fmt.Sprintf("%s: frame %d, %s check, expected %d, actual %d", e.message, e.Index, e.Check, e.Expected, e.Actual)
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestFrameError(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeRaw()
+
+	receiver := NewChunks()
+	if _, err = receiver.AddRawChunk(frames[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	outOfRange := append([]byte{}, frames[1]...)
+	outOfRange[0] = 0xFF
+
+	oversized := append([]byte{}, frames[1]...)
+	oversized[5] = 0xFF
+
+	tests := []struct {
+		read     func() (bool, error)
+		expected FrameError
+	}{
+		{
+			read:     func() (bool, error) { return receiver.ReadB64Chunk("AAA*") },
+			expected: FrameError{Index: -1, Check: FrameCheckBase64, Actual: 3},
+		},
+		{
+			read:     func() (bool, error) { return receiver.ReadB64Chunk("AG9:AAAA") },
+			expected: FrameError{Index: -1, Check: FrameCheckTag},
+		},
+		{
+			read:     func() (bool, error) { return receiver.AddRawChunk([]byte{0x01}) },
+			expected: FrameError{Index: -1, Check: FrameCheckLength, Expected: chunkHeaderOffset, Actual: 1},
+		},
+		{
+			read:     func() (bool, error) { return receiver.AddRawChunk(outOfRange) },
+			expected: FrameError{Index: 0xFF, Check: FrameCheckIndex, Expected: int(sender.Count()), Actual: 0xFF},
+		},
+		{
+			read:     func() (bool, error) { return receiver.AddRawChunk(oversized) },
+			expected: FrameError{Index: 1, Check: FrameCheckSize, Expected: int(sender.size), Actual: int(sender.size)&0xFF | 0xFF00},
+		},
+	}
+
+	for _, test := range tests {
+		_, err = test.read()
+
+		var frameErr *FrameError
+		if !errors.As(err, &frameErr) {
+			t.Fatal("frame error is not returned", err)
+		}
+
+		if frameErr.Index != test.expected.Index || frameErr.Check != test.expected.Check ||
+			frameErr.Expected != test.expected.Expected || frameErr.Actual != test.expected.Actual {
+			t.Fatal("incorrect frame error", frameErr)
+		}
+	}
+}
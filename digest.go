@@ -0,0 +1,76 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// SetPayloadDigest enables SHA-256 digest of compressed payload, which is
+// transmitted after payload in the last chunk, so receiver verifies that
+// reassembly produced exactly what sender encoded
+func (ch *Chunks) SetPayloadDigest(enabled bool) *Chunks {
+	ch.opts.digest = enabled
+	return ch
+}
+
+// SetPayloadDigest enables SHA-256 digest of compressed payload, see Chunks.SetPayloadDigest
+func (a *AirGap) SetPayloadDigest(enabled bool) *AirGap {
+	a.chunksOpts.digest = enabled
+	return a
+}
+
+// appendPayloadDigest appends digest of compressed payload, when enabled
+func (o chunksOptions) appendPayloadDigest(compressed []byte) []byte {
+	if !o.digest {
+		return compressed
+	}
+	digest := sha256.Sum256(compressed)
+	return append(compressed, digest[:]...)
+}
+
+// trimPayloadDigest verifies and removes digest of compressed payload, when enabled
+func (o chunksOptions) trimPayloadDigest(data []byte) ([]byte, error) {
+	if !o.digest {
+		return data, nil
+	}
+
+	if len(data) < sha256.Size {
+		return nil, ErrCorrupted
+	}
+
+	compressed := data[:len(data)-sha256.Size]
+	digest := sha256.Sum256(compressed)
+	if !bytes.Equal(digest[:], data[len(compressed):]) {
+		return nil, ErrCorrupted
+	}
+	return compressed, nil
+}
+
+// verifyPayloadDigest checks digest of stored chunks, chunks lock must be held
+func (ch *Chunks) verifyPayloadDigest() error {
+	var data []byte
+	for index := 0; index < int(ch.count); index++ {
+		chunk, err := ch.storage.Get(index)
+		if err != nil {
+			return err
+		}
+		data = append(data, chunk...)
+	}
+
+	_, err := ch.opts.trimPayloadDigest(data)
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetPayloadDigest(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetPayloadDigest(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetPayloadDigest(true)
+	for _, frame := range sender.SerializeRaw() {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	var buf bytes.Buffer
+	if _, err = receiver.WriteDataTo(&buf, nil); err != nil || !bytes.Equal(buf.Bytes(), payload) {
+		t.Fatal("incorrect streamed payload", err)
+	}
+
+	// chunk is corrupted after receiving
+	chunk, _ := receiver.storage.Get(1)
+	chunk[0] ^= 0x01
+
+	if receiver.Data() != nil {
+		t.Fatal("corrupted payload is returned")
+	}
+
+	if _, err = receiver.WriteDataTo(&buf, nil); err == nil {
+		t.Fatal("corrupted payload is written")
+	}
+}
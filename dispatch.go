@@ -0,0 +1,205 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"sync"
+)
+
+// OperationHandler handles received operation
+type OperationHandler func(op *Operation) error
+
+// ProgressiveDispatcher passes operations of plain messages to handler as soon
+// as they are received in order, before the whole message is received. Every
+// growth of in-order received prefix decompresses the prefix again, so frames
+// are expected to arrive mostly in order.
+type ProgressiveDispatcher struct {
+	mu      sync.Mutex
+	airGap  *AirGap
+	chunks  *Chunks
+	handler OperationHandler
+
+	// prefix is count of in-order received chunks of size
+	prefix int
+	size   uint16
+	// offset is position of the next operation in serialized message
+	offset int
+}
+
+// NewProgressiveDispatcher initiates dispatcher for single transmission. Encrypted,
+// private, padded and signed messages are authenticated only as a whole, so they are
+// not supported. Payload digest is verified only before operations of the last chunks.
+func (a *AirGap) NewProgressiveDispatcher(handler OperationHandler) (*ProgressiveDispatcher, error) {
+	if a.ed != nil || a.routingKey != nil || a.paddingBuckets != nil || a.compressFirst || a.verifier != nil || a.isSequenced() || a.ttl > 0 {
+		return nil, errors.New("go-airgap progressive dispatch requires plain messages")
+	}
+
+	if a.format != MessageFormatBinary {
+		return nil, errors.New("go-airgap progressive dispatch requires binary message format")
+	}
+
+	return &ProgressiveDispatcher{
+		airGap:  a,
+		chunks:  &Chunks{opts: a.chunksOpts},
+		handler: handler,
+	}, nil
+}
+
+// Chunks returns receiver of the transmission
+func (d *ProgressiveDispatcher) Chunks() *Chunks {
+	return d.chunks
+}
+
+// ReadB64Chunk reads frame and dispatches completed operations
+func (d *ProgressiveDispatcher) ReadB64Chunk(frame string) (wasAdded bool, err error) {
+	wasAdded, err = d.chunks.ReadB64Chunk(frame)
+	if err != nil || !wasAdded {
+		return wasAdded, err
+	}
+	return wasAdded, d.dispatch()
+}
+
+// AddRawChunk reads binary frame and dispatches completed operations
+func (d *ProgressiveDispatcher) AddRawChunk(chunk []byte) (wasAdded bool, err error) {
+	wasAdded, err = d.chunks.AddRawChunk(chunk)
+	if err != nil || !wasAdded {
+		return wasAdded, err
+	}
+	return wasAdded, d.dispatch()
+}
+
+func (d *ProgressiveDispatcher) dispatch() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	compressed, isFilled, err := d.receivedPrefix()
+	if err != nil || compressed == nil {
+		return err
+	}
+
+	if isFilled && d.airGap.chunksOpts.digest {
+		d.chunks.mu.RLock()
+		err = d.chunks.verifyPayloadDigest()
+		d.chunks.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	if isFilled && d.airGap.chunksOpts.digest {
+		compressed = &trailerReader{r: compressed, size: sha256.Size}
+	}
+
+	zr, err := d.airGap.chunksOpts.newReader(compressed)
+	if err != nil {
+		if isFilled {
+			return err
+		}
+		// compression header is not received yet
+		return nil
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil && (isFilled || err != io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	if d.airGap.magic {
+		if len(data) <= len(MessageMagic) {
+			if isFilled {
+				return errors.New("go-airgap message to small")
+			}
+			// magic prefix is not received yet
+			return nil
+		}
+
+		if data, err = d.airGap.trimMagic(data); err != nil {
+			return err
+		}
+	}
+
+	if len(data) < airGapMessagesOffset {
+		if isFilled {
+			return errors.New("go-airgap message to small")
+		}
+		return nil
+	}
+
+	if d.offset == 0 {
+		if err = d.airGap.verifyMessageHeader(data[0], data[1:airGapMessagesOffset]); err != nil {
+			return err
+		}
+		d.offset = airGapMessagesOffset
+	}
+
+	for d.offset < len(data) {
+		opCode, size, headerSize, err := parseOperationHeader(data[d.offset:], d.airGap.chunksOpts.compact)
+		if err != nil || uint64(size) > uint64(len(data)-d.offset-headerSize) {
+			if isFilled {
+				return errors.New("go-airgap operation is truncated")
+			}
+			// operation is not received yet
+			return nil
+		}
+
+		start := d.offset + headerSize
+		d.offset = start + int(size)
+
+		if err = d.handler(&Operation{OpCode: opCode, Size: size, Data: data[start:d.offset]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// receivedPrefix returns in-order received compressed chunks, or nil when
+// prefix isn't grown
+func (d *ProgressiveDispatcher) receivedPrefix() (io.Reader, bool, error) {
+	d.chunks.mu.RLock()
+	defer d.chunks.mu.RUnlock()
+
+	if d.chunks.size != d.size {
+		// sender has changed chunk size, decompressed offset is still valid
+		d.prefix = 0
+		d.size = d.chunks.size
+	}
+
+	prefix := d.prefix
+	for prefix < int(d.chunks.count) && d.chunks.storage.Has(prefix) {
+		prefix++
+	}
+
+	if prefix == d.prefix {
+		return nil, false, nil
+	}
+	d.prefix = prefix
+
+	var compressed []byte
+	for index := 0; index < prefix; index++ {
+		chunk, err := d.chunks.storage.Get(index)
+		if err != nil {
+			return nil, false, err
+		}
+		compressed = append(compressed, chunk...)
+	}
+
+	return bytes.NewReader(compressed), prefix == int(d.chunks.count), nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestProgressiveDispatcher(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, magic := range []bool{false, true} {
+		airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+			SetMagic(magic)
+		testProgressiveDispatcher(t, airGap)
+	}
+}
+
+func testProgressiveDispatcher(t *testing.T, airGap *AirGap) {
+	message := airGap.CreateMessage()
+	for i := 0; i < 8; i++ {
+		payload := make([]byte, 500)
+		_, _ = rand.Read(payload)
+		message.AddOperation(uint16(i), payload)
+	}
+
+	frames, err := message.MarshalB64Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dispatched []*Operation
+	framesRead := 0
+	firstDispatchedAt := 0
+
+	dispatcher, err := airGap.NewProgressiveDispatcher(func(op *Operation) error {
+		if len(dispatched) == 0 {
+			firstDispatchedAt = framesRead
+		}
+		dispatched = append(dispatched, op)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, frame := range frames {
+		framesRead++
+		if _, err = dispatcher.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(dispatched) != len(message.Operations) {
+		t.Fatal("incorrect dispatched operations count", len(dispatched))
+	}
+
+	if firstDispatchedAt >= len(frames)/2 {
+		t.Fatal("operation is not dispatched progressively", firstDispatchedAt, len(frames))
+	}
+
+	for i := range dispatched {
+		if dispatched[i].OpCode != message.Operations[i].OpCode || !bytes.Equal(dispatched[i].Data, message.Operations[i].Data) {
+			t.Fatal("incorrect dispatched operation", i)
+		}
+	}
+}
+
+func TestProgressiveDispatcher_Encrypted(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetEncryptorDecryptor(NewDummyEncryptorDecryptor())
+
+	if _, err = airGap.NewProgressiveDispatcher(func(op *Operation) error { return nil }); err == nil {
+		t.Fatal("encrypted messages are accepted")
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+)
+
+const (
+	eciesKeyWrapInfo = "go-airgap ecies key wrap"
+)
+
+// ECIESRecipient wraps payload keys to the recipient EC public key
+// with ephemeral ECDH, HKDF-SHA256 and AES-256-GCM
+type ECIESRecipient struct {
+	pub *ecdsa.PublicKey
+}
+
+// ECIESIdentity unwraps payload keys wrapped with ECIESRecipient
+type ECIESIdentity struct {
+	key ECDHKey
+}
+
+// ECDHKey computes ECDH shared secret (x coordinate) with peer public key.
+// Implemented by hardware keys, e.g. *piv.ECDSAPrivateKey of piv-go, so
+// private key never leaves the token.
+type ECDHKey interface {
+	Public() crypto.PublicKey
+	SharedKey(peer *ecdsa.PublicKey) ([]byte, error)
+}
+
+func NewECIESRecipient(pub *ecdsa.PublicKey) *ECIESRecipient {
+	return &ECIESRecipient{pub: pub}
+}
+
+func NewECIESIdentity(priv *ecdsa.PrivateKey) *ECIESIdentity {
+	return &ECIESIdentity{key: ecdsaECDHKey{priv}}
+}
+
+// NewECIESEncryptorDecryptor initiates ECIES encryption to the paired instances,
+// peerInstanceIds are compressed public keys on curve of priv, e.g.
+// elliptic.P256, secp256k1 keys are supported by secp256k1crypt submodule.
+// Every message is encrypted under a fresh payload key, which is wrapped for
+// every peer, so the same frames can be consumed by any of them, see
+// HybridEncryptorDecryptor. Message header still contains instance id of
+// AirGap, so all peers must share it, e.g. id of wallet instead of device.
+func NewECIESEncryptorDecryptor(priv *ecdsa.PrivateKey, peerInstanceIds ...[]byte) (*HybridEncryptorDecryptor, error) {
+	if len(peerInstanceIds) == 0 || len(peerInstanceIds) > 255 {
+		return nil, errors.New("go-airgap ecies requires 1-255 peer instance ids")
+	}
+
+	recipients := make([]KeyWrapper, len(peerInstanceIds))
+	for i := range peerInstanceIds {
+		peer, err := PublicKeyFromInstanceId(priv.Curve, peerInstanceIds[i])
+		if err != nil {
+			return nil, err
+		}
+		recipients[i] = NewECIESRecipient(peer)
+	}
+	return NewHybridEncryptorDecryptor(NewECIESIdentity(priv), recipients...), nil
+}
+
+// NewECIESIdentityFromKey initiates ECIESIdentity with external ECDH key
+func NewECIESIdentityFromKey(key ECDHKey) *ECIESIdentity {
+	return &ECIESIdentity{key: key}
+}
+
+// WrapKey returns ephemeral_pub_key || AES-GCM(kek, payload_key)
+func (r *ECIESRecipient) WrapKey(payloadKey []byte) ([]byte, error) {
+	ephemeral, err := ecdsa.GenerateKey(r.pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := ecdsaECDHKey{ephemeral}.SharedKey(r.pub)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPub := elliptic.MarshalCompressed(r.pub.Curve, ephemeral.X, ephemeral.Y)
+
+	aead, err := keyWrapAEAD(eciesKeyWrapInfo, shared, ephemeralPub, elliptic.MarshalCompressed(r.pub.Curve, r.pub.X, r.pub.Y))
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(ephemeralPub, make([]byte, aead.NonceSize()), payloadKey, nil), nil
+}
+
+func (id *ECIESIdentity) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	pub, ok := id.key.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("ecies identity must have EC public key")
+	}
+
+	pubKeySize := (pub.Curve.Params().BitSize+7)/8 + 1
+
+	if len(wrappedKey) < pubKeySize {
+		return nil, errors.New("ecies wrapped key to small")
+	}
+
+	x, y := elliptic.UnmarshalCompressed(pub.Curve, wrappedKey[:pubKeySize])
+	if x == nil {
+		return nil, errors.New("ecies wrapped key has incorrect ephemeral key")
+	}
+
+	shared, err := id.key.SharedKey(&ecdsa.PublicKey{Curve: pub.Curve, X: x, Y: y})
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := keyWrapAEAD(eciesKeyWrapInfo, shared, wrappedKey[:pubKeySize], elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y))
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, make([]byte, aead.NonceSize()), wrappedKey[pubKeySize:], nil)
+}
+
+// keyWrapAEAD derives key encryption key from ECDH shared secret, bound to
+// both ephemeral and recipient public keys. Every KEK is unique, so zero nonce is used.
+func keyWrapAEAD(keyWrapInfo string, shared, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	info := append([]byte(keyWrapInfo), ephemeralPub...)
+	info = append(info, recipientPub...)
+
+	block, err := aes.NewCipher(hkdfSHA256(shared, nil, info, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// ecdsaECDHKey implements ECDHKey for software keys
+type ecdsaECDHKey struct {
+	priv *ecdsa.PrivateKey
+}
+
+func (k ecdsaECDHKey) Public() crypto.PublicKey {
+	return &k.priv.PublicKey
+}
+
+func (k ecdsaECDHKey) SharedKey(peer *ecdsa.PublicKey) ([]byte, error) {
+	if peer.Curve != k.priv.Curve {
+		return nil, errors.New("ecdh peer key has incorrect curve")
+	}
+
+	sharedX, _ := k.priv.Curve.ScalarMult(peer.X, peer.Y, k.priv.D.Bytes())
+
+	shared := make([]byte, (k.priv.Curve.Params().BitSize+7)/8)
+	sharedX.FillBytes(shared)
+
+	return shared, nil
+}
@@ -0,0 +1,479 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FrameEncoding is text encoding of frames serialized with Chunks.SerializeText
+type FrameEncoding uint8
+
+const (
+	// EncodingBase64 is standard base64, the densest encoding for QR byte mode
+	EncodingBase64 FrameEncoding = iota
+	// EncodingNumeric is digits only encoding for QR numeric mode, every 7 bytes
+	// are encoded with 17 digits. Frames with multibase prefix are multibase
+	// base10, i.e. the whole frame is encoded as single number.
+	EncodingNumeric
+	// EncodingBase32 is RFC 4648 base32 without padding, uppercase alphabet fits
+	// QR alphanumeric mode and case-insensitive manual entry
+	EncodingBase32
+	// EncodingBase45 is RFC 9285 base45, which is the densest encoding for QR
+	// alphanumeric mode, every 2 bytes are encoded with 3 characters
+	EncodingBase45
+)
+
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Multibase prefixes of frame encodings, see https://github.com/multiformats/multibase
+const (
+	multibaseBase64    = 'M'
+	multibaseBase64URL = 'u'
+	multibaseBase32    = 'B'
+	multibaseBase10    = '9'
+	multibaseBase45    = 'R'
+)
+
+const (
+	numericGroupSize   = 7
+	numericGroupDigits = 17
+)
+
+// base10DigitsPerKilobyte is upper bound of base10 digits of 1000 bytes
+const base10DigitsPerKilobyte = 2409
+
+// numericDigits contains count of digits for group of bytes
+var numericDigits = [numericGroupSize + 1]int{0, 3, 5, 8, 10, 13, 15, 17}
+
+// SetEncoding defines text encoding of SerializeText and ReadTextChunk frames
+func (ch *Chunks) SetEncoding(encoding FrameEncoding) *Chunks {
+	ch.opts.encoding = encoding
+	return ch
+}
+
+// SetEncoding defines text encoding of MarshalTextChunks frames, receiver must
+// use the same encoding
+func (a *AirGap) SetEncoding(encoding FrameEncoding) *AirGap {
+	a.chunksOpts.encoding = encoding
+	return a
+}
+
+// SetMultibase enables multibase prefix of SerializeText frames, which identifies
+// frame encoding. Receiver with enabled multibase detects encoding of every frame.
+func (ch *Chunks) SetMultibase(enabled bool) *Chunks {
+	ch.opts.multibase = enabled
+	return ch
+}
+
+// SetMultibase enables multibase prefix of MarshalTextChunks frames, see Chunks.SetMultibase
+func (a *AirGap) SetMultibase(enabled bool) *AirGap {
+	a.chunksOpts.multibase = enabled
+	return a
+}
+
+// SetBase64URL enables URL-safe base64 alphabet without padding for SerializeB64
+// frames, so '+', '/' and '=' are not mangled by scanners. Receiver accepts
+// both alphabets regardless of this option.
+func (ch *Chunks) SetBase64URL(enabled bool) *Chunks {
+	ch.opts.base64URL = enabled
+	return ch
+}
+
+// SetBase64URL enables URL-safe base64 frames, see Chunks.SetBase64URL
+func (a *AirGap) SetBase64URL(enabled bool) *AirGap {
+	a.chunksOpts.base64URL = enabled
+	return a
+}
+
+// SerializeText represents data frames to strings array with the defined encoding
+func (ch *Chunks) SerializeText() []string {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	var result []string
+	for _, chunk := range ch.framesWithDecoys() {
+		result = append(result, ch.encodeFrame(chunk))
+	}
+	return result
+}
+
+// ReadTextChunk reads frame serialized with SerializeText
+func (ch *Chunks) ReadTextChunk(frame string) (wasAdded bool, err error) {
+	if ch.opts.codec == nil && ch.opts.encoding == EncodingBase64 && !ch.opts.multibase {
+		return ch.ReadB64Chunk(frame)
+	}
+
+	frame, err = ch.opts.trimURIScheme(frame)
+	if err != nil {
+		ch.frameFailed(err)
+		return wasAdded, err
+	}
+
+	var chunk []byte
+	if ch.opts.codec != nil {
+		chunk, err = ch.opts.codec.Decode(frame)
+	} else {
+		chunk, err = ch.opts.decodeFrame(ch.opts.normalizeTextFrame(frame))
+	}
+
+	if err != nil {
+		ch.frameFailed(err)
+		return wasAdded, err
+	}
+
+	return ch.AddRawChunk(chunk)
+}
+
+// MarshalTextChunks marshals message to frames with encoding of AirGap
+func (m *Message) MarshalTextChunks() ([]string, error) {
+	result, err := m.chunks()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.SerializeText(), nil
+}
+
+func (ch *Chunks) encodeFrame(chunk []byte) string {
+	if ch.opts.codec == nil && !ch.opts.multibase && ch.opts.encoding == EncodingBase64 {
+		return ch.encodeB64(chunk)
+	}
+	return ch.opts.uriScheme + ch.encodeTextFrame(chunk)
+}
+
+func (ch *Chunks) encodeTextFrame(chunk []byte) string {
+	if ch.opts.codec != nil {
+		return ch.opts.codec.Encode(chunk)
+	}
+
+	if ch.opts.multibase {
+		switch ch.opts.encoding {
+		case EncodingNumeric:
+			return string(multibaseBase10) + encodeBase10(chunk)
+		case EncodingBase32:
+			return string(multibaseBase32) + base32Encoding.EncodeToString(chunk)
+		case EncodingBase45:
+			return string(multibaseBase45) + encodeBase45(chunk)
+		default:
+			if ch.opts.base64URL {
+				return string(multibaseBase64URL) + base64.RawURLEncoding.EncodeToString(chunk)
+			}
+			return string(multibaseBase64) + base64.StdEncoding.EncodeToString(chunk)
+		}
+	}
+
+	switch ch.opts.encoding {
+	case EncodingNumeric:
+		return encodeNumeric(chunk)
+	case EncodingBase32:
+		return base32Encoding.EncodeToString(chunk)
+	case EncodingBase45:
+		return encodeBase45(chunk)
+	default:
+		return base64.StdEncoding.EncodeToString(chunk)
+	}
+}
+
+func (o chunksOptions) decodeFrame(frame string) ([]byte, error) {
+	encoding := o.encoding
+
+	if o.multibase {
+		if frame == "" {
+			return nil, newFrameError("unsupported go-airgap frame tag", FrameCheckTag, -1, 0, 0)
+		}
+
+		switch frame[0] {
+		case multibaseBase10:
+			return decodeBase10(frame[1:])
+		case multibaseBase32, multibaseBase32 + 'a' - 'A':
+			encoding = EncodingBase32
+		case multibaseBase45:
+			encoding = EncodingBase45
+		case multibaseBase64, multibaseBase64 + 'a' - 'A', multibaseBase64URL, multibaseBase64URL + 'A' - 'a':
+			chunk, err := decodeB64(frame[1:])
+			if err != nil {
+				return nil, newFrameError("incorrect go-airgap message", FrameCheckBase64, -1, 0, 0)
+			}
+			return chunk, nil
+		default:
+			return nil, newFrameError("unsupported go-airgap frame tag", FrameCheckTag, -1, 0, 0)
+		}
+		frame = frame[1:]
+	}
+
+	switch encoding {
+	case EncodingNumeric:
+		return decodeNumeric(frame)
+	case EncodingBase32:
+		chunk, err := base32Encoding.DecodeString(strings.ToUpper(strings.TrimRight(frame, "=")))
+		if err != nil {
+			offset := 0
+			if corrupted, ok := err.(base32.CorruptInputError); ok {
+				offset = int(corrupted)
+			}
+			return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset)
+		}
+		return chunk, nil
+	case EncodingBase45:
+		return decodeBase45(frame)
+	default:
+		return nil, errors.New("unsupported go-airgap frame encoding")
+	}
+}
+
+// frameChunkSize returns max chunk size, which fits to frameSize characters
+func (o chunksOptions) frameChunkSize(frameSize int) int {
+	frameSize -= len(o.uriScheme)
+
+	if o.codec != nil {
+		return codecChunkSize(o.codec, frameSize)
+	}
+
+	if o.multibase {
+		frameSize--
+	}
+
+	switch o.encoding {
+	case EncodingNumeric:
+		if o.multibase {
+			// every byte takes log10(256) < 2.409 digits
+			return frameSize * 1000 / base10DigitsPerKilobyte
+		}
+
+		size := frameSize / numericGroupDigits * numericGroupSize
+		for n := numericGroupSize - 1; n > 0; n-- {
+			if numericDigits[n] <= frameSize%numericGroupDigits {
+				return size + n
+			}
+		}
+		return size
+	case EncodingBase32:
+		return frameSize * 5 / 8
+	case EncodingBase45:
+		size := frameSize / 3 * 2
+		if frameSize%3 == 2 {
+			size++
+		}
+		return size
+	default:
+		if o.tagged && !o.multibase && o.uriScheme == "" {
+			frameSize -= len(FrameTag)
+		}
+		return ChunkSizeForFrame(frameSize)
+	}
+}
+
+// encodeBase10 encodes data as big-endian number, every leading zero byte is
+// encoded with '0' digit as in multibase base10
+func encodeBase10(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	result := strings.Repeat("0", zeros)
+	if zeros < len(data) {
+		result += new(big.Int).SetBytes(data[zeros:]).String()
+	}
+	return result
+}
+
+func decodeBase10(frame string) ([]byte, error) {
+	for offset := 0; offset < len(frame); offset++ {
+		if frame[offset] < '0' || frame[offset] > '9' {
+			return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset)
+		}
+	}
+
+	zeros := 0
+	for zeros < len(frame) && frame[zeros] == '0' {
+		zeros++
+	}
+
+	result := make([]byte, zeros)
+	if zeros < len(frame) {
+		value, _ := new(big.Int).SetString(frame[zeros:], 10)
+		result = append(result, value.Bytes()...)
+	}
+	return result, nil
+}
+
+// encodeNumeric encodes every 7 bytes group as big-endian number of 17 digits,
+// the last shorter group uses the min count of digits
+func encodeNumeric(data []byte) string {
+	var sb strings.Builder
+	sb.Grow((len(data) + numericGroupSize - 1) / numericGroupSize * numericGroupDigits)
+
+	for len(data) > 0 {
+		n := numericGroupSize
+		if len(data) < n {
+			n = len(data)
+		}
+
+		var value uint64
+		for _, b := range data[:n] {
+			value = value<<8 | uint64(b)
+		}
+
+		digits := strconv.FormatUint(value, 10)
+		sb.WriteString(strings.Repeat("0", numericDigits[n]-len(digits)))
+		sb.WriteString(digits)
+
+		data = data[n:]
+	}
+
+	return sb.String()
+}
+
+func decodeNumeric(frame string) ([]byte, error) {
+	result := make([]byte, 0, len(frame)/numericGroupDigits*numericGroupSize+numericGroupSize)
+
+	for offset := 0; offset < len(frame); {
+		digits := len(frame) - offset
+		if digits > numericGroupDigits {
+			digits = numericGroupDigits
+		}
+
+		n := 0
+		for size, groupDigits := range numericDigits {
+			if groupDigits == digits {
+				n = size
+			}
+		}
+
+		if n == 0 {
+			return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset)
+		}
+
+		value, err := strconv.ParseUint(frame[offset:offset+digits], 10, 64)
+		if err != nil || value>>(8*n) != 0 {
+			return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset)
+		}
+
+		for i := n - 1; i >= 0; i-- {
+			result = append(result, byte(value>>(8*i)))
+		}
+
+		offset += digits
+	}
+
+	return result, nil
+}
+
+// normalizeTextFrame removes whitespace like normalizeFrame, base45 frames keep
+// spaces, which belong to alphabet
+func (o chunksOptions) normalizeTextFrame(frame string) string {
+	isBase45 := o.encoding == EncodingBase45 && !o.multibase
+	if o.multibase {
+		trimmed := strings.TrimLeftFunc(frame, unicode.IsSpace)
+		isBase45 = trimmed != "" && trimmed[0] == multibaseBase45
+		if isBase45 {
+			frame = trimmed
+		}
+	}
+
+	if isBase45 {
+		return strings.NewReplacer("\r", "", "\n", "").Replace(frame)
+	}
+	return normalizeFrame(frame)
+}
+
+// normalizeFrame removes whitespace and line breaks, which are added by
+// scanners and copy-paste transports
+func normalizeFrame(frame string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, frame)
+}
+
+// decodeB64 decodes both standard and URL-safe base64 alphabets with missing
+// or extra padding
+func decodeB64(frame string) ([]byte, error) {
+	frame = strings.TrimRight(frame, "=")
+	frame = strings.NewReplacer("-", "+", "_", "/").Replace(frame)
+	return base64.RawStdEncoding.DecodeString(frame)
+}
+
+// base45Alphabet is RFC 9285 alphabet, which fits QR alphanumeric mode
+const base45Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// encodeBase45 encodes every 2 bytes with 3 characters, the last byte is
+// encoded with 2 characters
+func encodeBase45(data []byte) string {
+	var sb strings.Builder
+	sb.Grow((len(data) + 1) / 2 * 3)
+
+	for ; len(data) >= 2; data = data[2:] {
+		value := int(data[0])<<8 | int(data[1])
+		sb.WriteByte(base45Alphabet[value%45])
+		sb.WriteByte(base45Alphabet[value/45%45])
+		sb.WriteByte(base45Alphabet[value/45/45])
+	}
+
+	if len(data) == 1 {
+		sb.WriteByte(base45Alphabet[int(data[0])%45])
+		sb.WriteByte(base45Alphabet[int(data[0])/45])
+	}
+
+	return sb.String()
+}
+
+func decodeBase45(frame string) ([]byte, error) {
+	if len(frame)%3 == 1 {
+		return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, len(frame)-1)
+	}
+
+	result := make([]byte, 0, len(frame)/3*2+1)
+	for offset := 0; offset < len(frame); offset += 3 {
+		digits := 3
+		if len(frame)-offset < 3 {
+			digits = 2
+		}
+
+		value := 0
+		for i := digits - 1; i >= 0; i-- {
+			digit := strings.IndexByte(base45Alphabet, frame[offset+i])
+			if digit < 0 {
+				return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset+i)
+			}
+			value = value*45 + digit
+		}
+
+		if digits == 3 {
+			if value > 0xFFFF {
+				return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset)
+			}
+			result = append(result, byte(value>>8), byte(value))
+		} else {
+			if value > 0xFF {
+				return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, offset)
+			}
+			result = append(result, byte(value))
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,336 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestChunks_ReadB64ChunkTolerant(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetFrameTag(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mangle := []func(frame string) string{
+		func(frame string) string { return "  " + frame + "\r\n" },
+		func(frame string) string { return frame[:20] + "\n" + frame[20:40] + "\t" + frame[40:] },
+		func(frame string) string { return strings.TrimRight(frame, "=") },
+		func(frame string) string { return frame + "==" },
+		func(frame string) string {
+			data, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(frame, FrameTag))
+			return FrameTag + base64.RawURLEncoding.EncodeToString(data)
+		},
+	}
+
+	receiver := NewChunks()
+	for i, frame := range sender.SerializeB64() {
+		if _, err = receiver.ReadB64Chunk(mangle[i%len(mangle)](frame)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
+
+func TestChunks_SetBase64URL(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	for _, multibase := range []bool{false, true} {
+		sender, err := NewChunks().SetFrameTag(true).SetBase64URL(true).SetMultibase(multibase).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewChunks().SetMultibase(multibase)
+		for _, frame := range sender.SerializeText() {
+			if strings.ContainsAny(frame, "+/=") {
+				t.Fatal("frame contains URL-unsafe characters", frame)
+			}
+
+			if _, err = receiver.ReadTextChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+}
+
+func TestEncodeNumeric(t *testing.T) {
+	for size := 0; size < 40; size++ {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		if size > 0 {
+			data[0] = 0xFF
+		}
+
+		encoded := encodeNumeric(data)
+		if strings.Trim(encoded, "0123456789") != "" {
+			t.Fatal("encoded frame contains non-digits", encoded)
+		}
+
+		decoded, err := decodeNumeric(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(decoded, data) {
+			t.Fatal("incorrect decoded data", size)
+		}
+	}
+
+	for _, frame := range []string{"1", "1234", "999", "12a45", "99999999999999999"} {
+		if _, err := decodeNumeric(frame); err == nil {
+			t.Fatal("incorrect numeric frame is accepted", frame)
+		}
+	}
+}
+
+func TestAirGap_SetEncodingNumeric(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetEncoding(EncodingNumeric)
+	airGap.SetFrameSize(300)
+
+	payload := make([]byte, 1000)
+	_, _ = rand.Read(payload)
+
+	frames, err := airGap.CreateMessage().AddOperation(opCodeTest1, payload).MarshalTextChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetEncoding(EncodingNumeric)
+	for _, frame := range frames {
+		if len(frame) > 300 || strings.Trim(frame, "0123456789") != "" {
+			t.Fatal("incorrect numeric frame", frame)
+		}
+
+		if _, err = receiver.ReadTextChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	message, err := airGap.Unmarshal(receiver.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(message.Operations[0].Data, payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
+
+func TestChunks_SetEncodingBase32(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetEncoding(EncodingBase32).SetData(payload, (&chunksOptions{encoding: EncodingBase32}).frameChunkSize(250))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetEncoding(EncodingBase32)
+	for i, frame := range sender.SerializeText() {
+		if len(frame) > 250 || strings.Trim(frame, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567") != "" {
+			t.Fatal("incorrect base32 frame", frame)
+		}
+
+		// manual entry is case-insensitive
+		if i%2 == 1 {
+			frame = strings.ToLower(frame)
+		}
+
+		if _, err = receiver.ReadTextChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	if _, err = receiver.ReadTextChunk("AAAA1"); err == nil {
+		t.Fatal("incorrect base32 frame is accepted")
+	}
+}
+
+func TestChunks_SetMultibase(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	receiver := NewChunks().SetMultibase(true)
+
+	var frames []string
+	for _, encoding := range []FrameEncoding{EncodingBase64, EncodingNumeric, EncodingBase32} {
+		sender, err := NewChunks().SetEncoding(encoding).SetMultibase(true).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, sender.SerializeText()...)
+	}
+
+	prefixes := map[byte]bool{}
+	// every frame is read with encoding of its prefix
+	for i := 0; i < len(frames); i += 3 {
+		prefixes[frames[i][0]] = true
+		if _, err := receiver.ReadTextChunk(frames[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < len(frames) && !receiver.IsFilled(); i++ {
+		if _, err := receiver.ReadTextChunk(frames[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(prefixes) != 3 {
+		t.Fatal("incorrect multibase prefixes", prefixes)
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	if _, err := receiver.ReadTextChunk("zABCD"); err == nil {
+		t.Fatal("unsupported multibase prefix is accepted")
+	}
+}
+
+func TestChunks_SetMultibaseBase10(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	opts := chunksOptions{encoding: EncodingNumeric, multibase: true}
+	sender, err := NewChunks().SetEncoding(EncodingNumeric).SetMultibase(true).SetData(payload, opts.frameChunkSize(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetMultibase(true)
+	chunks := sender.framesWithDecoys()
+	for i, frame := range sender.SerializeText() {
+		if len(frame) > 200 {
+			t.Fatal("incorrect frame size", len(frame))
+		}
+
+		// frame is whole chunk as single number, leading zero bytes are '0'
+		chunk := chunks[i]
+		zeros := 0
+		for zeros < len(chunk) && chunk[zeros] == 0 {
+			zeros++
+		}
+		expected := "9" + strings.Repeat("0", zeros)
+		if zeros < len(chunk) {
+			expected += new(big.Int).SetBytes(chunk[zeros:]).String()
+		}
+		if frame != expected {
+			t.Fatal("incorrect base10 frame", i)
+		}
+
+		if _, err = receiver.ReadTextChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	decoded, err := decodeBase10(encodeBase10([]byte{0, 0, 1, 0}))
+	if err != nil || !bytes.Equal(decoded, []byte{0, 0, 1, 0}) {
+		t.Fatal("incorrect base10 leading zeros", decoded, err)
+	}
+
+	if _, err = receiver.ReadTextChunk("912a4"); err == nil {
+		t.Fatal("incorrect base10 frame is accepted")
+	}
+}
+
+func TestEncodeBase45(t *testing.T) {
+	// RFC 9285 examples
+	vectors := map[string]string{
+		"AB":      "BB8",
+		"Hello!!": "%69 VD92EX0",
+		"base-45": "UJCLQE7W581",
+		"ietf!":   "QED8WEX0",
+	}
+
+	for data, encoded := range vectors {
+		if result := encodeBase45([]byte(data)); result != encoded {
+			t.Fatal("incorrect base45 encoding", data, result)
+		}
+
+		decoded, err := decodeBase45(encoded)
+		if err != nil || string(decoded) != data {
+			t.Fatal("incorrect base45 decoding", encoded, err)
+		}
+	}
+
+	for _, frame := range []string{"GGW", "ZZZZ", "a0"} {
+		if _, err := decodeBase45(frame); err == nil {
+			t.Fatal("incorrect base45 frame is accepted", frame)
+		}
+	}
+}
+
+func TestChunks_SetEncodingBase45(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	for _, multibase := range []bool{false, true} {
+		opts := &chunksOptions{encoding: EncodingBase45, multibase: multibase}
+		sender, err := NewChunks().SetEncoding(EncodingBase45).SetMultibase(multibase).SetData(payload, opts.frameChunkSize(250))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewChunks().SetEncoding(EncodingBase45).SetMultibase(multibase)
+		for _, frame := range sender.SerializeText() {
+			if len(frame) > 250 {
+				t.Fatal("incorrect base45 frame", frame)
+			}
+
+			if _, err = receiver.ReadTextChunk(frame + "\r\n"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	timestampsSize = 8 + 8 // created_at(8) + expires_at(8)
+)
+
+var (
+	// ErrMessageExpired is returned by Unmarshal for expired messages
+	ErrMessageExpired = errors.New("go-airgap message is expired")
+	// ErrMessageNotYetValid is returned by Unmarshal for messages created in future
+	ErrMessageNotYetValid = errors.New("go-airgap message is created in future")
+)
+
+// SetMessageTTL enables created-at and expires-at timestamps of messages,
+// which are prepended to message body before encryption. Marshaled messages
+// expire after ttl, unless Message.ExpiresAt is defined. Receiver must enable
+// timestamps too, with any positive ttl.
+func (a *AirGap) SetMessageTTL(ttl time.Duration) *AirGap {
+	a.ttl = ttl
+	return a
+}
+
+// SetClockSkew defines tolerated difference of sender and receiver clocks
+func (a *AirGap) SetClockSkew(skew time.Duration) *AirGap {
+	a.clockSkew = skew
+	return a
+}
+
+// appendTimestamps assigns message timestamps once and prepends them to body
+func (m *Message) appendTimestamps(body []byte) []byte {
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().Truncate(time.Second)
+	}
+
+	if m.ExpiresAt.IsZero() {
+		m.ExpiresAt = m.CreatedAt.Add(m.ttl)
+	}
+
+	result := make([]byte, timestampsSize, timestampsSize+len(body))
+	binary.BigEndian.PutUint64(result, uint64(m.CreatedAt.Unix()))
+	binary.BigEndian.PutUint64(result[8:], uint64(m.ExpiresAt.Unix()))
+	return append(result, body...)
+}
+
+// trimTimestamps removes message timestamps and checks them with clock skew
+func (a *AirGap) trimTimestamps(data []byte) (createdAt, expiresAt time.Time, body []byte, err error) {
+	if len(data) < timestampsSize {
+		return time.Time{}, time.Time{}, nil, errors.New("go-airgap message to small")
+	}
+
+	createdAt = time.Unix(int64(binary.BigEndian.Uint64(data)), 0)
+	expiresAt = time.Unix(int64(binary.BigEndian.Uint64(data[8:])), 0)
+
+	now := time.Now()
+
+	if createdAt.After(now.Add(a.clockSkew)) {
+		return time.Time{}, time.Time{}, nil, ErrMessageNotYetValid
+	}
+
+	if now.Add(-a.clockSkew).After(expiresAt) {
+		return time.Time{}, time.Time{}, nil, ErrMessageExpired
+	}
+
+	return createdAt, expiresAt, data[timestampsSize:], nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func TestAirGap_SetMessageTTL(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	sender := NewAirGap(VersionDefault, instanceId).SetMessageTTL(time.Hour)
+	receiver := NewAirGap(VersionDefault, instanceId).SetMessageTTL(time.Hour).SetClockSkew(time.Minute)
+
+	message := sender.CreateMessage().AddOperation(opCodeTest1, []byte("sign transaction"))
+
+	data, err := message.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.ExpiresAt.Sub(message.CreatedAt) != time.Hour {
+		t.Fatal("incorrect message expiry", message.CreatedAt, message.ExpiresAt)
+	}
+
+	received, err := receiver.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !received.CreatedAt.Equal(message.CreatedAt) || !received.ExpiresAt.Equal(message.ExpiresAt) {
+		t.Fatal("incorrect received timestamps")
+	}
+
+	now := time.Now()
+
+	cases := []struct {
+		createdAt time.Time
+		expiresAt time.Time
+		err       error
+	}{
+		{now.Add(-48 * time.Hour), now.Add(-24 * time.Hour), ErrMessageExpired},
+		{now.Add(-2 * time.Hour), now.Add(-30 * time.Second), nil},
+		{now.Add(30 * time.Second), now.Add(time.Hour), nil},
+		{now.Add(time.Hour), now.Add(2 * time.Hour), ErrMessageNotYetValid},
+	}
+
+	for _, c := range cases {
+		message = sender.CreateMessage().AddOperation(opCodeTest1, []byte("sign transaction"))
+		message.CreatedAt, message.ExpiresAt = c.createdAt, c.expiresAt
+
+		data, err = message.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = receiver.Unmarshal(data); err != c.err {
+			t.Fatal("incorrect expiry check", c.createdAt, c.expiresAt, err)
+		}
+	}
+}
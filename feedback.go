@@ -0,0 +1,108 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	// OpCodeScanFeedback is standard operation with scan quality metrics,
+	// which receiver sends back to the sender
+	OpCodeScanFeedback uint16 = 0xFF01
+
+	scanFeedbackSize = 20 // frames(4) + duplicates(4) + decode_failures(4) + duration_ms(4) + loop_duration_ms(4)
+)
+
+// ScanFeedback contains scan quality metrics observed by receiver, so sender
+// can tune frame rate and chunk size, see AdaptiveChunkSize
+type ScanFeedback struct {
+	// Frames is count of successfully decoded frames
+	Frames uint32
+	// Duplicates is count of already received frames
+	Duplicates uint32
+	// DecodeFailures is count of frames, which cannot be decoded
+	DecodeFailures uint32
+	// Duration is measurement window
+	Duration time.Duration
+	// LoopDuration is time per animation loop
+	LoopDuration time.Duration
+}
+
+// DuplicateRate returns share of duplicates in decoded frames
+func (f *ScanFeedback) DuplicateRate() float64 {
+	if f.Frames == 0 {
+		return 0
+	}
+	return float64(f.Duplicates) / float64(f.Frames)
+}
+
+// DecodeFailuresPerSecond returns decode failures rate
+func (f *ScanFeedback) DecodeFailuresPerSecond() float64 {
+	if f.Duration <= 0 {
+		return 0
+	}
+	return float64(f.DecodeFailures) / f.Duration.Seconds()
+}
+
+// FrameErrorRate returns share of frames, which cannot be decoded
+func (f *ScanFeedback) FrameErrorRate() float64 {
+	total := f.Frames + f.DecodeFailures
+	if total == 0 {
+		return 0
+	}
+	return float64(f.DecodeFailures) / float64(total)
+}
+
+func (f *ScanFeedback) Marshal() []byte {
+	result := make([]byte, scanFeedbackSize)
+	binary.BigEndian.PutUint32(result[0:], f.Frames)
+	binary.BigEndian.PutUint32(result[4:], f.Duplicates)
+	binary.BigEndian.PutUint32(result[8:], f.DecodeFailures)
+	binary.BigEndian.PutUint32(result[12:], uint32(f.Duration.Milliseconds()))
+	binary.BigEndian.PutUint32(result[16:], uint32(f.LoopDuration.Milliseconds()))
+	return result
+}
+
+func UnmarshalScanFeedback(data []byte) (*ScanFeedback, error) {
+	if len(data) != scanFeedbackSize {
+		return nil, errors.New("go-airgap scan feedback has incorrect size")
+	}
+
+	return &ScanFeedback{
+		Frames:         binary.BigEndian.Uint32(data[0:]),
+		Duplicates:     binary.BigEndian.Uint32(data[4:]),
+		DecodeFailures: binary.BigEndian.Uint32(data[8:]),
+		Duration:       time.Duration(binary.BigEndian.Uint32(data[12:])) * time.Millisecond,
+		LoopDuration:   time.Duration(binary.BigEndian.Uint32(data[16:])) * time.Millisecond,
+	}, nil
+}
+
+// AddScanFeedback adds OpCodeScanFeedback operation
+func (m *Message) AddScanFeedback(feedback *ScanFeedback) *Message {
+	return m.AddOperation(OpCodeScanFeedback, feedback.Marshal())
+}
+
+// ScanFeedback returns the first OpCodeScanFeedback operation of message
+func (m *Message) ScanFeedback() (*ScanFeedback, error) {
+	for i := range m.Operations {
+		if m.Operations[i].OpCode == OpCodeScanFeedback {
+			return UnmarshalScanFeedback(m.Operations[i].Data)
+		}
+	}
+	return nil, errors.New("go-airgap message has no scan feedback")
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFeedback_ScanFeedback(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	feedback := &ScanFeedback{
+		Frames:         60,
+		Duplicates:     15,
+		DecodeFailures: 40,
+		Duration:       10 * time.Second,
+		LoopDuration:   2500 * time.Millisecond,
+	}
+
+	serialized, err := airGap.CreateMessage().AddScanFeedback(feedback).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := airGap.Unmarshal(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readedFeedback, err := message.ScanFeedback()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(feedback, readedFeedback) {
+		t.Fatal("mismatch scan feedback")
+	}
+
+	if readedFeedback.DuplicateRate() != 0.25 || readedFeedback.DecodeFailuresPerSecond() != 4 || readedFeedback.FrameErrorRate() != 0.4 {
+		t.Fatal("incorrect scan feedback metrics")
+	}
+
+	if AdaptiveChunkSize(defaultChunkSize, readedFeedback.FrameErrorRate()) >= defaultChunkSize {
+		t.Fatal("chunk size is not dropped")
+	}
+}
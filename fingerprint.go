@@ -0,0 +1,118 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// FingerprintHrp is default human-readable part of bech32 fingerprints
+	FingerprintHrp = "airgap"
+
+	fingerprintHexSize = 8
+	fingerprintWords   = 3
+	fingerprintEmoji   = 7
+)
+
+//go:embed wordlist_english.txt
+var bip39English string
+
+// bip39Words is BIP-39 english wordlist
+var bip39Words = strings.Fields(bip39English)
+
+// Emoji is fingerprint symbol with its name for reading aloud
+type Emoji struct {
+	Symbol string
+	Name   string
+}
+
+// fingerprintEmojiTable contains 64 distinct emoji, which are easy to name
+var fingerprintEmojiTable = [64]Emoji{
+	{"🐶", "Dog"}, {"🐱", "Cat"}, {"🦁", "Lion"}, {"🐎", "Horse"},
+	{"🦄", "Unicorn"}, {"🐷", "Pig"}, {"🐘", "Elephant"}, {"🐰", "Rabbit"},
+	{"🐼", "Panda"}, {"🐓", "Rooster"}, {"🐧", "Penguin"}, {"🐢", "Turtle"},
+	{"🐟", "Fish"}, {"🐙", "Octopus"}, {"🦋", "Butterfly"}, {"🌷", "Flower"},
+	{"🌳", "Tree"}, {"🌵", "Cactus"}, {"🍄", "Mushroom"}, {"🌏", "Globe"},
+	{"🌙", "Moon"}, {"☁️", "Cloud"}, {"🔥", "Fire"}, {"🍌", "Banana"},
+	{"🍎", "Apple"}, {"🍓", "Strawberry"}, {"🌽", "Corn"}, {"🍕", "Pizza"},
+	{"🎂", "Cake"}, {"❤️", "Heart"}, {"😀", "Smiley"}, {"🤖", "Robot"},
+	{"🎩", "Hat"}, {"👓", "Glasses"}, {"🔧", "Spanner"}, {"🎅", "Santa"},
+	{"👍", "Thumbs Up"}, {"☂️", "Umbrella"}, {"⌛", "Hourglass"}, {"⏰", "Clock"},
+	{"🎁", "Gift"}, {"💡", "Light Bulb"}, {"📕", "Book"}, {"✏️", "Pencil"},
+	{"📎", "Paperclip"}, {"✂️", "Scissors"}, {"🔒", "Lock"}, {"🔑", "Key"},
+	{"🔨", "Hammer"}, {"☎️", "Telephone"}, {"🏁", "Flag"}, {"🚂", "Train"},
+	{"🚲", "Bicycle"}, {"✈️", "Aeroplane"}, {"🚀", "Rocket"}, {"🏆", "Trophy"},
+	{"⚽", "Ball"}, {"🎸", "Guitar"}, {"🎺", "Trumpet"}, {"🔔", "Bell"},
+	{"⚓", "Anchor"}, {"🎧", "Headphones"}, {"📁", "Folder"}, {"📌", "Pin"},
+}
+
+// fingerprintDigest returns SHA-256 of instance id
+func fingerprintDigest(instanceId []byte) []byte {
+	digest := sha256.Sum256(instanceId)
+	return digest[:]
+}
+
+// FingerprintHex renders instance id digest as uppercase hex groups,
+// e.g. "1A2B 3C4D 5E6F 7A8B"
+func FingerprintHex(instanceId []byte) string {
+	encoded := strings.ToUpper(hex.EncodeToString(fingerprintDigest(instanceId)[:fingerprintHexSize]))
+
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+	return strings.Join(groups, " ")
+}
+
+// FingerprintBech32 renders full instance id as bech32 string with
+// human-readable part hrp, e.g. FingerprintHrp
+func FingerprintBech32(hrp string, instanceId []byte) (string, error) {
+	return bech32Encode(hrp, instanceId, bech32Const)
+}
+
+// FingerprintWords renders instance id digest as BIP-39 words triplet (33 bits)
+func FingerprintWords(instanceId []byte) []string {
+	var result []string
+	for _, index := range bitsToIndexes(fingerprintDigest(instanceId), 11, fingerprintWords) {
+		result = append(result, bip39Words[index])
+	}
+	return result
+}
+
+// FingerprintEmoji renders instance id digest as sequence of 7 emoji (42 bits)
+func FingerprintEmoji(instanceId []byte) []Emoji {
+	var result []Emoji
+	for _, index := range bitsToIndexes(fingerprintDigest(instanceId), 6, fingerprintEmoji) {
+		result = append(result, fingerprintEmojiTable[index])
+	}
+	return result
+}
+
+// bitsToIndexes splits leading count*bits bits of data to indexes
+func bitsToIndexes(data []byte, bits, count int) []int {
+	result := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		index := 0
+		for bit := i * bits; bit < (i+1)*bits; bit++ {
+			index = index<<1 | int(data[bit/8]>>(7-bit%8)&1)
+		}
+		result = append(result, index)
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint_bech32Encode(t *testing.T) {
+	// BIP-173 and BIP-350 test vectors
+	encoded, err := bech32Encode("a", nil, bech32Const)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encoded != "a12uel5l" {
+		t.Fatalf("mismatch bech32 %s", encoded)
+	}
+
+	encoded, err = bech32Encode("a", nil, bech32mConst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encoded != "a1lqfn3a" {
+		t.Fatalf("mismatch bech32m %s", encoded)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if len(bip39Words) != 2048 || bip39Words[0] != "abandon" || bip39Words[2047] != "zoo" {
+		t.Fatal("incorrect BIP-39 wordlist")
+	}
+
+	instanceId := append([]byte{0x02}, bytes.Repeat([]byte{0x01}, 32)...)
+	otherId := append([]byte{0x03}, bytes.Repeat([]byte{0x01}, 32)...)
+
+	if hexFingerprint := FingerprintHex(instanceId); len(hexFingerprint) != 19 || hexFingerprint == FingerprintHex(otherId) {
+		t.Fatalf("incorrect hex fingerprint %s", hexFingerprint)
+	}
+
+	bech32Fingerprint, err := FingerprintBech32(FingerprintHrp, instanceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(bech32Fingerprint, FingerprintHrp+"1") {
+		t.Fatalf("incorrect bech32 fingerprint %s", bech32Fingerprint)
+	}
+
+	words := FingerprintWords(instanceId)
+	if len(words) != 3 || reflect.DeepEqual(words, FingerprintWords(otherId)) {
+		t.Fatalf("incorrect words fingerprint %v", words)
+	}
+
+	emoji := FingerprintEmoji(instanceId)
+	if len(emoji) != 7 || reflect.DeepEqual(emoji, FingerprintEmoji(otherId)) {
+		t.Fatalf("incorrect emoji fingerprint %v", emoji)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+const (
+	fountainHeaderSize = 8 // seq(4) + chunks_count(2) + last_chunk_size(2)
+)
+
+// fountainDecoder keeps received fountain frames, which are not decoded yet
+type fountainDecoder struct {
+	seen     map[uint32]bool
+	pending  []*fountainEquation
+	lastSize uint16
+}
+
+// fountainEquation is XOR of chunks with indexes
+type fountainEquation struct {
+	indexes []int
+	data    []byte
+}
+
+// SetFountain enables fountain coding. Sender emits endless sequence of frames
+// with FountainFrame, every frame is XOR of pseudo-random chunks subset, so
+// receiver reconstructs payload from any sufficiently large subset of frames.
+// The first Count frames contain single chunks. Receiver must enable fountain
+// coding too.
+func (ch *Chunks) SetFountain(enabled bool) *Chunks {
+	ch.opts.fountain = enabled
+	return ch
+}
+
+// SetFountain enables fountain coding, see Chunks.SetFountain
+func (a *AirGap) SetFountain(enabled bool) *AirGap {
+	a.chunksOpts.fountain = enabled
+	return a
+}
+
+// FountainFrame returns fountain frame with sequence number seq
+func (ch *Chunks) FountainFrame(seq uint32) []byte {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return ch.fountainFrame(seq)
+}
+
+func (ch *Chunks) fountainFrame(seq uint32) []byte {
+	frame := append(ch.fountainHeader(seq), make([]byte, ch.size)...)
+
+	for _, index := range fountainIndexes(seq, int(ch.count)) {
+		chunk, _ := ch.storage.Get(index)
+		for i := range chunk {
+			frame[fountainHeaderSize+i] ^= chunk[i]
+		}
+	}
+
+	return ch.sealFrame(seq, frame)
+}
+
+// fountainHeader returns header of fountain frame with sequence number seq
+func (ch *Chunks) fountainHeader(seq uint32) []byte {
+	header := make([]byte, fountainHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], seq)
+	binary.LittleEndian.PutUint16(header[4:], uint16(ch.count))
+
+	if ch.count > 0 {
+		last, _ := ch.storage.Get(int(ch.count) - 1)
+		binary.LittleEndian.PutUint16(header[6:], uint16(len(last)))
+	}
+	return header
+}
+
+// FountainFrameB64 returns fountain frame with sequence number seq, ready for QR code
+func (ch *Chunks) FountainFrameB64(seq uint32) string {
+	return ch.encodeB64(ch.FountainFrame(seq))
+}
+
+// fountainIndexes returns indexes of chunks mixed to frame seq
+func fountainIndexes(seq uint32, count int) []int {
+	if count == 0 {
+		return nil
+	}
+
+	if int64(seq) < int64(count) {
+		return []int{int(seq)}
+	}
+
+	rng := rand.New(rand.NewSource(int64(seq)<<16 | int64(count)))
+	return rng.Perm(count)[:fountainDegree(rng, count)]
+}
+
+// fountainDegree samples ideal soliton distribution
+func fountainDegree(rng *rand.Rand, count int) int {
+	u := rng.Float64()
+	for degree := 1; degree < count; degree++ {
+		if u < 1/float64(count)+1-1/float64(degree) {
+			return degree
+		}
+	}
+	return count
+}
+
+// addFountainFrame decodes fountain frame with peeling decoder
+func (ch *Chunks) addFountainFrame(chunk []byte) (wasAdded bool, err error) {
+	seq := binary.LittleEndian.Uint32(chunk[0:])
+	count := uint32(binary.LittleEndian.Uint16(chunk[4:]))
+	lastSize := binary.LittleEndian.Uint16(chunk[6:])
+	capacity := uint16(len(chunk) - fountainHeaderSize)
+
+	if err = ch.opts.verifyPayloadCapacity(count, capacity); err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		return false, newFrameError("go-airgap chunk has incorrect count",
+			FrameCheckCount, -1, int(ch.count), int(count))
+	}
+
+	// decoder state is initialized by valid frame only
+	if lastSize > capacity {
+		return false, newFrameError("go-airgap chunk has incorrect size",
+			FrameCheckSize, int(count)-1, int(capacity), int(lastSize))
+	}
+
+	if ch.count == 0 {
+		if ch.storage == nil {
+			ch.storage = &memoryStorage{}
+		}
+
+		if err = ch.storage.Reset(int(count), int(capacity)); err != nil {
+			return false, err
+		}
+
+		ch.count = count
+		ch.size = capacity
+		ch.fountain = &fountainDecoder{seen: make(map[uint32]bool), lastSize: lastSize}
+	} else if count != ch.count || capacity != ch.size {
+		return false, newFrameError("go-airgap chunk has incorrect count",
+			FrameCheckCount, -1, int(ch.count), int(count))
+	} else if lastSize != ch.fountain.lastSize {
+		return false, newFrameError("go-airgap chunk has incorrect size",
+			FrameCheckSize, int(count)-1, int(ch.fountain.lastSize), int(lastSize))
+	}
+
+	if ch.fountain.seen[seq] {
+		ch.stats.Duplicates++
+		return false, nil
+	}
+	ch.fountain.seen[seq] = true
+
+	equation := &fountainEquation{
+		indexes: fountainIndexes(seq, int(count)),
+		data:    append([]byte{}, chunk[fountainHeaderSize:]...),
+	}
+
+	if err = ch.reduceFountainEquation(equation); err != nil || len(equation.indexes) == 0 {
+		return false, err
+	}
+
+	ch.fountain.pending = append(ch.fountain.pending, equation)
+
+	for {
+		solved := -1
+		for i, pending := range ch.fountain.pending {
+			if len(pending.indexes) == 1 {
+				solved = i
+				break
+			}
+		}
+
+		if solved < 0 {
+			return wasAdded, nil
+		}
+
+		equation = ch.fountain.pending[solved]
+		ch.fountain.pending = append(ch.fountain.pending[:solved], ch.fountain.pending[solved+1:]...)
+
+		index := equation.indexes[0]
+		size := int(ch.size)
+		if index == int(ch.count)-1 {
+			size = int(ch.fountain.lastSize)
+		}
+
+		if err = ch.storage.Put(index, equation.data[:size]); err != nil {
+			return wasAdded, err
+		}
+
+		ch.filled++
+		ch.received += size
+		ch.recordIngest(size)
+		wasAdded = true
+
+		remaining := ch.fountain.pending[:0]
+		for _, pending := range ch.fountain.pending {
+			if err = ch.reduceFountainEquation(pending); err != nil {
+				return wasAdded, err
+			}
+			if len(pending.indexes) > 0 {
+				remaining = append(remaining, pending)
+			}
+		}
+		ch.fountain.pending = remaining
+	}
+}
+
+// reduceFountainEquation removes already decoded chunks from equation
+func (ch *Chunks) reduceFountainEquation(equation *fountainEquation) error {
+	indexes := equation.indexes[:0]
+	for _, index := range equation.indexes {
+		if !ch.storage.Has(index) {
+			indexes = append(indexes, index)
+			continue
+		}
+
+		chunk, err := ch.storage.Get(index)
+		if err != nil {
+			return err
+		}
+
+		for i := range chunk {
+			equation.data[i] ^= chunk[i]
+		}
+	}
+	equation.indexes = indexes
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetFountain(t *testing.T) {
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetFountain(true).SetFrameKey([]byte("key")).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := uint32(sender.Count())
+
+	// the whole first pass is lost, receiver decodes mixed frames only
+	receiver := NewChunks().SetFountain(true).SetFrameKey([]byte("key"))
+	for seq := count; !receiver.IsFilled() || receiver.Count() == 0; seq++ {
+		if seq > count*20 {
+			t.Fatal("payload is not decoded", receiver.Filled(), receiver.Count())
+		}
+
+		if _, err = receiver.ReadB64Chunk(sender.FountainFrameB64(seq)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect reconstructed payload")
+	}
+}
+
+func TestChunks_SetFountainSystematic(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetFountain(true).SetData(payload, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := uint32(sender.Count())
+
+	receiver := NewChunks().SetFountain(true)
+	for seq := uint32(0); seq < count; seq++ {
+		wasAdded, err := receiver.ReadRawChunk(sender.FountainFrame(seq))
+		if err != nil || !wasAdded {
+			t.Fatal("chunk is not added", seq, err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect reconstructed payload")
+	}
+
+	// duplicates are ignored
+	if wasAdded, err := receiver.ReadRawChunk(sender.FountainFrame(0)); err != nil || wasAdded {
+		t.Fatal("duplicate is added")
+	}
+}
+
+func TestChunks_SetFountainCorruptedFirstFrame(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetFountain(true).SetData(payload, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := uint32(sender.Count())
+	receiver := NewChunks().SetFountain(true)
+
+	// last chunk size exceeds chunk capacity
+	corrupted := sender.FountainFrame(0)
+	corrupted[6], corrupted[7] = 0xFF, 0xFF
+	if _, err = receiver.ReadRawChunk(corrupted); err == nil {
+		t.Fatal("corrupted frame is accepted")
+	}
+
+	for seq := uint32(0); seq < count; seq++ {
+		if _, err = receiver.ReadRawChunk(sender.FountainFrame(seq)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect reconstructed payload")
+	}
+
+	// last chunk size differs from the first frame
+	receiver = NewChunks().SetFountain(true)
+	if _, err = receiver.ReadRawChunk(sender.FountainFrame(count)); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupted = sender.FountainFrame(count + 1)
+	corrupted[6]--
+	if _, err = receiver.ReadRawChunk(corrupted); err == nil {
+		t.Fatal("frame with another last chunk size is accepted")
+	}
+}
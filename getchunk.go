@@ -0,0 +1,49 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+)
+
+// GetChunk returns frame of chunk with index serialized like SerializeB64, so UI
+// can re-display only frames, which receiver reports missing. Indexes after
+// chunks count address parity frames.
+func (ch *Chunks) GetChunk(index uint32) (string, error) {
+	frame, err := ch.GetRawChunk(index)
+	if err != nil {
+		return "", err
+	}
+	return ch.encodeB64(frame), nil
+}
+
+// GetRawChunk returns frame of chunk with index serialized like SerializeRaw
+func (ch *Chunks) GetRawChunk(index uint32) ([]byte, error) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if index < ch.count {
+		if !ch.storage.Has(int(index)) {
+			return nil, errors.New("go-airgap chunk is not stored")
+		}
+		return ch.getChunkWithHeader(index), nil
+	}
+
+	if int64(index) < int64(ch.count)+int64(ch.opts.parityGroups(ch.count)) {
+		return ch.parityFrameWithHeader(int(index - ch.count)), nil
+	}
+
+	return nil, errors.New("go-airgap chunk index out of range")
+}
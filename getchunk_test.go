@@ -0,0 +1,59 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_GetChunk(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetParity(4).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeB64()
+	for index := range frames {
+		frame, err := sender.GetChunk(uint32(index))
+		if err != nil || frame != frames[index] {
+			t.Fatal("incorrect frame", index, err)
+		}
+	}
+
+	if _, err = sender.GetChunk(uint32(len(frames))); err == nil {
+		t.Fatal("frame out of range is returned")
+	}
+
+	// missing chunk is re-displayed
+	receiver := NewChunks()
+	for _, frame := range frames[1:sender.Count()] {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	frame, err := sender.GetChunk(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = receiver.ReadB64Chunk(frame); err != nil || !receiver.Complete() {
+		t.Fatal("missing chunk is not received", err)
+	}
+}
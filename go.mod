@@ -1,3 +1,5 @@
 module github.com/censync/go-airgap
 
 go 1.18
+
+require golang.org/x/crypto v0.7.0
@@ -0,0 +1,295 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// OpCodePairingRequest is standard operation with commitment to ephemeral
+	// public key of pairing initiator
+	OpCodePairingRequest uint16 = 0xFF06
+	// OpCodePairingResponse is standard operation with ephemeral public key of
+	// pairing responder
+	OpCodePairingResponse uint16 = 0xFF07
+	// OpCodePairingReveal is standard operation with ephemeral public key of
+	// pairing initiator, which is checked against commitment of request
+	OpCodePairingReveal uint16 = 0xFF0B
+
+	pairingSessionInfo = "go-airgap pairing session"
+	pairingSASInfo     = "go-airgap pairing sas"
+	pairingCommitInfo  = "go-airgap pairing commit"
+	pairingKeySize     = 32
+	pairingSASSize     = 8
+
+	// PairingSASEmojiCount is count of emoji of short authentication string,
+	// 6 bits each
+	PairingSASEmojiCount = 7
+)
+
+// PairingState is state of pairing handshake
+type PairingState uint8
+
+const (
+	PairingStateIdle PairingState = iota
+	// PairingStateRequested means initiator displayed request and waits for response
+	PairingStateRequested
+	// PairingStateCompleted means session EncryptorDecryptor is installed
+	PairingStateCompleted
+	// PairingStateRekeying means initiator displayed rekey request and waits for response
+	PairingStateRekeying
+	// PairingStateResponded means responder displayed response and waits for reveal
+	PairingStateResponded
+)
+
+// Pairing implements ECDH pairing handshake with commitment. Initiator displays
+// Request with hash of its ephemeral key, responder scans it and displays
+// Respond result, initiator scans it with Complete and displays Reveal,
+// responder scans it with Confirm. Both sides derive the same session key from
+// ephemeral ECDH and install SessionEncryptorDecryptor into AirGap.
+//
+// Handshake messages aren't authenticated, so users should compare SAS or
+// SASEmoji of both sides against man-in-the-middle. Initiator key is committed
+// before responder key is shown, so attacker can't search keys with matching
+// SAS and succeeds with probability 10^-6 for SAS and 2^-42 for SASEmoji per
+// pairing attempt.
+type Pairing struct {
+	mu     sync.Mutex
+	airGap *AirGap
+	priv   *ecdsa.PrivateKey
+	state  PairingState
+
+	// initiatorPub and responderPub are compressed ephemeral public keys
+	initiatorPub []byte
+	responderPub []byte
+	// commitment is hash of initiator ephemeral key, see Request
+	commitment []byte
+	initiator  bool
+	sessionKey []byte
+	session    *SessionEncryptorDecryptor
+	// rekeyPriv is ephemeral key of requested rekey
+	rekeyPriv *ecdsa.PrivateKey
+	// sas is short authentication string material
+	sas []byte
+}
+
+// NewPairing initiates pairing handshake of AirGap with ephemeral key on curve,
+// e.g. elliptic.P256, both sides must use the same curve
+func (a *AirGap) NewPairing(curve elliptic.Curve) (*Pairing, error) {
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pairing{airGap: a, priv: priv}, nil
+}
+
+// State returns current state of handshake
+func (p *Pairing) State() PairingState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.state
+}
+
+// Request returns pairing request message of initiator with commitment to
+// its ephemeral key
+func (p *Pairing) Request() (*Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateIdle {
+		return nil, errors.New("go-airgap pairing is already started")
+	}
+
+	p.initiator = true
+	p.initiatorPub = p.publicKey()
+	p.commitment = pairingCommitment(p.initiatorPub)
+	p.state = PairingStateRequested
+
+	return p.airGap.CreateMessage().AddOperation(OpCodePairingRequest, p.commitment), nil
+}
+
+// Respond handles pairing request and returns response message of responder,
+// session is established by Confirm
+func (p *Pairing) Respond(request *Message) (*Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateIdle {
+		return nil, errors.New("go-airgap pairing is already started")
+	}
+
+	if len(request.Operations) != 1 || request.Operations[0].OpCode != OpCodePairingRequest ||
+		len(request.Operations[0].Data) != sha256.Size {
+		return nil, errors.New("go-airgap pairing message has incorrect operation")
+	}
+
+	p.commitment = append([]byte{}, request.Operations[0].Data...)
+	p.responderPub = p.publicKey()
+	p.state = PairingStateResponded
+
+	return p.airGap.CreateMessage().AddOperation(OpCodePairingResponse, p.responderPub), nil
+}
+
+// Reveal returns reveal message of completed initiator with its ephemeral key,
+// message isn't encrypted with session key
+func (p *Pairing) Reveal() (*Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.initiator || p.state != PairingStateCompleted {
+		return nil, errors.New("go-airgap pairing is not completed")
+	}
+
+	reveal := p.airGap.CreateMessage().AddOperation(OpCodePairingReveal, p.initiatorPub)
+	reveal.e = nil
+	return reveal, nil
+}
+
+// Confirm handles reveal message, checks it against commitment of request and
+// installs session EncryptorDecryptor
+func (p *Pairing) Confirm(reveal *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateResponded {
+		return errors.New("go-airgap pairing is not responded")
+	}
+
+	peer, err := p.peerKey(reveal, OpCodePairingReveal)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(pairingCommitment(peer), p.commitment) != 1 {
+		return errors.New("go-airgap pairing reveal doesn't match commitment")
+	}
+
+	p.initiatorPub = peer
+	return p.complete(peer)
+}
+
+// Complete handles pairing response and installs session EncryptorDecryptor,
+// responder completes pairing with Reveal result
+func (p *Pairing) Complete(response *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateRequested {
+		return errors.New("go-airgap pairing is not requested")
+	}
+
+	peer, err := p.peerKey(response, OpCodePairingResponse)
+	if err != nil {
+		return err
+	}
+
+	p.responderPub = peer
+	return p.complete(peer)
+}
+
+// SAS returns 6 digits short authentication string, which is the same on both
+// sides of completed pairing, unless handshake messages were substituted
+func (p *Pairing) SAS() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sas == nil {
+		return "", errors.New("go-airgap pairing is not completed")
+	}
+
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(p.sas)%1000000), nil
+}
+
+// SASEmoji returns short authentication string of PairingSASEmojiCount emoji
+// of FingerprintEmoji table, see SAS
+func (p *Pairing) SASEmoji() ([]Emoji, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sas == nil {
+		return nil, errors.New("go-airgap pairing is not completed")
+	}
+
+	var result []Emoji
+	for _, index := range bitsToIndexes(p.sas, 6, PairingSASEmojiCount) {
+		result = append(result, fingerprintEmojiTable[index])
+	}
+	return result, nil
+}
+
+// pairingCommitment returns hash of initiator ephemeral key
+func pairingCommitment(initiatorPub []byte) []byte {
+	digest := sha256.Sum256(append([]byte(pairingCommitInfo), initiatorPub...))
+	return digest[:]
+}
+
+func (p *Pairing) publicKey() []byte {
+	return elliptic.MarshalCompressed(p.priv.Curve, p.priv.X, p.priv.Y)
+}
+
+// peerKey returns ephemeral public key of the single opCode operation
+func (p *Pairing) peerKey(message *Message, opCode uint16) ([]byte, error) {
+	if len(message.Operations) != 1 || message.Operations[0].OpCode != opCode {
+		return nil, errors.New("go-airgap pairing message has incorrect operation")
+	}
+
+	if _, err := PublicKeyFromInstanceId(p.priv.Curve, message.Operations[0].Data); err != nil {
+		return nil, errors.New("go-airgap pairing message has incorrect public key")
+	}
+
+	return append([]byte{}, message.Operations[0].Data...), nil
+}
+
+// complete derives session key bound to both ephemeral keys and installs
+// session EncryptorDecryptor
+func (p *Pairing) complete(peer []byte) error {
+	peerKey, err := PublicKeyFromInstanceId(p.priv.Curve, peer)
+	if err != nil {
+		return err
+	}
+
+	shared, err := ecdsaECDHKey{p.priv}.SharedKey(peerKey)
+	if err != nil {
+		return err
+	}
+
+	info := append([]byte(pairingSessionInfo), p.initiatorPub...)
+	info = append(info, p.responderPub...)
+	p.sessionKey = hkdfSHA256(shared, nil, info, pairingKeySize)
+
+	sasInfo := append([]byte(pairingSASInfo), p.initiatorPub...)
+	sasInfo = append(sasInfo, p.responderPub...)
+	p.sas = hkdfSHA256(shared, nil, sasInfo, pairingSASSize)
+
+	p.session, err = NewSessionEncryptorDecryptor(p.sessionKey)
+	if err != nil {
+		return err
+	}
+
+	p.airGap.SetEncryptorDecryptor(p.session)
+	p.state = PairingStateCompleted
+	return nil
+}
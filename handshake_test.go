@@ -0,0 +1,316 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPairing(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	// transfer marshals message and unmarshals it on the other side
+	transfer := func(message *Message, receiver *AirGap) *Message {
+		data, err := message.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := receiver.Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	for _, curve := range []elliptic.Curve{elliptic.P256()} {
+		initiatorAirGap := NewAirGap(VersionDefault, instanceId)
+		responderAirGap := NewAirGap(VersionDefault, instanceId)
+
+		initiator, err := initiatorAirGap.NewPairing(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		responder, err := responderAirGap.NewPairing(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request, err := initiator.Request()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if initiator.State() != PairingStateRequested {
+			t.Fatal("incorrect initiator state")
+		}
+
+		if _, err = initiator.Request(); err == nil {
+			t.Fatal("pairing is requested twice")
+		}
+
+		if bytes.Contains(request.Operations[0].Data, initiator.publicKey()) {
+			t.Fatal("initiator key is revealed by request")
+		}
+
+		response, err := responder.Respond(transfer(request, responderAirGap))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if responder.State() != PairingStateResponded || responderAirGap.EncryptorDecryptor() != nil {
+			t.Fatal("responder session is installed before reveal")
+		}
+
+		if _, err = initiator.Reveal(); err == nil {
+			t.Fatal("initiator key is revealed before response")
+		}
+
+		if err = initiator.Complete(transfer(response, initiatorAirGap)); err != nil {
+			t.Fatal(err)
+		}
+
+		reveal, err := initiator.Reveal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = responder.Reveal(); err == nil {
+			t.Fatal("responder reveals key")
+		}
+
+		if err = responder.Confirm(transfer(reveal, responderAirGap)); err != nil {
+			t.Fatal(err)
+		}
+
+		if responder.State() != PairingStateCompleted || responderAirGap.EncryptorDecryptor() == nil {
+			t.Fatal("responder session is not installed")
+		}
+
+		if initiator.State() != PairingStateCompleted || !bytes.Equal(initiator.sessionKey, responder.sessionKey) {
+			t.Fatal("incorrect initiator session")
+		}
+
+		data := []byte(`{"key": "secret message"}`)
+
+		message := transfer(initiatorAirGap.CreateMessage().AddOperation(opCodeTest1, data), responderAirGap)
+		if !bytes.Equal(message.Operations[0].Data, data) {
+			t.Fatal("incorrect received payload")
+		}
+
+		encrypted, err := responderAirGap.CreateMessage().AddOperation(opCodeTest1, data).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Contains(encrypted, data) {
+			t.Fatal("session message is not encrypted")
+		}
+
+		if _, err = NewAirGap(VersionDefault, instanceId).Unmarshal(encrypted); err == nil {
+			t.Fatal("session message is accepted without session key")
+		}
+	}
+}
+
+func TestPairing_IncorrectMessage(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y))
+
+	pairing, err := airGap.NewPairing(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pairing.Complete(airGap.CreateMessage()); err == nil {
+		t.Fatal("pairing is completed without request")
+	}
+
+	if err = pairing.Confirm(airGap.CreateMessage()); err == nil {
+		t.Fatal("pairing is confirmed without response")
+	}
+
+	for _, message := range []*Message{
+		airGap.CreateMessage(),
+		airGap.CreateMessage().AddOperation(OpCodePairingResponse, pairing.publicKey()),
+		airGap.CreateMessage().AddOperation(OpCodePairingRequest, pairing.publicKey()),
+	} {
+		if _, err = pairing.Respond(message); err == nil {
+			t.Fatal("incorrect pairing request is accepted")
+		}
+	}
+
+	if pairing.State() != PairingStateIdle || airGap.EncryptorDecryptor() != nil {
+		t.Fatal("incorrect pairing state")
+	}
+
+	// revealed key must match commitment
+	initiator, err := airGap.NewPairing(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := initiator.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = pairing.Respond(request); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := airGap.NewPairing(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = pairing.Confirm(airGap.CreateMessage().AddOperation(OpCodePairingReveal, other.publicKey())); err == nil {
+		t.Fatal("reveal of another key is accepted")
+	}
+
+	if pairing.State() != PairingStateResponded || airGap.EncryptorDecryptor() != nil {
+		t.Fatal("incorrect pairing state")
+	}
+}
+
+func TestPairing_SAS(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	// handshake returns completed initiator and responder, mitm substitutes
+	// request, response and reveal with own keys
+	handshake := func(mitm bool) (*Pairing, *Pairing) {
+		var pairings [4]*Pairing
+		for i := range pairings {
+			pairings[i], err = NewAirGap(VersionDefault, instanceId).NewPairing(elliptic.P256())
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		initiator, responder, attackerInitiator, attackerResponder := pairings[0], pairings[1], pairings[2], pairings[3]
+
+		request, err := initiator.Request()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var attackerResponse *Message
+		if mitm {
+			if attackerResponse, err = attackerResponder.Respond(request); err != nil {
+				t.Fatal(err)
+			}
+			if request, err = attackerInitiator.Request(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err = initiator.SAS(); err == nil {
+			t.Fatal("SAS of incomplete pairing")
+		}
+
+		response, err := responder.Respond(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mitm {
+			if err = attackerInitiator.Complete(response); err != nil {
+				t.Fatal(err)
+			}
+			response = attackerResponse
+		}
+
+		if err = initiator.Complete(response); err != nil {
+			t.Fatal(err)
+		}
+
+		reveal, err := initiator.Reveal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mitm {
+			if err = attackerResponder.Confirm(reveal); err != nil {
+				t.Fatal(err)
+			}
+			if reveal, err = attackerInitiator.Reveal(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err = responder.Confirm(reveal); err != nil {
+			t.Fatal(err)
+		}
+		return initiator, responder
+	}
+
+	initiator, responder := handshake(false)
+
+	initiatorSAS, err := initiator.SAS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responderSAS, err := responder.SAS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(initiatorSAS) != 6 || initiatorSAS != responderSAS {
+		t.Fatal("mismatch SAS", initiatorSAS, responderSAS)
+	}
+
+	initiatorEmoji, err := initiator.SASEmoji()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responderEmoji, err := responder.SASEmoji()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(initiatorEmoji) != PairingSASEmojiCount || !reflect.DeepEqual(initiatorEmoji, responderEmoji) {
+		t.Fatal("mismatch SAS emoji", initiatorEmoji, responderEmoji)
+	}
+
+	initiator, responder = handshake(true)
+
+	initiatorEmoji, _ = initiator.SASEmoji()
+	responderEmoji, _ = responder.SASEmoji()
+
+	if reflect.DeepEqual(initiatorEmoji, responderEmoji) {
+		t.Fatal("SAS emoji match under man-in-the-middle")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+const (
+	payloadKeySize = 32
+)
+
+// KeyWrapper wraps payload key to the recipient, e.g. with KEM or ECIES
+type KeyWrapper interface {
+	WrapKey(payloadKey []byte) ([]byte, error)
+}
+
+// KeyUnwrapper recovers payload key wrapped by KeyWrapper
+type KeyUnwrapper interface {
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+// HybridEncryptorDecryptor encrypts each message under a fresh random payload
+// key with AES-256-GCM and wraps the payload key to every recipient.
+//
+// Serialized format:
+// wrapped_keys_count(1) + [wrapped_key_size(2) + wrapped_key] * count + nonce(12) + ciphertext
+type HybridEncryptorDecryptor struct {
+	recipients []KeyWrapper
+	identity   KeyUnwrapper
+}
+
+// NewHybridEncryptorDecryptor initiates hybrid encryption for recipients, identity
+// is used for decryption and may be nil for encrypt-only instances
+func NewHybridEncryptorDecryptor(identity KeyUnwrapper, recipients ...KeyWrapper) *HybridEncryptorDecryptor {
+	return &HybridEncryptorDecryptor{
+		recipients: recipients,
+		identity:   identity,
+	}
+}
+
+func (ed *HybridEncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	return ed.EncryptAD(data, nil)
+}
+
+func (ed *HybridEncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	return ed.DecryptAD(data, nil)
+}
+
+// EncryptAD encrypts payload with associated data, wrapped keys aren't bound to it
+func (ed *HybridEncryptorDecryptor) EncryptAD(data, additionalData []byte) ([]byte, error) {
+	if len(ed.recipients) == 0 || len(ed.recipients) > 255 {
+		return nil, errors.New("hybrid encryption requires 1-255 recipients")
+	}
+
+	payloadKey := make([]byte, payloadKeySize)
+	if _, err := io.ReadFull(rand.Reader, payloadKey); err != nil {
+		return nil, err
+	}
+
+	result := []byte{byte(len(ed.recipients))}
+	for i := range ed.recipients {
+		wrappedKey, err := ed.recipients[i].WrapKey(payloadKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(wrappedKey) > 1<<16-1 {
+			return nil, errors.New("hybrid wrapped key to large")
+		}
+
+		result = append(result, byte(len(wrappedKey)>>8), byte(len(wrappedKey)))
+		result = append(result, wrappedKey...)
+	}
+
+	aead, err := newPayloadAEAD(payloadKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	result = append(result, nonce...)
+	return aead.Seal(result, nonce, data, additionalData), nil
+}
+
+func (ed *HybridEncryptorDecryptor) DecryptAD(data, additionalData []byte) ([]byte, error) {
+	if ed.identity == nil {
+		return nil, errors.New("hybrid identity is not defined")
+	}
+
+	if len(data) < 1 {
+		return nil, errors.New("hybrid ciphertext to small")
+	}
+
+	var payloadKey []byte
+
+	count := int(data[0])
+	offset := 1
+	for i := 0; i < count; i++ {
+		if len(data) < offset+2 {
+			return nil, errors.New("hybrid ciphertext to small")
+		}
+
+		size := int(data[offset])<<8 | int(data[offset+1])
+		offset += 2
+
+		if len(data) < offset+size {
+			return nil, errors.New("hybrid ciphertext to small")
+		}
+
+		if payloadKey == nil {
+			// wrapped keys of other recipients are expected to fail
+			payloadKey, _ = ed.identity.UnwrapKey(data[offset : offset+size])
+		}
+		offset += size
+	}
+
+	if payloadKey == nil {
+		return nil, errors.New("hybrid ciphertext is not addressed to identity")
+	}
+
+	aead, err := newPayloadAEAD(payloadKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < offset+aead.NonceSize() {
+		return nil, errors.New("hybrid ciphertext to small")
+	}
+
+	return aead.Open(nil, data[offset:offset+aead.NonceSize()], data[offset+aead.NonceSize():], additionalData)
+}
+
+func newPayloadAEAD(payloadKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(payloadKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
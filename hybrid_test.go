@@ -0,0 +1,201 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestHybridEncryptorDecryptor(t *testing.T) {
+	var identities []*ecdsa.PrivateKey
+	var recipients []KeyWrapper
+
+	for i := 0; i < 3; i++ {
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal("cannot generate private key")
+		}
+		identities = append(identities, privKey)
+		recipients = append(recipients, NewECIESRecipient(&privKey.PublicKey))
+	}
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := NewHybridEncryptorDecryptor(nil, recipients...).Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range identities {
+		decrypted, err := NewHybridEncryptorDecryptor(NewECIESIdentity(identities[i])).Decrypt(encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(decrypted, data) {
+			t.Fatal("mismatch decrypted data")
+		}
+	}
+
+	stranger, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	if _, err = NewHybridEncryptorDecryptor(NewECIESIdentity(stranger)).Decrypt(encrypted); err == nil {
+		t.Fatal("ciphertext decrypted by stranger")
+	}
+}
+
+func TestNewECIESEncryptorDecryptor(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256()} {
+		senderKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal("cannot generate private key")
+		}
+
+		receiverKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal("cannot generate private key")
+		}
+
+		senderId := elliptic.MarshalCompressed(curve, senderKey.X, senderKey.Y)
+		receiverId := elliptic.MarshalCompressed(curve, receiverKey.X, receiverKey.Y)
+
+		senderED, err := NewECIESEncryptorDecryptor(senderKey, receiverId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiverED, err := NewECIESEncryptorDecryptor(receiverKey, senderId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sender := NewAirGap(VersionDefault, receiverId).SetEncryptorDecryptor(senderED)
+		receiver := NewAirGap(VersionDefault, receiverId).SetEncryptorDecryptor(receiverED)
+
+		data, err := sender.CreateMessage().AddOperation(opCodeTest1, []byte("secret message")).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message, err := receiver.Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, []byte("secret message")) {
+			t.Fatal("mismatch decrypted data", curve.Params().Name)
+		}
+
+		if _, err = senderED.Decrypt(data); err == nil {
+			t.Fatal("message decrypted by sender", curve.Params().Name)
+		}
+	}
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	if _, err = NewECIESEncryptorDecryptor(privKey, make([]byte, compressedPubKeySize)); err == nil {
+		t.Fatal("incorrect instance id is accepted")
+	}
+}
+
+func TestNewECIESEncryptorDecryptor_MultipleRecipients(t *testing.T) {
+	senderKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	signerKeys := make([]*ecdsa.PrivateKey, 3)
+	signerIds := make([][]byte, len(signerKeys))
+	for i := range signerKeys {
+		signerKeys[i], err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal("cannot generate private key")
+		}
+		signerIds[i] = elliptic.MarshalCompressed(elliptic.P256(), signerKeys[i].X, signerKeys[i].Y)
+	}
+
+	senderED, err := NewECIESEncryptorDecryptor(senderKey, signerIds...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// signers share instance id of the wallet
+	walletId := signerIds[0]
+
+	frames, err := NewAirGap(VersionDefault, walletId).SetEncryptorDecryptor(senderED).
+		CreateMessage().AddOperation(opCodeTest1, []byte("signing request")).MarshalB64Chunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	senderId := elliptic.MarshalCompressed(elliptic.P256(), senderKey.X, senderKey.Y)
+	for i := range signerKeys {
+		signerED, err := NewECIESEncryptorDecryptor(signerKeys[i], senderId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewChunks()
+		for _, frame := range frames {
+			if _, err = receiver.ReadB64Chunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		message, err := NewAirGap(VersionDefault, walletId).SetEncryptorDecryptor(signerED).Unmarshal(receiver.Data())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, []byte("signing request")) {
+			t.Fatal("mismatch decrypted data", i)
+		}
+	}
+
+	stranger, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	strangerED, err := NewECIESEncryptorDecryptor(stranger, senderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks()
+	for _, frame := range frames {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = NewAirGap(VersionDefault, walletId).SetEncryptorDecryptor(strangerED).Unmarshal(receiver.Data()); err == nil {
+		t.Fatal("message decrypted by stranger")
+	}
+
+	if _, err = NewECIESEncryptorDecryptor(senderKey); err == nil {
+		t.Fatal("encryptor without peers is accepted")
+	}
+}
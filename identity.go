@@ -0,0 +1,90 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+)
+
+const (
+	// InstanceTypeEd25519 is type byte of instance id with ed25519 public key
+	InstanceTypeEd25519 = 0xED
+	// InstanceTypeX25519 is type byte of instance id with X25519 public key
+	InstanceTypeX25519 = 0x25
+)
+
+// compressedSerializer is implemented by secp256k1 public keys of
+// btcec and dcrd/dcrec/secp256k1 packages
+type compressedSerializer interface {
+	SerializeCompressed() []byte
+}
+
+// InstanceIdFromPublicKey derives instance id from *ecdsa.PublicKey with
+// 256 bits curve, ed25519.PublicKey, X25519PublicKey or secp256k1 public key of
+// btcec package. EC keys are compressed, 32 bytes keys are prefixed with
+// InstanceTypeEd25519 or InstanceTypeX25519.
+func InstanceIdFromPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	var instanceId []byte
+
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		instanceId = elliptic.MarshalCompressed(key.Curve, key.X, key.Y)
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, errors.New("incorrect ed25519 public key size")
+		}
+		instanceId = append([]byte{InstanceTypeEd25519}, key...)
+	case X25519PublicKey:
+		if len(key) != X25519KeySize {
+			return nil, errors.New("incorrect x25519 public key size")
+		}
+		instanceId = append([]byte{InstanceTypeX25519}, key...)
+	case compressedSerializer:
+		instanceId = key.SerializeCompressed()
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+
+	if len(instanceId) != compressedPubKeySize {
+		return nil, errors.New("incorrect instance pub key size")
+	}
+
+	return instanceId, nil
+}
+
+// PublicKeyFromInstanceId parses compressed EC public key of instance id on
+// curve, e.g. elliptic.P256
+func PublicKeyFromInstanceId(curve elliptic.Curve, instanceId []byte) (*ecdsa.PublicKey, error) {
+	x, y := elliptic.UnmarshalCompressed(curve, instanceId)
+	if x == nil {
+		return nil, errors.New("incorrect instance pub key")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// NewAirGapFromPublicKey initiates a new AirGap instance with instance id
+// derived by InstanceIdFromPublicKey
+func NewAirGapFromPublicKey(version uint8, pub crypto.PublicKey) (*AirGap, error) {
+	instanceId, err := InstanceIdFromPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAirGap(version, instanceId), nil
+}
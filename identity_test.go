@@ -0,0 +1,87 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+// dummySecp256k1PubKey emulates btcec.PublicKey
+type dummySecp256k1PubKey struct {
+	serialized []byte
+}
+
+func (k dummySecp256k1PubKey) SerializeCompressed() []byte {
+	return k.serialized
+}
+
+func TestIdentity_InstanceIdFromPublicKey(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	instanceId, err := InstanceIdFromPublicKey(&privKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(instanceId, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)) {
+		t.Fatal("mismatch ecdsa instance id")
+	}
+
+	edPubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate ed25519 key")
+	}
+
+	instanceId, err = InstanceIdFromPublicKey(edPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if instanceId[0] != InstanceTypeEd25519 || !bytes.Equal(instanceId[1:], edPubKey) {
+		t.Fatal("mismatch ed25519 instance id")
+	}
+
+	serialized := append([]byte{0x02}, bytes.Repeat([]byte{0x01}, 32)...)
+
+	instanceId, err = InstanceIdFromPublicKey(dummySecp256k1PubKey{serialized})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(instanceId, serialized) {
+		t.Fatal("mismatch secp256k1 instance id")
+	}
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	if _, err = InstanceIdFromPublicKey(&p384Key.PublicKey); err == nil {
+		t.Fatal("P-384 key accepted")
+	}
+
+	if _, err = NewAirGapFromPublicKey(VersionDefault, edPubKey); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// FrameIterator generates frames on demand, so frames slice of big transfer
+// isn't materialized. Frames aren't shuffled, redundancy and decoy frames are
+// not emitted. Iterator isn't safe for concurrent use.
+type FrameIterator struct {
+	ch   *Chunks
+	loop bool
+	// position is sequence number of the next frame
+	position uint64
+}
+
+// NewFrameIterator initiates iterator over data and parity frames, frames are
+// emitted endlessly in loop mode. Fountain frames are always emitted endlessly.
+func (ch *Chunks) NewFrameIterator(loop bool) *FrameIterator {
+	return &FrameIterator{ch: ch, loop: loop}
+}
+
+// Next returns the next frame encoded like SerializeB64, false is returned
+// after the last frame
+func (it *FrameIterator) Next() (frame string, ok bool) {
+	chunk, ok := it.NextRaw()
+	if !ok {
+		return "", false
+	}
+	return it.ch.encodeB64(chunk), true
+}
+
+// NextRaw returns the next frame like SerializeRaw, false is returned
+// after the last frame
+func (it *FrameIterator) NextRaw() (frame []byte, ok bool) {
+	it.ch.mu.RLock()
+	defer it.ch.mu.RUnlock()
+
+	if it.ch.count == 0 {
+		return nil, false
+	}
+
+	position := it.position
+	it.position++
+
+	if it.ch.opts.fountain {
+		return it.ch.fountainFrame(uint32(position)), true
+	}
+
+	total := uint64(it.ch.count) + uint64(it.ch.opts.parityGroups(it.ch.count))
+	if position >= total {
+		if !it.loop {
+			it.position = total
+			return nil, false
+		}
+		position %= total
+	}
+
+	if position < uint64(it.ch.count) {
+		return it.ch.getChunkWithHeader(uint32(position)), true
+	}
+	return it.ch.parityFrameWithHeader(int(position - uint64(it.ch.count))), true
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestFrameIterator(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetParity(4).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frames []string
+	it := sender.NewFrameIterator(false)
+	for frame, ok := it.Next(); ok; frame, ok = it.Next() {
+		frames = append(frames, frame)
+	}
+
+	if !reflect.DeepEqual(frames, sender.SerializeB64()) {
+		t.Fatal("incorrect iterated frames")
+	}
+
+	if _, ok := it.Next(); ok {
+		t.Fatal("frame after the last frame")
+	}
+
+	// looped emission repeats frames
+	looped := sender.NewFrameIterator(true)
+	for i := 0; i < len(frames)*2; i++ {
+		frame, ok := looped.Next()
+		if !ok || frame != frames[i%len(frames)] {
+			t.Fatal("incorrect looped frame", i)
+		}
+	}
+}
+
+func TestFrameIterator_Fountain(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetFountain(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetFountain(true)
+	it := sender.NewFrameIterator(false)
+	for i := 0; !receiver.Complete(); i++ {
+		frame, ok := it.NextRaw()
+		if !ok || i > 1000 {
+			t.Fatal("fountain frames are exhausted")
+		}
+
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwecrypt wraps go-airgap payloads in JWE compact serialization
+// (RFC 7516) with direct AES-GCM key, so browser-based receivers can decrypt
+// transmissions with WebCrypto.
+package jwecrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const (
+	algDirect = "dir"
+	encA128   = "A128GCM"
+	encA256   = "A256GCM"
+
+	tagSize = 16
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// EncryptorDecryptor implements go_airgap.EncryptorDecryptor with JWE compact
+// serialization, alg "dir" and enc "A128GCM" or "A256GCM"
+type EncryptorDecryptor struct {
+	key []byte
+	enc string
+	kid string
+}
+
+// NewEncryptorDecryptor initiates JWE envelope with 16 or 32 bytes content key
+func NewEncryptorDecryptor(key []byte) (*EncryptorDecryptor, error) {
+	ed := &EncryptorDecryptor{key: key}
+
+	switch len(key) {
+	case 16:
+		ed.enc = encA128
+	case 32:
+		ed.enc = encA256
+	default:
+		return nil, errors.New("jwe key size must be 16 or 32 bytes")
+	}
+
+	return ed, nil
+}
+
+// SetKeyId defines "kid" header, which helps receivers to select the key
+func (ed *EncryptorDecryptor) SetKeyId(kid string) *EncryptorDecryptor {
+	ed.kid = kid
+	return ed
+}
+
+func (ed *EncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	aead, err := ed.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	serializedHeader, err := json.Marshal(header{Alg: algDirect, Enc: ed.enc, Kid: ed.kid})
+	if err != nil {
+		return nil, err
+	}
+
+	protected := encodeSegment(serializedHeader)
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	sealed := aead.Seal(nil, iv, data, protected)
+
+	return bytes.Join([][]byte{
+		protected,
+		{}, // encrypted key is empty for direct encryption
+		encodeSegment(iv),
+		encodeSegment(sealed[:len(sealed)-tagSize]),
+		encodeSegment(sealed[len(sealed)-tagSize:]),
+	}, []byte(".")), nil
+}
+
+func (ed *EncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	segments := bytes.Split(data, []byte("."))
+	if len(segments) != 5 {
+		return nil, errors.New("jwe compact serialization must have 5 segments")
+	}
+
+	serializedHeader, err := decodeSegment(segments[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var h header
+	if err = json.Unmarshal(serializedHeader, &h); err != nil {
+		return nil, err
+	}
+
+	if h.Alg != algDirect || h.Enc != ed.enc || len(segments[1]) != 0 {
+		return nil, errors.New("jwe has unsupported algorithm")
+	}
+
+	aead, err := ed.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	var parts [3][]byte
+	for i := range parts {
+		if parts[i], err = decodeSegment(segments[i+2]); err != nil {
+			return nil, err
+		}
+	}
+
+	iv, ciphertext, tag := parts[0], parts[1], parts[2]
+
+	if len(iv) != aead.NonceSize() || len(tag) != tagSize {
+		return nil, errors.New("jwe has incorrect iv or tag")
+	}
+
+	return aead.Open(nil, iv, append(ciphertext, tag...), segments[0])
+}
+
+func (ed *EncryptorDecryptor) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(ed.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func encodeSegment(src []byte) []byte {
+	dst := make([]byte, base64.RawURLEncoding.EncodedLen(len(src)))
+	base64.RawURLEncoding.Encode(dst, src)
+	return dst
+}
+
+func decodeSegment(src []byte) ([]byte, error) {
+	dst := make([]byte, base64.RawURLEncoding.DecodedLen(len(src)))
+	n, err := base64.RawURLEncoding.Decode(dst, src)
+	return dst[:n], err
+}
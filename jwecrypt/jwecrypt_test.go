@@ -0,0 +1,59 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwecrypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+const (
+	testPassphrase = "My dummy AES256 password 0123456"
+)
+
+func TestEncryptorDecryptor(t *testing.T) {
+	ed, err := NewEncryptorDecryptor([]byte(testPassphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ed.SetKeyId("airgap-1")
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := ed.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if segments := bytes.Split(encrypted, []byte(".")); len(segments) != 5 || len(segments[1]) != 0 {
+		t.Fatalf("incorrect compact serialization %s", encrypted)
+	}
+
+	decrypted, err := ed.Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	// protected header is authenticated
+	tampered := append([]byte("eyJhbGciOiJkaXIiLCJlbmMiOiJBMjU2R0NNIn0"), encrypted[bytes.IndexByte(encrypted, '.'):]...)
+	if _, err = ed.Decrypt(tampered); err == nil {
+		t.Fatal("tampered header accepted")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// hkdfSHA256 derives key of length bytes with HKDF-SHA256 (RFC 5869)
+func hkdfSHA256(secret, salt, info []byte, length int) []byte {
+	result := make([]byte, length)
+	// output of HKDF-SHA256 is limited by 255 blocks, lengths of keys are constant
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), result); err != nil {
+		panic(err)
+	}
+	return result
+}
@@ -0,0 +1,324 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	keystoreKeyP256    = 0x01
+	keystoreKeyEd25519 = 0x02
+	keystoreKeyX25519  = 0x03
+
+	keystoreKeySize   = 32
+	keystoreEntrySize = compressedPubKeySize + 1 + keystoreKeySize
+)
+
+// ErrKeyNotFound is returned by Keystore, when instance key isn't stored
+var ErrKeyNotFound = errors.New("go-airgap instance key is not found")
+
+// Keystore keeps private keys of instance identities, so keys of several
+// paired devices are selected by instance id. Supported keys are
+// *ecdsa.PrivateKey on elliptic.P256, ed25519.PrivateKey and
+// X25519PrivateKey.
+type Keystore interface {
+	// Get returns private key of instance, or ErrKeyNotFound
+	Get(instanceId []byte) (crypto.PrivateKey, error)
+	// Put stores private key of instance, instance id must be derived from
+	// its public key, see InstanceIdFromPublicKey
+	Put(instanceId []byte, priv crypto.PrivateKey) error
+	// Sign signs data with key of instance, see Signer
+	Sign(instanceId, data []byte) ([]byte, error)
+}
+
+// KeystoreSigner returns Signer with key of instance, e.g. for SetSigner
+func KeystoreSigner(keystore Keystore, instanceId []byte) Signer {
+	return &keystoreSigner{keystore: keystore, instanceId: instanceId}
+}
+
+type keystoreSigner struct {
+	keystore   Keystore
+	instanceId []byte
+}
+
+func (s *keystoreSigner) Sign(data []byte) ([]byte, error) {
+	return s.keystore.Sign(s.instanceId, data)
+}
+
+// MemoryKeystore keeps instance keys in memory
+type MemoryKeystore struct {
+	mu   sync.RWMutex
+	keys map[string]crypto.PrivateKey
+}
+
+func NewMemoryKeystore() *MemoryKeystore {
+	return &MemoryKeystore{keys: make(map[string]crypto.PrivateKey)}
+}
+
+func (k *MemoryKeystore) Get(instanceId []byte) (crypto.PrivateKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	priv, ok := k.keys[string(instanceId)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return priv, nil
+}
+
+func (k *MemoryKeystore) Put(instanceId []byte, priv crypto.PrivateKey) error {
+	if err := verifyKeystoreKey(instanceId, priv); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[string(instanceId)] = priv
+	return nil
+}
+
+func (k *MemoryKeystore) Sign(instanceId, data []byte) ([]byte, error) {
+	priv, err := k.Get(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	return signKeystoreKey(priv, data)
+}
+
+// FileKeystore keeps instance keys in file, which is replaced atomically on
+// every stored key. File is encrypted with EncryptorDecryptor, e.g.
+// passcrypt.NewPassphraseEncryptor, nil EncryptorDecryptor keeps keys cleartext.
+//
+// Serialized format:
+// [instance_id(33) + key_type(1) + private_key(32)] * count
+type FileKeystore struct {
+	mu   sync.RWMutex
+	path string
+	ed   EncryptorDecryptor
+	keys map[string]crypto.PrivateKey
+}
+
+// NewFileKeystore loads instance keys from file at path, missing file is
+// created on the first stored key
+func NewFileKeystore(path string, ed EncryptorDecryptor) (*FileKeystore, error) {
+	k := &FileKeystore{path: path, ed: ed, keys: make(map[string]crypto.PrivateKey)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return k, nil
+		}
+		return nil, err
+	}
+
+	if ed != nil {
+		data, err = ed.Decrypt(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(data)%keystoreEntrySize != 0 {
+		return nil, errors.New("go-airgap keystore file is corrupted")
+	}
+
+	for offset := 0; offset < len(data); offset += keystoreEntrySize {
+		instanceId := data[offset : offset+compressedPubKeySize]
+
+		priv, err := unmarshalKeystoreKey(data[offset+compressedPubKeySize], data[offset+compressedPubKeySize+1:offset+keystoreEntrySize])
+		if err != nil {
+			return nil, err
+		}
+
+		if err = verifyKeystoreKey(instanceId, priv); err != nil {
+			return nil, errors.New("go-airgap keystore file is corrupted")
+		}
+		k.keys[string(instanceId)] = priv
+	}
+
+	return k, nil
+}
+
+func (k *FileKeystore) Get(instanceId []byte) (crypto.PrivateKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	priv, ok := k.keys[string(instanceId)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return priv, nil
+}
+
+func (k *FileKeystore) Put(instanceId []byte, priv crypto.PrivateKey) error {
+	if err := verifyKeystoreKey(instanceId, priv); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	previous, ok := k.keys[string(instanceId)]
+	k.keys[string(instanceId)] = priv
+	if err := k.save(); err != nil {
+		if ok {
+			k.keys[string(instanceId)] = previous
+		} else {
+			delete(k.keys, string(instanceId))
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (k *FileKeystore) Sign(instanceId, data []byte) ([]byte, error) {
+	priv, err := k.Get(instanceId)
+	if err != nil {
+		return nil, err
+	}
+	return signKeystoreKey(priv, data)
+}
+
+// save writes keys to temporary file and renames it to path
+func (k *FileKeystore) save() error {
+	data := make([]byte, 0, len(k.keys)*keystoreEntrySize)
+	for instanceId, priv := range k.keys {
+		keyType, key, err := marshalKeystoreKey(priv)
+		if err != nil {
+			return err
+		}
+
+		data = append(data, instanceId...)
+		data = append(data, keyType)
+		data = append(data, key...)
+	}
+
+	if k.ed != nil {
+		var err error
+		data, err = k.ed.Encrypt(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	// temporary file is created with 0600 permissions
+	file, err := os.CreateTemp(filepath.Dir(k.path), filepath.Base(k.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), k.path)
+}
+
+// verifyKeystoreKey checks that instance id is derived from public key of priv
+func verifyKeystoreKey(instanceId []byte, priv crypto.PrivateKey) error {
+	if _, _, err := marshalKeystoreKey(priv); err != nil {
+		return err
+	}
+
+	var pub crypto.PublicKey
+	switch key := priv.(type) {
+	case *ecdsa.PrivateKey:
+		pub = &key.PublicKey
+	case ed25519.PrivateKey:
+		pub = key.Public()
+	case X25519PrivateKey:
+		pub = key.Public()
+	}
+
+	expected, err := InstanceIdFromPublicKey(pub)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(instanceId, expected) {
+		return errors.New("go-airgap instance id doesn't match private key")
+	}
+	return nil
+}
+
+func marshalKeystoreKey(priv crypto.PrivateKey) (byte, []byte, error) {
+	switch key := priv.(type) {
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return 0, nil, errors.New("go-airgap keystore doesn't support curve")
+		}
+		return keystoreKeyP256, key.D.FillBytes(make([]byte, keystoreKeySize)), nil
+	case ed25519.PrivateKey:
+		if len(key) != ed25519.PrivateKeySize {
+			return 0, nil, errors.New("incorrect ed25519 private key size")
+		}
+		return keystoreKeyEd25519, key.Seed(), nil
+	case X25519PrivateKey:
+		if len(key) != X25519KeySize {
+			return 0, nil, errors.New("incorrect x25519 private key size")
+		}
+		return keystoreKeyX25519, append([]byte{}, key...), nil
+	}
+	return 0, nil, errors.New("go-airgap keystore doesn't support private key type")
+}
+
+func unmarshalKeystoreKey(keyType byte, key []byte) (crypto.PrivateKey, error) {
+	switch keyType {
+	case keystoreKeyP256:
+		curve := elliptic.P256()
+		priv := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(key)}
+		priv.Curve = curve
+		priv.X, priv.Y = curve.ScalarBaseMult(key)
+		return priv, nil
+	case keystoreKeyEd25519:
+		return ed25519.NewKeyFromSeed(key), nil
+	case keystoreKeyX25519:
+		return append(X25519PrivateKey{}, key...), nil
+	}
+	return nil, errors.New("go-airgap keystore has unsupported key type")
+}
+
+func signKeystoreKey(priv crypto.PrivateKey, data []byte) ([]byte, error) {
+	switch key := priv.(type) {
+	case *ecdsa.PrivateKey:
+		return NewECDSASigner(key).Sign(data)
+	case ed25519.PrivateKey:
+		return NewEd25519Signer(key).Sign(data)
+	}
+	return nil, errors.New("go-airgap instance key doesn't support signing")
+}
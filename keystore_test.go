@@ -0,0 +1,168 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKeystore(t *testing.T) {
+	var keys []crypto.PrivateKey
+
+	// the same curve keys of different instances
+	for i := 0; i < 2; i++ {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal("cannot generate private key")
+		}
+		keys = append(keys, priv)
+	}
+
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate ed25519 key")
+	}
+
+	xPriv, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys = append(keys, edPriv, xPriv)
+
+	instanceIds := make([][]byte, len(keys))
+	for i := range keys {
+		instanceIds[i], err = InstanceIdFromPublicKey(keys[i].(interface{ Public() crypto.PublicKey }).Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p384Priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "keys")
+
+	fileKeystore, err := NewFileKeystore(path, NewDummyEncryptorDecryptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keystore := range []Keystore{NewMemoryKeystore(), fileKeystore} {
+		if _, err = keystore.Get(instanceIds[0]); err != ErrKeyNotFound {
+			t.Fatal("missing key is found")
+		}
+
+		for i := range keys {
+			if err = keystore.Put(instanceIds[i], keys[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err = keystore.Put(instanceIds[0], keys[1]); err == nil {
+			t.Fatal("key of another instance is accepted")
+		}
+
+		if err = keystore.Put(instanceIds[0], p384Priv); err == nil {
+			t.Fatal("key of unsupported curve is accepted")
+		}
+
+		for i := range keys[:2] {
+			signature, err := keystore.Sign(instanceIds[i], []byte("message"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifier, err := NewInstanceVerifier(keys[i].(*ecdsa.PrivateKey).Curve, instanceIds[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = verifier.Verify([]byte("message"), signature); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// ed25519 instance signs messages of AirGap
+		sender := NewAirGap(VersionDefault, instanceIds[2]).SetSigner(KeystoreSigner(keystore, instanceIds[2]))
+
+		verifier, err := NewInstanceVerifier(nil, instanceIds[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		receiver := NewAirGap(VersionDefault, instanceIds[2]).SetVerifier(verifier)
+
+		data, err := sender.CreateMessage().AddOperation(opCodeTest1, []byte("signed message")).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = receiver.Unmarshal(data); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = keystore.Sign(instanceIds[3], []byte("message")); err == nil {
+			t.Fatal("x25519 key signs message")
+		}
+	}
+
+	reloaded, err := NewFileKeystore(path, NewDummyEncryptorDecryptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range keys {
+		priv, err := reloaded.Get(instanceIds[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(priv, keys[i]) {
+			t.Fatal("mismatch reloaded key", i)
+		}
+	}
+
+	if _, err = NewFileKeystore(path, nil); err == nil {
+		t.Fatal("encrypted keystore is loaded without decryptor")
+	}
+
+	plain, err := NewFileKeystore(filepath.Join(t.TempDir(), "keys"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = plain.Put(instanceIds[2], keys[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err = NewFileKeystore(plain.path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if priv, err := reloaded.Get(instanceIds[2]); err != nil || !bytes.Equal(priv.(ed25519.PrivateKey), edPriv) {
+		t.Fatal("mismatch reloaded key", err)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	// ErrPayloadTooLarge is returned for payload, which exceeds max payload size
+	ErrPayloadTooLarge = errors.New("go-airgap payload is too large")
+	// ErrDecompressionLimit is returned for received payload, which exceeds
+	// max decompressed size, e.g. compression bomb
+	ErrDecompressionLimit = errors.New("go-airgap decompressed payload exceeds limit")
+)
+
+// SetMaxPayloadSize limits size of payload, which is sent or received,
+// maxPayloadSize is used when size isn't positive
+func (ch *Chunks) SetMaxPayloadSize(size int64) *Chunks {
+	ch.opts.maxPayload = size
+	return ch
+}
+
+// SetMaxPayloadSize limits size of marshaled messages and received payloads,
+// see Chunks.SetMaxPayloadSize
+func (a *AirGap) SetMaxPayloadSize(size int64) *AirGap {
+	a.chunksOpts.maxPayload = size
+	return a
+}
+
+// payloadLimit returns max payload size
+func (o chunksOptions) payloadLimit() int64 {
+	if o.maxPayload <= 0 {
+		return maxPayloadSize
+	}
+	return o.maxPayload
+}
+
+// SetMaxDecompressedSize limits size of decompressed payload, max payload
+// size is used when size isn't positive
+func (ch *Chunks) SetMaxDecompressedSize(size int64) *Chunks {
+	ch.opts.maxDecompressed = size
+	return ch
+}
+
+// SetMaxDecompressedSize limits size of decompressed payload, see Chunks.SetMaxDecompressedSize
+func (a *AirGap) SetMaxDecompressedSize(size int64) *AirGap {
+	a.chunksOpts.maxDecompressed = size
+	return a
+}
+
+// decompressionLimit returns max decompressed payload size
+func (o chunksOptions) decompressionLimit() int64 {
+	if o.maxDecompressed <= 0 {
+		return o.payloadLimit()
+	}
+	return o.maxDecompressed
+}
+
+// verifyPayloadCapacity checks that received payload may fit max payload size,
+// every chunk besides the last one is full, so chunks count is limited before
+// storage is allocated
+func (o chunksOptions) verifyPayloadCapacity(count uint32, capacity uint16) error {
+	if count > 1 && capacity == 0 {
+		return errors.New("go-airgap chunk has zero capacity")
+	}
+
+	if count > 1 && int64(count-1) > o.payloadLimit()/int64(capacity) {
+		return ErrPayloadTooLarge
+	}
+
+	if count > 0 && int64(count-1)*int64(capacity) >= o.payloadLimit() {
+		return ErrPayloadTooLarge
+	}
+	return nil
+}
+
+// limitedReader returns err, when limit is exceeded
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	err   error
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.limit -= int64(n)
+	if r.limit < 0 {
+		return n, r.err
+	}
+	return n, err
+}
+
+// limitedReadCloser limits decompressed payload of ReadCloser
+type limitedReadCloser struct {
+	limitedReader
+	io.Closer
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestChunks_SetMaxPayloadSize(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	if _, err := NewChunks().SetMaxPayloadSize(1000).SetData(payload, 200); err != ErrPayloadTooLarge {
+		t.Fatal("payload limit is not enforced", err)
+	}
+
+	if _, err := NewChunks().SetMaxPayloadSize(1000).SetDataFromReader(bytes.NewReader(payload), 200); err != ErrPayloadTooLarge {
+		t.Fatal("streamed payload limit is not enforced", err)
+	}
+
+	sender, err := NewChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetMaxPayloadSize(1000)
+	if _, err = receiver.ReadRawChunk(sender.SerializeRaw()[0]); err != ErrPayloadTooLarge {
+		t.Fatal("received payload limit is not enforced", err)
+	}
+
+	// wide header without payload declares huge chunks count
+	for _, count := range []uint32{200000000, 0xFFFFFFFF} {
+		frame := make([]byte, wideHeaderOffset)
+		binary.LittleEndian.PutUint32(frame[4:], count)
+
+		if _, err = NewChunks().SetWideHeaders(true).ReadRawChunk(frame); err == nil {
+			t.Fatal("frame without payload is accepted", count)
+		}
+	}
+
+	frame := make([]byte, wideHeaderOffset+1)
+	binary.LittleEndian.PutUint32(frame[4:], 0xFFFFFFFF)
+	if _, err = NewChunks().SetWideHeaders(true).SetMaxPayloadSize(1 << 20).ReadRawChunk(frame); err != ErrPayloadTooLarge {
+		t.Fatal("received payload limit is not enforced", err)
+	}
+}
+
+func TestAirGap_SetMaxPayloadSize(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetMaxPayloadSize(1000)
+
+	if _, err = airGap.CreateMessage().AddOperation(1, make([]byte, 900)).Marshal(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = airGap.CreateMessage().AddOperation(1, make([]byte, 1000)).Marshal(); err != ErrPayloadTooLarge {
+		t.Fatal("message limit is not enforced", err)
+	}
+}
+
+func TestChunks_SetMaxDecompressedSize(t *testing.T) {
+	payload := make([]byte, 1<<20)
+
+	sender, err := NewChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetMaxDecompressedSize(64 << 10)
+	for _, frame := range sender.SerializeB64() {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = receiver.DataE(); err != ErrDecompressionLimit {
+		t.Fatal("decompression limit is not enforced", err)
+	}
+
+	if _, err = receiver.WriteDataTo(io.Discard, nil); err != ErrDecompressionLimit {
+		t.Fatal("decompression limit is not enforced on stream", err)
+	}
+
+	data, err := receiver.SetMaxDecompressedSize(int64(len(payload))).DataE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"errors"
+)
+
+// MessageMagic is prefix of marshaled messages, it's followed by protocol
+// version. Prefix is not encrypted, so consumers distinguish go-airgap payloads
+// from arbitrary data before decryption.
+var MessageMagic = []byte{'A', 'G', 'M', 0x01}
+
+// SetMagic enables MessageMagic prefix of marshaled messages, receiver must
+// enable it too
+func (a *AirGap) SetMagic(enabled bool) *AirGap {
+	a.magic = enabled
+	return a
+}
+
+// DetectFormat checks that data is go-airgap message with MessageMagic prefix
+// and returns protocol version of message
+func DetectFormat(data []byte) (version uint8, ok bool) {
+	if len(data) <= len(MessageMagic) || !bytes.HasPrefix(data, MessageMagic) {
+		return 0, false
+	}
+	return data[len(MessageMagic)], true
+}
+
+func appendMagic(version uint8, data []byte) []byte {
+	result := make([]byte, 0, len(MessageMagic)+1+len(data))
+	result = append(result, MessageMagic...)
+	result = append(result, version)
+	return append(result, data...)
+}
+
+// trimMagic removes MessageMagic prefix and checks protocol version
+func (a *AirGap) trimMagic(data []byte) ([]byte, error) {
+	version, ok := DetectFormat(data)
+	if !ok {
+		return nil, errors.New("go-airgap message has incorrect magic bytes")
+	}
+
+	if err := a.verifyVersion(version); err != nil {
+		return nil, err
+	}
+
+	return data[len(MessageMagic)+1:], nil
+}
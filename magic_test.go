@@ -0,0 +1,69 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestAirGap_SetMagic(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	ed, err := NewAESGCMSIVEncryptorDecryptor(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := NewAirGap(3, instanceId).SetEncryptorDecryptor(ed).SetMagic(true)
+
+	data, err := sender.CreateMessage().AddOperation(opCodeTest1, []byte("payload")).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version, ok := DetectFormat(data); !ok || version != 3 {
+		t.Fatal("incorrect detected format", version, ok)
+	}
+
+	if _, ok := DetectFormat([]byte("https://example.com")); ok {
+		t.Fatal("arbitrary data is detected")
+	}
+
+	message, err := NewAirGap(3, instanceId).SetEncryptorDecryptor(ed).SetMagic(true).Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(message.Operations[0].Data, []byte("payload")) {
+		t.Fatal("incorrect unmarshaled operation")
+	}
+
+	if _, err = NewAirGap(4, instanceId).SetEncryptorDecryptor(ed).SetMagic(true).Unmarshal(data); err == nil {
+		t.Fatal("message of another version is accepted")
+	}
+
+	if _, err = NewAirGap(3, instanceId).SetEncryptorDecryptor(ed).SetMagic(true).Unmarshal(data[len(MessageMagic)+1:]); err == nil {
+		t.Fatal("message without magic bytes is accepted")
+	}
+}
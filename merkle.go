@@ -0,0 +1,183 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	merkleHashSize = 16 // truncated SHA-256
+
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// SetMerkleProofs appends merkle proof of chunk to every data frame, so receiver
+// verifies every frame independently as it arrives, see SetMerkleRoot. Chunks
+// recovered from parity frames can't be verified, so parity is rejected, fountain
+// frames are not supported.
+func (ch *Chunks) SetMerkleProofs(enabled bool) *Chunks {
+	ch.opts.merkle = enabled
+	return ch
+}
+
+// SetMerkleProofs appends merkle proof of chunk to every data frame, see Chunks.SetMerkleProofs
+func (a *AirGap) SetMerkleProofs(enabled bool) *AirGap {
+	a.chunksOpts.merkle = enabled
+	return a
+}
+
+// SetMerkleRoot defines trusted merkle root, e.g. received over authenticated
+// channel. Without trusted root receiver accepts root of the first frame and
+// rejects frames, which don't match it. Root is bound to chunk size of sender.
+func (ch *Chunks) SetMerkleRoot(root []byte) *Chunks {
+	ch.merkleRoot = root
+	ch.merkleTrusted = root != nil
+	return ch
+}
+
+// MerkleRoot returns merkle root of chunks, or nil when merkle proofs are disabled
+// or root is not known yet
+func (ch *Chunks) MerkleRoot() []byte {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if ch.merkleTree != nil {
+		return ch.merkleTree[len(ch.merkleTree)-1][0]
+	}
+	return ch.merkleRoot
+}
+
+// verifyMerkleOptions rejects options, which bypass verification of chunks
+func (o chunksOptions) verifyMerkleOptions() error {
+	if o.merkle && o.parity > 0 {
+		return errors.New("go-airgap merkle proofs can't be combined with parity")
+	}
+	return nil
+}
+
+// merkleDepth returns depth of tree, which leaves count is padded to power of two
+func merkleDepth(count uint32) int {
+	depth := 0
+	for uint64(1)<<depth < uint64(count) {
+		depth++
+	}
+	return depth
+}
+
+// merkleProofSize returns size of proof of every chunk
+func merkleProofSize(count uint32) int {
+	return merkleDepth(count) * merkleHashSize
+}
+
+func merkleLeaf(index uint32, data []byte) []byte {
+	var header [5]byte
+	header[0] = merkleLeafPrefix
+	binary.BigEndian.PutUint32(header[1:], index)
+
+	h := sha256.New()
+	h.Write(header[:])
+	h.Write(data)
+	return h.Sum(nil)[:merkleHashSize]
+}
+
+func merkleNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)[:merkleHashSize]
+}
+
+// buildMerkleTree computes levels of tree over stored chunks, when enabled
+func (ch *Chunks) buildMerkleTree() error {
+	if !ch.opts.merkle || ch.count == 0 {
+		return nil
+	}
+
+	width := 1 << merkleDepth(ch.count)
+	level := make([][]byte, width)
+	for index := range level {
+		if index >= int(ch.count) {
+			level[index] = make([]byte, merkleHashSize)
+			continue
+		}
+
+		chunk, err := ch.storage.Get(index)
+		if err != nil {
+			return err
+		}
+		level[index] = merkleLeaf(uint32(index), chunk)
+	}
+
+	tree := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, len(level)/2)
+		for i := range next {
+			next[i] = merkleNode(level[2*i], level[2*i+1])
+		}
+		tree = append(tree, next)
+		level = next
+	}
+
+	ch.merkleTree = tree
+	return nil
+}
+
+// merkleProof returns siblings of chunk with index from leaf to root
+func (ch *Chunks) merkleProof(index uint32) []byte {
+	proof := make([]byte, 0, merkleProofSize(ch.count))
+	if ch.merkleTree == nil {
+		// frames of incomplete tree carry empty proof
+		return proof[:cap(proof)]
+	}
+
+	position := int(index)
+	for _, level := range ch.merkleTree[:len(ch.merkleTree)-1] {
+		proof = append(proof, level[position^1]...)
+		position /= 2
+	}
+	return proof
+}
+
+// verifyMerkleProof checks chunk with proof against trusted or the first received root
+func (ch *Chunks) verifyMerkleProof(index uint32, data, proof []byte) error {
+	node := merkleLeaf(index, data)
+	position := index
+	for offset := 0; offset < len(proof); offset += merkleHashSize {
+		sibling := proof[offset : offset+merkleHashSize]
+		if position%2 == 0 {
+			node = merkleNode(node, sibling)
+		} else {
+			node = merkleNode(sibling, node)
+		}
+		position /= 2
+	}
+
+	if ch.merkleRoot == nil {
+		ch.merkleRoot = node
+		return nil
+	}
+
+	if !bytes.Equal(ch.merkleRoot, node) {
+		return newFrameError("go-airgap chunk has incorrect merkle proof",
+			FrameCheckMerkle, int(index), 0, 0)
+	}
+	return nil
+}
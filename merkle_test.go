@@ -0,0 +1,98 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetMerkleProofs(t *testing.T) {
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	for _, compact := range []bool{false, true} {
+		sender, err := NewChunks().SetMerkleProofs(true).SetCompactHeaders(compact).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		root := sender.MerkleRoot()
+		if len(root) != merkleHashSize {
+			t.Fatal("incorrect merkle root")
+		}
+
+		frames := sender.SerializeRaw()
+		if len(frames[0]) > 200 {
+			t.Fatal("frame exceeds chunk size", len(frames[0]))
+		}
+
+		receiver := NewChunks().SetMerkleProofs(true).SetCompactHeaders(compact).SetMerkleRoot(root)
+		for _, frame := range frames {
+			if _, err = receiver.ReadRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+
+		// chunks recovered from parity frames bypass merkle proofs
+		if _, err = NewChunks().SetMerkleProofs(true).SetCompactHeaders(compact).SetParity(4).SetData(payload, 200); err == nil {
+			t.Fatal("merkle proofs are combined with parity")
+		}
+
+		if _, err = NewChunks().SetMerkleProofs(true).SetCompactHeaders(compact).SetParity(4).ReadRawChunk(frames[0]); err == nil {
+			t.Fatal("merkle proofs are combined with parity by receiver")
+		}
+	}
+}
+
+func TestChunks_SetMerkleRoot(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetMerkleProofs(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewChunks().SetMerkleProofs(true).SetData(payload[1:], 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// receiver without trusted root accepts root of the first frame
+	receiver := NewChunks().SetMerkleProofs(true)
+	if _, err = receiver.ReadRawChunk(sender.SerializeRaw()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(receiver.MerkleRoot(), sender.MerkleRoot()) {
+		t.Fatal("incorrect received merkle root")
+	}
+
+	_, err = receiver.ReadRawChunk(other.SerializeRaw()[1])
+	if frameErr, ok := err.(*FrameError); !ok || frameErr.Check != FrameCheckMerkle {
+		t.Fatal("frame with incorrect proof is accepted", err)
+	}
+
+	_, err = NewChunks().SetMerkleProofs(true).SetMerkleRoot(other.MerkleRoot()).ReadRawChunk(sender.SerializeRaw()[0])
+	if frameErr, ok := err.(*FrameError); !ok || frameErr.Check != FrameCheckMerkle {
+		t.Fatal("frame with untrusted root is accepted", err)
+	}
+}
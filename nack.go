@@ -0,0 +1,133 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+const (
+	// NackFrameTag is self-describing prefix of NACK frames
+	NackFrameTag = "AGN1:"
+
+	nackFlagTransferId = 1 << 0
+)
+
+// Nack contains chunks, which receiver reports missing
+type Nack struct {
+	// TransferId is id of transfer, when enabled
+	TransferId []byte
+	// Count is chunks count of transfer
+	Count uint32
+	// Missing is bitmap of missing chunks, bit index%8 of byte index/8 is set
+	// when chunk index is missing, trailing zero bytes are trimmed
+	Missing []byte
+}
+
+// IsMissing checks that chunk with index is reported missing
+func (n *Nack) IsMissing(index uint32) bool {
+	return index < n.Count && int(index/8) < len(n.Missing) && n.Missing[index/8]&(1<<(index%8)) != 0
+}
+
+// NackFrame returns frame with bitmap of missing chunks, which receiver displays
+// as its own QR code, so sender re-emits only missing chunks, see RetransmitB64
+func (ch *Chunks) NackFrame() (string, error) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if ch.count == 0 {
+		return "", errors.New("go-airgap chunks count is unknown")
+	}
+
+	var flags byte
+	if ch.transferId != nil {
+		flags |= nackFlagTransferId
+	}
+
+	result := append([]byte{flags}, ch.transferId...)
+	result = appendUvarint(result, uint64(ch.count))
+
+	missing := make([]byte, (int(ch.count)+7)/8)
+	for index := 0; index < int(ch.count); index++ {
+		if !ch.storage.Has(index) {
+			missing[index/8] |= 1 << (index % 8)
+		}
+	}
+
+	for len(missing) > 0 && missing[len(missing)-1] == 0 {
+		missing = missing[:len(missing)-1]
+	}
+
+	return NackFrameTag + base64.StdEncoding.EncodeToString(append(result, missing...)), nil
+}
+
+// ParseNackFrame reads frame serialized with NackFrame
+func ParseNackFrame(frame string) (*Nack, error) {
+	if !strings.HasPrefix(frame, NackFrameTag) {
+		return nil, errors.New("go-airgap frame is not nack")
+	}
+
+	data, err := decodeB64(normalizeFrame(frame[len(NackFrameTag):]))
+	if err != nil || len(data) < 1 {
+		return nil, errors.New("incorrect go-airgap nack")
+	}
+
+	nack := &Nack{}
+	offset := 1
+	if data[0]&nackFlagTransferId != 0 {
+		if len(data) < offset+transferIdSize {
+			return nil, errors.New("incorrect go-airgap nack")
+		}
+		nack.TransferId = data[offset : offset+transferIdSize]
+		offset += transferIdSize
+	}
+
+	count, n := binary.Uvarint(data[offset:])
+	if n <= 0 || count > 0xFFFFFFFF || len(data)-offset-n > (int(count)+7)/8 {
+		return nil, errors.New("incorrect go-airgap nack")
+	}
+
+	nack.Count = uint32(count)
+	nack.Missing = data[offset+n:]
+	return nack, nil
+}
+
+// RetransmitB64 returns frames of chunks, which receiver reports missing
+func (ch *Chunks) RetransmitB64(nack *Nack) ([]string, error) {
+	ch.mu.RLock()
+	if nack.Count != ch.count || (nack.TransferId != nil && !bytes.Equal(nack.TransferId, ch.transferId)) {
+		ch.mu.RUnlock()
+		return nil, ErrTransferMismatch
+	}
+	ch.mu.RUnlock()
+
+	var frames []string
+	for index := uint32(0); index < nack.Count; index++ {
+		if !nack.IsMissing(index) {
+			continue
+		}
+
+		frame, err := ch.GetChunk(index)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_NackFrame(t *testing.T) {
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetTransferId(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetTransferId(true)
+	if _, err = receiver.NackFrame(); err == nil {
+		t.Fatal("nack of unknown transfer is returned")
+	}
+
+	frames := sender.SerializeB64()
+	for i, frame := range frames {
+		if i%5 == 2 {
+			continue
+		}
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	frame, err := receiver.NackFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nack, err := ParseNackFrame(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nack.Count != uint32(sender.Count()) || !bytes.Equal(nack.TransferId, sender.TransferId()) || !nack.IsMissing(2) || nack.IsMissing(3) {
+		t.Fatal("incorrect nack", nack)
+	}
+
+	missing, err := sender.RetransmitB64(nack)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(missing) != (len(frames)+2)/5 {
+		t.Fatal("incorrect retransmitted frames count", len(missing))
+	}
+
+	for _, frame := range missing {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	other, err := NewChunks().SetTransferId(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = other.RetransmitB64(nack); err != ErrTransferMismatch {
+		t.Fatal("nack of another transfer is accepted")
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// Observer receives events of chunks receiver, e.g. for metrics export
+type Observer interface {
+	// FrameProcessed is called for every decoded frame, wasAdded is false
+	// for duplicates and ignored frames
+	FrameProcessed(wasAdded bool)
+	// FrameFailed is called for frames, which cannot be decoded
+	FrameFailed(err error)
+	// TransmissionCompleted is called once, when all chunks are received,
+	// payloadSize is size of received compressed payload
+	TransmissionCompleted(payloadSize int)
+}
+
+// SetObserver defines observer of received frames
+func (ch *Chunks) SetObserver(observer Observer) *Chunks {
+	ch.observer = observer
+	return ch
+}
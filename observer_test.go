@@ -0,0 +1,70 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+type testObserver struct {
+	added, duplicates, failures, completed, payloadSize int
+}
+
+func (o *testObserver) FrameProcessed(wasAdded bool) {
+	if wasAdded {
+		o.added++
+	} else {
+		o.duplicates++
+	}
+}
+
+func (o *testObserver) FrameFailed(_ error) {
+	o.failures++
+}
+
+func (o *testObserver) TransmissionCompleted(payloadSize int) {
+	o.completed++
+	o.payloadSize = payloadSize
+}
+
+func TestChunks_SetObserver(t *testing.T) {
+	payload := make([]byte, 500)
+	_, _ = rand.Read(payload)
+
+	sender, err := (&Chunks{}).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeB64()
+
+	observer := &testObserver{}
+	receiver := (&Chunks{}).SetObserver(observer)
+
+	for _, frame := range frames {
+		_, _ = receiver.ReadB64Chunk(frame)
+	}
+	_, _ = receiver.ReadB64Chunk(frames[1])
+	_, _ = receiver.ReadB64Chunk("%%%")
+
+	if observer.added != len(frames) || observer.duplicates != 1 || observer.failures != 1 {
+		t.Fatal("incorrect frames events", observer)
+	}
+
+	if observer.completed != 1 || observer.payloadSize < len(payload) {
+		t.Fatal("incorrect completion events", observer)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oteltrace instruments go-airgap flows with OpenTelemetry spans.
+// Both sides derive the same message id from the transmitted payload, so
+// sender and receiver traces can be correlated after the fact.
+package oteltrace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+const (
+	instrumentationName = "github.com/censync/go-airgap/oteltrace"
+
+	AttrMessageId  = attribute.Key("airgap.message_id")
+	AttrOperations = attribute.Key("airgap.operations")
+	AttrOpCode     = attribute.Key("airgap.op_code")
+	AttrSize       = attribute.Key("airgap.size")
+	AttrFrames     = attribute.Key("airgap.frames")
+	AttrDuplicates = attribute.Key("airgap.duplicates")
+	AttrFailures   = attribute.Key("airgap.failures")
+)
+
+// Tracer creates spans for marshal, frames emission, assembly, decrypt and dispatch
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer initiates Tracer with the provider, e.g. otel.GetTracerProvider()
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(instrumentationName)}
+}
+
+// MessageId returns transmission identifier, derived from marshaled payload
+func MessageId(payload []byte) string {
+	digest := sha256.Sum256(payload)
+	return hex.EncodeToString(digest[:8])
+}
+
+// Marshal marshals message within "airgap.marshal" span
+func (t *Tracer) Marshal(ctx context.Context, m *airgap.Message) ([]byte, error) {
+	_, span := t.tracer.Start(ctx, "airgap.marshal", trace.WithAttributes(
+		AttrOperations.Int(len(m.Operations)),
+	))
+	defer span.End()
+
+	data, err := m.Marshal()
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(AttrMessageId.String(MessageId(data)), AttrSize.Int(len(data)))
+	return data, nil
+}
+
+// MarshalB64Chunks marshals message and emits frames within "airgap.emit" span.
+// Frames are serialized with frame options of message.
+func (t *Tracer) MarshalB64Chunks(ctx context.Context, m *airgap.Message, chunkSize int) ([]string, error) {
+	ctx, span := t.tracer.Start(ctx, "airgap.emit")
+	defer span.End()
+
+	data, err := t.Marshal(ctx, m)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	chunks, err := m.NewChunks().SetData(data, chunkSize)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	frames := chunks.SerializeB64()
+	span.SetAttributes(AttrMessageId.String(MessageId(data)), AttrFrames.Int(len(frames)))
+
+	return frames, nil
+}
+
+// Unmarshal unmarshals assembled payload within "airgap.unmarshal" span,
+// decryption is traced as nested "airgap.decrypt" span
+func (t *Tracer) Unmarshal(ctx context.Context, a *airgap.AirGap, data []byte) (*airgap.Message, error) {
+	ctx, span := t.tracer.Start(ctx, "airgap.unmarshal", trace.WithAttributes(
+		AttrMessageId.String(MessageId(data)),
+		AttrSize.Int(len(data)),
+	))
+	defer span.End()
+
+	var d airgap.Decryptor
+	if ed := a.EncryptorDecryptor(); ed != nil {
+		d = &decryptor{ctx: ctx, tracer: t.tracer, ed: ed}
+	}
+
+	message, err := a.UnmarshalWithDecryptor(data, d)
+	if err != nil {
+		recordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(AttrOperations.Int(len(message.Operations)))
+	return message, nil
+}
+
+// Dispatch passes every message operation to handler within "airgap.dispatch" span
+func (t *Tracer) Dispatch(ctx context.Context, m *airgap.Message, handler func(ctx context.Context, op *airgap.Operation) error) error {
+	ctx, span := t.tracer.Start(ctx, "airgap.dispatch", trace.WithAttributes(
+		AttrOperations.Int(len(m.Operations)),
+	))
+	defer span.End()
+
+	for _, op := range m.Operations {
+		opCtx, opSpan := t.tracer.Start(ctx, "airgap.operation", trace.WithAttributes(
+			AttrOpCode.Int(int(op.OpCode)),
+			AttrSize.Int(int(op.Size)),
+		))
+		err := handler(opCtx, op)
+		if err != nil {
+			recordError(opSpan, err)
+			opSpan.End()
+			recordError(span, err)
+			return err
+		}
+		opSpan.End()
+	}
+
+	return nil
+}
+
+// decryptor wraps session decryptor with "airgap.decrypt" span
+type decryptor struct {
+	ctx    context.Context
+	tracer trace.Tracer
+	ed     airgap.EncryptorDecryptor
+}
+
+func (d *decryptor) Encrypt(data []byte) ([]byte, error) {
+	return d.ed.Encrypt(data)
+}
+
+func (d *decryptor) Decrypt(data []byte) ([]byte, error) {
+	return d.trace(data, d.ed.Decrypt)
+}
+
+func (d *decryptor) EncryptAD(data, additionalData []byte) ([]byte, error) {
+	e, ok := d.ed.(airgap.EncryptorAEAD)
+	if !ok {
+		return nil, errors.New("oteltrace encryptor doesn't support associated data")
+	}
+	return e.EncryptAD(data, additionalData)
+}
+
+func (d *decryptor) DecryptAD(data, additionalData []byte) ([]byte, error) {
+	ad, ok := d.ed.(airgap.DecryptorAEAD)
+	if !ok {
+		return nil, errors.New("oteltrace decryptor doesn't support associated data")
+	}
+
+	return d.trace(data, func(data []byte) ([]byte, error) {
+		return ad.DecryptAD(data, additionalData)
+	})
+}
+
+// trace calls decrypt within "airgap.decrypt" span
+func (d *decryptor) trace(data []byte, decrypt func(data []byte) ([]byte, error)) ([]byte, error) {
+	_, span := d.tracer.Start(d.ctx, "airgap.decrypt", trace.WithAttributes(AttrSize.Int(len(data))))
+	defer span.End()
+
+	result, err := decrypt(data)
+	if err != nil {
+		recordError(span, err)
+	}
+	return result, err
+}
+
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oteltrace
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ed := airgap.NewHybridEncryptorDecryptor(airgap.NewECIESIdentity(key), airgap.NewECIESRecipient(&key.PublicKey))
+
+	instance, err := airgap.NewAirGapFromPublicKey(airgap.VersionDefault, &key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance.SetEncryptorDecryptor(ed).SetChunkChecksum(true)
+
+	payload := make([]byte, 600)
+	_, _ = rand.Read(payload)
+
+	message := instance.CreateMessage().AddOperation(0x01, payload)
+
+	frames, err := tracer.MarshalB64Chunks(context.Background(), message, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// frames are serialized with chunk checksum of message
+	corrupted, err := base64.StdEncoding.DecodeString(frames[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted[len(corrupted)/2] ^= 0xFF
+	if _, err = message.NewChunks().AddRawChunk(corrupted); err == nil {
+		t.Fatal("corrupted frame is accepted")
+	}
+
+	receiver := tracer.NewReceiver(context.Background(), message.NewChunks())
+	for _, frame := range frames {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	received, err := tracer.Unmarshal(receiver.Context(), instance, receiver.chunks.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tracer.Dispatch(receiver.Context(), received, func(ctx context.Context, op *airgap.Operation) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	for _, name := range []string{"airgap.marshal", "airgap.emit", "airgap.assemble", "airgap.unmarshal", "airgap.decrypt", "airgap.dispatch", "airgap.operation"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatal("span is not recorded", name)
+		}
+	}
+
+	messageId := func(span sdktrace.ReadOnlySpan) string {
+		for _, attr := range span.Attributes() {
+			if attr.Key == AttrMessageId {
+				return attr.Value.AsString()
+			}
+		}
+		return ""
+	}
+
+	if id := messageId(spans["airgap.marshal"]); id == "" || id != messageId(spans["airgap.assemble"]) || id != messageId(spans["airgap.unmarshal"]) {
+		t.Fatal("message id is not correlated")
+	}
+
+	if spans["airgap.decrypt"].Parent().SpanID() != spans["airgap.unmarshal"].SpanContext().SpanID() {
+		t.Fatal("decrypt span has incorrect parent")
+	}
+
+	if spans["airgap.unmarshal"].Parent().SpanID() != spans["airgap.assemble"].SpanContext().SpanID() {
+		t.Fatal("unmarshal span has incorrect parent")
+	}
+}
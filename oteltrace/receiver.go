@@ -0,0 +1,87 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oteltrace
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+// Receiver assembles frames within "airgap.assemble" span, started with
+// the first frame and ended, when transmission is completed
+type Receiver struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	tracer *Tracer
+	span   trace.Span
+	chunks *airgap.Chunks
+
+	frames, duplicates, failures int
+}
+
+// NewReceiver initiates traced receiver for single transmission
+func (t *Tracer) NewReceiver(ctx context.Context, chunks *airgap.Chunks) *Receiver {
+	return &Receiver{
+		ctx:    ctx,
+		tracer: t,
+		chunks: chunks,
+	}
+}
+
+// ReadB64Chunk reads frame with Chunks.ReadB64Chunk
+func (r *Receiver) ReadB64Chunk(frame string) (wasAdded bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.span == nil {
+		r.ctx, r.span = r.tracer.tracer.Start(r.ctx, "airgap.assemble")
+	}
+
+	wasAdded, err = r.chunks.ReadB64Chunk(frame)
+
+	switch {
+	case err != nil:
+		r.failures++
+		r.span.AddEvent("frame failed")
+	case wasAdded:
+		r.frames++
+	default:
+		r.duplicates++
+	}
+
+	if wasAdded && r.chunks.IsFilled() {
+		r.span.SetAttributes(
+			AttrMessageId.String(MessageId(r.chunks.Data())),
+			AttrFrames.Int(r.frames),
+			AttrDuplicates.Int(r.duplicates),
+			AttrFailures.Int(r.failures),
+		)
+		r.span.End()
+	}
+
+	return wasAdded, err
+}
+
+// Context returns context with assembly span, to be passed to Tracer.Unmarshal
+func (r *Receiver) Context() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ctx
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+	"sort"
+)
+
+const (
+	paddingMarker = 0x80
+)
+
+var errPaddingWithoutEncryptor = errors.New("go-airgap padding requires encryptor")
+
+// SetPaddingBuckets enables padding of serialized message before encryption to
+// the smallest bucket size, which fits the message, so observers of QR animation
+// can't infer payload type from frames count. Messages larger than the largest
+// bucket are padded to its multiple. Padding requires encryptor, otherwise
+// chunks compression strips it. Receiver must enable padding too.
+func (a *AirGap) SetPaddingBuckets(buckets ...int) *AirGap {
+	a.paddingBuckets = nil
+	for _, bucket := range buckets {
+		if bucket > 0 {
+			a.paddingBuckets = append(a.paddingBuckets, bucket)
+		}
+	}
+	sort.Ints(a.paddingBuckets)
+	return a
+}
+
+// padMessage appends ISO/IEC 7816-4 padding: marker byte and zeroes
+func padMessage(data []byte, buckets []int) []byte {
+	size := len(data) + 1
+
+	paddedSize := 0
+	for _, bucket := range buckets {
+		if bucket >= size {
+			paddedSize = bucket
+			break
+		}
+	}
+
+	if paddedSize == 0 {
+		largest := buckets[len(buckets)-1]
+		paddedSize = (size + largest - 1) / largest * largest
+	}
+
+	result := make([]byte, paddedSize)
+	copy(result, data)
+	result[len(data)] = paddingMarker
+	return result
+}
+
+func unpadMessage(data []byte) ([]byte, error) {
+	for i := len(data) - 1; i >= 0; i-- {
+		switch data[i] {
+		case 0:
+			continue
+		case paddingMarker:
+			return data[:i], nil
+		}
+		break
+	}
+	return nil, errors.New("go-airgap message has incorrect padding")
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestPadding_SetPaddingBuckets(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetEncryptorDecryptor(NewDummyEncryptorDecryptor()).
+		SetPaddingBuckets(1024, 256)
+
+	var sizes []int
+	for _, payload := range [][]byte{[]byte("short"), bytes.Repeat([]byte("medium"), 20), bytes.Repeat([]byte("long"), 200)} {
+		serialized, err := airGap.CreateMessage().AddOperation(opCodeTest1, payload).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(serialized))
+
+		message, err := airGap.Unmarshal(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(message.Operations) != 1 || !bytes.Equal(message.Operations[0].Data, payload) {
+			t.Fatal("mismatch operation data")
+		}
+	}
+
+	if sizes[0] != sizes[1] || sizes[1] == sizes[2] {
+		t.Fatalf("incorrect padded sizes %v", sizes)
+	}
+
+	plain := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetPaddingBuckets(256)
+
+	if _, err = plain.CreateMessage().AddOperation(opCodeTest1, []byte("short")).Marshal(); err == nil {
+		t.Fatal("message is padded without encryptor")
+	}
+
+	if _, err = unpadMessage([]byte{1, 2, 0}); err == nil {
+		t.Fatal("incorrect padding accepted")
+	}
+}
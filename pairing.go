@@ -0,0 +1,204 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	pairingOfferVersion   = 1
+	pairingOfferNonceSize = 16
+	pairingOfferSize      = 1 + compressedPubKeySize + 8 + pairingOfferNonceSize // version(1) + instance_id(33) + expires_at(8) + nonce(16)
+
+	pairingOfferSignatureInfo = "go-airgap pairing offer"
+
+	// DefaultPairingOfferMaxTTL is default max lifetime of accepted pairing offers
+	DefaultPairingOfferMaxTTL = 10 * time.Minute
+)
+
+var (
+	ErrPairingExpired = errors.New("go-airgap pairing offer is expired")
+	ErrPairingReused  = errors.New("go-airgap pairing offer is already used")
+)
+
+// PairingOffer is payload of pairing QR code, which is valid until expiry
+// and can be used only once. Offer is signed by key of instance.
+type PairingOffer struct {
+	InstanceId []byte
+	ExpiresAt  time.Time
+	Nonce      []byte
+	Signature  []byte
+}
+
+// NewPairingOffer initiates pairing offer with random single-use nonce, which
+// expires after ttl, signer must use key of instance, see NewInstanceVerifier
+func NewPairingOffer(instanceId []byte, ttl time.Duration, signer Signer) (*PairingOffer, error) {
+	if len(instanceId) != compressedPubKeySize {
+		return nil, errors.New("incorrect instance pub key size")
+	}
+
+	nonce := make([]byte, pairingOfferNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	offer := &PairingOffer{
+		InstanceId: instanceId,
+		ExpiresAt:  time.Now().Add(ttl).Truncate(time.Second),
+		Nonce:      nonce,
+	}
+
+	signature, err := signer.Sign(offer.signedData())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signature) == 0 || len(signature) > maxSignatureSize {
+		return nil, errors.New("go-airgap pairing offer signature has incorrect size")
+	}
+
+	offer.Signature = signature
+	return offer, nil
+}
+
+// Marshal serializes pairing offer with signature trailer:
+// version(1) + instance_id(33) + expires_at(8) + nonce(16) + signature + signature_size(1)
+func (p *PairingOffer) Marshal() []byte {
+	result := make([]byte, pairingOfferSize, pairingOfferSize+len(p.Signature)+1)
+	result[0] = pairingOfferVersion
+	copy(result[1:], p.InstanceId)
+	binary.BigEndian.PutUint64(result[1+compressedPubKeySize:], uint64(p.ExpiresAt.Unix()))
+	copy(result[1+compressedPubKeySize+8:], p.Nonce)
+	result = append(result, p.Signature...)
+	return append(result, byte(len(p.Signature)))
+}
+
+// MarshalB64 represents pairing offer as string, ready for QR code
+func (p *PairingOffer) MarshalB64() string {
+	return base64.StdEncoding.EncodeToString(p.Marshal())
+}
+
+// signedData returns signed representation of pairing offer
+func (p *PairingOffer) signedData() []byte {
+	data := p.Marshal()
+	return append([]byte(pairingOfferSignatureInfo), data[:pairingOfferSize]...)
+}
+
+func UnmarshalPairingOffer(data []byte) (*PairingOffer, error) {
+	if len(data) < pairingOfferSize+2 {
+		return nil, errors.New("go-airgap pairing offer has incorrect size")
+	}
+
+	if data[0] != pairingOfferVersion {
+		return nil, errors.New("go-airgap pairing offer version is not supported")
+	}
+
+	size := int(data[len(data)-1])
+	if size == 0 || pairingOfferSize+size+1 != len(data) {
+		return nil, errors.New("go-airgap pairing offer has incorrect size")
+	}
+
+	offset := 1 + compressedPubKeySize
+	return &PairingOffer{
+		InstanceId: append([]byte{}, data[1:offset]...),
+		ExpiresAt:  time.Unix(int64(binary.BigEndian.Uint64(data[offset:offset+8])), 0),
+		Nonce:      append([]byte{}, data[offset+8:pairingOfferSize]...),
+		Signature:  append([]byte{}, data[pairingOfferSize:len(data)-1]...),
+	}, nil
+}
+
+func UnmarshalPairingOfferB64(frame string) (*PairingOffer, error) {
+	data, err := base64.StdEncoding.DecodeString(frame)
+	if err != nil {
+		return nil, errors.New("incorrect go-airgap pairing offer")
+	}
+
+	return UnmarshalPairingOffer(data)
+}
+
+// PairingVerifier enforces signature, expiry and single use of pairing offers
+// on the receiver side, used nonces are kept in memory until expiry, which is
+// limited by max ttl
+type PairingVerifier struct {
+	mu     sync.Mutex
+	used   map[string]time.Time
+	curve  elliptic.Curve
+	maxTTL time.Duration
+	now    func() time.Time
+}
+
+// NewPairingVerifier initiates verifier of offers with instance ids on curve,
+// see NewInstanceVerifier
+func NewPairingVerifier(curve elliptic.Curve) *PairingVerifier {
+	return &PairingVerifier{
+		used:   make(map[string]time.Time),
+		curve:  curve,
+		maxTTL: DefaultPairingOfferMaxTTL,
+		now:    time.Now,
+	}
+}
+
+// SetMaxTTL limits lifetime of accepted offers, offers expiring later are rejected
+func (v *PairingVerifier) SetMaxTTL(ttl time.Duration) *PairingVerifier {
+	v.maxTTL = ttl
+	return v
+}
+
+// Verify accepts signed pairing offer once before expiry, otherwise returns
+// ErrSignature, ErrPairingExpired or ErrPairingReused
+func (v *PairingVerifier) Verify(offer *PairingOffer) error {
+	verifier, err := NewInstanceVerifier(v.curve, offer.InstanceId)
+	if err != nil {
+		return err
+	}
+
+	if err = verifier.Verify(offer.signedData(), offer.Signature); err != nil {
+		return ErrSignature
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+
+	for nonce, expiresAt := range v.used {
+		if now.After(expiresAt) {
+			delete(v.used, nonce)
+		}
+	}
+
+	if now.After(offer.ExpiresAt) {
+		return ErrPairingExpired
+	}
+
+	if offer.ExpiresAt.After(now.Add(v.maxTTL)) {
+		return errors.New("go-airgap pairing offer lifetime exceeds limit")
+	}
+
+	if _, ok := v.used[string(offer.Nonce)]; ok {
+		return ErrPairingReused
+	}
+
+	v.used[string(offer.Nonce)] = offer.ExpiresAt
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func TestPairing_PairingOffer(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	offer, err := NewPairingOffer(instanceId, time.Minute, NewECDSASigner(privKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readedOffer, err := UnmarshalPairingOfferB64(offer.MarshalB64())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(readedOffer.InstanceId, offer.InstanceId) ||
+		!bytes.Equal(readedOffer.Nonce, offer.Nonce) ||
+		!readedOffer.ExpiresAt.Equal(offer.ExpiresAt) ||
+		!bytes.Equal(readedOffer.Signature, offer.Signature) {
+		t.Fatal("mismatch pairing offer")
+	}
+
+	verifier := NewPairingVerifier(elliptic.P256())
+
+	if err = verifier.Verify(readedOffer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = verifier.Verify(readedOffer); err != ErrPairingReused {
+		t.Fatal("pairing offer reused")
+	}
+
+	// offer of another instance key and forged expiry are rejected
+	forged := *offer
+	forged.ExpiresAt = forged.ExpiresAt.Add(time.Hour)
+	forged.Nonce = bytes.Repeat([]byte{2}, pairingOfferNonceSize)
+	if err = verifier.Verify(&forged); err != ErrSignature {
+		t.Fatal("forged pairing offer accepted", err)
+	}
+
+	strangerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	if forged.Signature, err = NewECDSASigner(strangerKey).Sign(forged.signedData()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = verifier.Verify(&forged); err != ErrSignature {
+		t.Fatal("pairing offer signed by another key accepted", err)
+	}
+
+	longLived, err := NewPairingOffer(instanceId, time.Hour, NewECDSASigner(privKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = verifier.Verify(longLived); err == nil {
+		t.Fatal("pairing offer exceeding max ttl accepted")
+	}
+
+	if err = NewPairingVerifier(elliptic.P256()).SetMaxTTL(2 * time.Hour).Verify(longLived); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier.now = func() time.Time {
+		return time.Now().Add(2 * time.Minute)
+	}
+
+	if offer, err = NewPairingOffer(instanceId, time.Minute, NewECDSASigner(privKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = verifier.Verify(offer); err != ErrPairingExpired {
+		t.Fatal("expired pairing offer accepted")
+	}
+}
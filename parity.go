@@ -0,0 +1,166 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// parityFrame is XOR of group chunks, padded to chunk size
+type parityFrame struct {
+	data []byte
+	// lastSize is size of the last chunk of group
+	lastSize uint16
+}
+
+// SetParity appends XOR parity frame for every group of n chunks, so receiver
+// reconstructs any single lost chunk of group. Parity frames have indexes after
+// the last chunk. Receiver must enable parity with the same n.
+func (ch *Chunks) SetParity(n int) *Chunks {
+	ch.opts.parity = n
+	return ch
+}
+
+// SetParity appends XOR parity frames, see Chunks.SetParity
+func (a *AirGap) SetParity(n int) *AirGap {
+	a.chunksOpts.parity = n
+	return a
+}
+
+// parityGroups returns count of parity groups
+func (o chunksOptions) parityGroups(count uint32) int {
+	if o.parity <= 0 {
+		return 0
+	}
+	return (int(count) + o.parity - 1) / o.parity
+}
+
+// parityGroupRange returns chunks range of parity group
+func (ch *Chunks) parityGroupRange(group int) (start, end int) {
+	start = group * ch.opts.parity
+	end = start + ch.opts.parity
+	if end > int(ch.count) {
+		end = int(ch.count)
+	}
+	return start, end
+}
+
+// parityFramesWithHeader returns serialized parity frames
+func (ch *Chunks) parityFramesWithHeader() [][]byte {
+	var frames [][]byte
+	for group := 0; group < ch.opts.parityGroups(ch.count); group++ {
+		frames = append(frames, ch.parityFrameWithHeader(group))
+	}
+	return frames
+}
+
+// parityFrameWithHeader returns serialized parity frame of group
+func (ch *Chunks) parityFrameWithHeader(group int) []byte {
+	start, end := ch.parityGroupRange(group)
+
+	data := make([]byte, ch.size)
+	var lastSize uint16
+	for index := start; index < end; index++ {
+		chunk, _ := ch.storage.Get(index)
+		for i := range chunk {
+			data[i] ^= chunk[i]
+		}
+		lastSize = uint16(len(chunk))
+	}
+
+	frame := append(ch.frameHeader(ch.count+uint32(group), lastSize), data...)
+	return ch.sealFrame(ch.count+uint32(group), frame)
+}
+
+// addParityFrame keeps parity frame of incomplete group, the lost chunk is
+// reconstructed, when the rest of group is received
+func (ch *Chunks) addParityFrame(group int, lastSize uint16, data []byte) (wasAdded bool, err error) {
+	index := int(ch.count) + group
+	if len(data) != int(ch.size) {
+		return false, newFrameError("go-airgap parity chunk has incorrect size", FrameCheckSize, index, int(ch.size), len(data))
+	}
+
+	if lastSize > ch.size {
+		return false, newFrameError("go-airgap parity chunk has incorrect size", FrameCheckSize, index, int(ch.size), int(lastSize))
+	}
+
+	if _, ok := ch.parityFrames[group]; ok {
+		ch.stats.Duplicates++
+		return false, nil
+	}
+
+	if ch.parityFrames == nil {
+		ch.parityFrames = make(map[int]parityFrame)
+	}
+
+	ch.parityFrames[group] = parityFrame{
+		data:     append([]byte{}, data...),
+		lastSize: lastSize,
+	}
+
+	return ch.recoverParityGroup(group)
+}
+
+// recoverParityGroup reconstructs the single lost chunk of group with parity frame
+func (ch *Chunks) recoverParityGroup(group int) (wasAdded bool, err error) {
+	parity, ok := ch.parityFrames[group]
+	if !ok {
+		return false, nil
+	}
+
+	start, end := ch.parityGroupRange(group)
+
+	missing := -1
+	data := append([]byte{}, parity.data...)
+
+	for index := start; index < end; index++ {
+		if !ch.storage.Has(index) {
+			if missing >= 0 {
+				// parity recovers single lost chunk only
+				return false, nil
+			}
+			missing = index
+			continue
+		}
+
+		chunk, err := ch.storage.Get(index)
+		if err != nil {
+			return false, err
+		}
+
+		for i := range chunk {
+			data[i] ^= chunk[i]
+		}
+	}
+
+	delete(ch.parityFrames, group)
+
+	if missing < 0 {
+		return false, nil
+	}
+
+	size := int(ch.size)
+	if missing == end-1 {
+		size = int(parity.lastSize)
+	}
+
+	if size > len(data) {
+		return false, newFrameError("go-airgap chunk has incorrect size", FrameCheckSize, missing, len(data), size)
+	}
+
+	if err = ch.storage.Put(missing, data[:size]); err != nil {
+		return false, err
+	}
+
+	ch.filled++
+	ch.received += size
+	return true, nil
+}
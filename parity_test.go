@@ -0,0 +1,111 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetParity(t *testing.T) {
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	for _, compact := range []bool{false, true} {
+		sender, err := NewChunks().SetParity(4).SetCompactHeaders(compact).SetFrameKey([]byte("key")).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		count := int(sender.Count())
+		frames := sender.SerializeRaw()
+
+		if len(frames) != count+(count+3)/4 {
+			t.Fatal("incorrect frames count", len(frames))
+		}
+
+		// the single chunk of every group is lost, including the last chunk
+		receiver := NewChunks().SetParity(4).SetCompactHeaders(compact).SetFrameKey([]byte("key"))
+		for i, frame := range frames {
+			if i < count && ((i/4 < (count-1)/4 && i%4 == 1) || i == count-1) {
+				continue
+			}
+
+			if _, err = receiver.ReadRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !receiver.IsFilled() || !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect reconstructed payload")
+		}
+	}
+}
+
+func TestChunks_SetParityParityFirst(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetParity(3).SetData(payload, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := int(sender.Count())
+	frames := sender.SerializeRaw()
+
+	receiver := NewChunks().SetParity(3)
+
+	// parity frames are received before data frames
+	for _, frame := range frames[count:] {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, frame := range frames[:count] {
+		if i%3 == 0 {
+			continue
+		}
+
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect reconstructed payload")
+	}
+
+	// truncated parity frame of the last group is rejected
+	group := (count - 1) / 3
+	receiver = NewChunks().SetParity(3)
+	for _, frame := range frames[group*3 : count-1] {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parity := frames[count+group]
+	if _, err = receiver.ReadRawChunk(parity[:len(parity)-1]); err == nil {
+		t.Fatal("truncated parity frame is accepted")
+	}
+
+	// receiver without parity rejects parity frames
+	if _, err = NewChunks().ReadRawChunk(frames[count]); err == nil {
+		t.Fatal("parity frame is accepted")
+	}
+}
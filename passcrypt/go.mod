@@ -0,0 +1,10 @@
+module github.com/censync/go-airgap/passcrypt
+
+go 1.18
+
+require (
+	github.com/censync/go-airgap v0.1.0
+	golang.org/x/crypto v0.7.0
+)
+
+require golang.org/x/sys v0.6.0 // indirect
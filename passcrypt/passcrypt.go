@@ -0,0 +1,254 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package passcrypt encrypts go-airgap payloads with key derived from user
+// passphrase via Argon2id, for use cases without key-pair pairing, e.g.
+// paper backups.
+package passcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+const (
+	formatVersion = 1
+	saltSize      = 16
+	keySize       = 32
+	// headerSize is version(1) + time(4) + memory(4) + threads(1) + salt(16)
+	headerSize = 1 + 4 + 4 + 1 + saltSize
+
+	// MaxTime and MaxMemory bound Argon2id parameters of sender
+	MaxTime   = 16
+	MaxMemory = 1 << 20
+
+	// maxCachedKeys bounds count of cached keys of received headers
+	maxCachedKeys = 16
+)
+
+var _ airgap.EncryptorDecryptor = (*PassphraseEncryptor)(nil)
+
+// Params contains Argon2id parameters, memory is in KiB
+type Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// DefaultParams are second recommended Argon2id parameters of RFC 9106
+var DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// DefaultMaxParams bound Argon2id parameters of received payloads, so crafted
+// header cannot exhaust receiver resources, see PassphraseEncryptor.SetMaxParams
+var DefaultMaxParams = Params{Time: 4, Memory: 128 * 1024, Threads: 8}
+
+// PassphraseEncryptor implements go_airgap.EncryptorDecryptor with AES-256-GCM
+// and key derived from passphrase. Salt and parameters are serialized, so
+// receiver needs passphrase only.
+//
+// Serialized format:
+// version(1) + time(4) + memory(4) + threads(1) + salt(16) + nonce(12) + ciphertext
+type PassphraseEncryptor struct {
+	passphrase []byte
+	params     Params
+	maxParams  Params
+
+	mu sync.Mutex
+	// header and aead of the sender key, derived on the first Encrypt
+	header []byte
+	aead   cipher.AEAD
+	// keys caches derived keys of successfully decrypted headers
+	keys map[string]cipher.AEAD
+}
+
+// NewPassphraseEncryptor initiates passphrase encryption with Argon2id params
+func NewPassphraseEncryptor(passphrase []byte, params Params) (*PassphraseEncryptor, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase is empty")
+	}
+
+	if err := params.verify(); err != nil {
+		return nil, err
+	}
+
+	return &PassphraseEncryptor{
+		passphrase: append([]byte{}, passphrase...),
+		params:     params,
+		maxParams:  DefaultMaxParams,
+		keys:       make(map[string]cipher.AEAD),
+	}, nil
+}
+
+// SetMaxParams bounds Argon2id parameters of received payloads, DefaultMaxParams
+// are used by default
+func (ed *PassphraseEncryptor) SetMaxParams(params Params) *PassphraseEncryptor {
+	ed.maxParams = params
+	return ed
+}
+
+func (ed *PassphraseEncryptor) Encrypt(data []byte) ([]byte, error) {
+	header, aead, err := ed.senderKey()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, len(header)+len(nonce)+len(data)+aead.Overhead())
+	result = append(result, header...)
+	result = append(result, nonce...)
+
+	return aead.Seal(result, nonce, data, header), nil
+}
+
+func (ed *PassphraseEncryptor) Decrypt(data []byte) ([]byte, error) {
+	if len(data) < headerSize {
+		return nil, errors.New("passphrase ciphertext to small")
+	}
+
+	header := data[:headerSize]
+
+	aead, isCached, err := ed.receiverKey(header)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[headerSize:]
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("passphrase ciphertext to small")
+	}
+
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], header)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isCached {
+		ed.cacheKey(header, aead)
+	}
+	return plaintext, nil
+}
+
+// senderKey derives key with random salt once per instance
+func (ed *PassphraseEncryptor) senderKey() ([]byte, cipher.AEAD, error) {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	if ed.aead != nil {
+		return ed.header, ed.aead, nil
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, nil, err
+	}
+
+	header := make([]byte, headerSize)
+	header[0] = formatVersion
+	binary.BigEndian.PutUint32(header[1:], ed.params.Time)
+	binary.BigEndian.PutUint32(header[5:], ed.params.Memory)
+	header[9] = ed.params.Threads
+	copy(header[10:], salt)
+
+	aead, err := deriveAEAD(ed.passphrase, salt, ed.params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ed.header, ed.aead = header, aead
+	ed.keys[string(header)] = aead
+	return header, aead, nil
+}
+
+// receiverKey derives key of serialized salt and parameters, unless it's cached
+func (ed *PassphraseEncryptor) receiverKey(header []byte) (aead cipher.AEAD, isCached bool, err error) {
+	if header[0] != formatVersion {
+		return nil, false, errors.New("passphrase ciphertext has unsupported version")
+	}
+
+	params := Params{
+		Time:    binary.BigEndian.Uint32(header[1:]),
+		Memory:  binary.BigEndian.Uint32(header[5:]),
+		Threads: header[9],
+	}
+
+	if err = params.verify(); err != nil {
+		return nil, false, err
+	}
+
+	if params.Time > ed.maxParams.Time || params.Memory > ed.maxParams.Memory || params.Threads > ed.maxParams.Threads {
+		return nil, false, errors.New("argon2 parameters exceed receiver limits")
+	}
+
+	ed.mu.Lock()
+	aead, isCached = ed.keys[string(header)]
+	ed.mu.Unlock()
+
+	if isCached {
+		return aead, true, nil
+	}
+
+	aead, err = deriveAEAD(ed.passphrase, header[10:], params)
+	return aead, false, err
+}
+
+// cacheKey keeps key of decrypted header, cache is dropped when it's full
+func (ed *PassphraseEncryptor) cacheKey(header []byte, aead cipher.AEAD) {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	if len(ed.keys) >= maxCachedKeys {
+		ed.keys = make(map[string]cipher.AEAD)
+		if ed.header != nil {
+			ed.keys[string(ed.header)] = ed.aead
+		}
+	}
+
+	ed.keys[string(header)] = aead
+}
+
+func (p Params) verify() error {
+	if p.Time == 0 || p.Time > MaxTime {
+		return errors.New("argon2 time parameter is out of range")
+	}
+
+	if p.Threads == 0 || p.Memory < 8*uint32(p.Threads) || p.Memory > MaxMemory {
+		return errors.New("argon2 memory parameter is out of range")
+	}
+
+	return nil
+}
+
+func deriveAEAD(passphrase, salt []byte, params Params) (cipher.AEAD, error) {
+	key := argon2.IDKey(passphrase, salt, params.Time, params.Memory, params.Threads, keySize)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
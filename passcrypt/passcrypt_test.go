@@ -0,0 +1,190 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package passcrypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+// testParams are weak parameters to keep tests fast
+var testParams = Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestPassphraseEncryptor(t *testing.T) {
+	sender, err := NewPassphraseEncryptor([]byte("correct horse battery staple"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	data := []byte(`{"key": "secret message"}`)
+
+	serialized, err := airgap.NewAirGap(airgap.VersionDefault, instanceId).
+		SetEncryptorDecryptor(sender).
+		CreateMessage().AddOperation(1, data).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// receiver knows passphrase only, parameters are serialized
+	receiver, err := NewPassphraseEncryptor([]byte("correct horse battery staple"), DefaultParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := airgap.NewAirGap(airgap.VersionDefault, instanceId).
+		SetEncryptorDecryptor(receiver).
+		Unmarshal(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(message.Operations[0].Data, data) {
+		t.Fatal("mismatch operation data")
+	}
+
+	first, err := sender.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := sender.Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(first, second) {
+		t.Fatal("nonce is reused")
+	}
+
+	stranger, err := NewPassphraseEncryptor([]byte("incorrect passphrase"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = stranger.Decrypt(first); err == nil {
+		t.Fatal("payload decrypted with incorrect passphrase")
+	}
+
+	// parameters are authenticated
+	tampered := append([]byte{}, first...)
+	tampered[4]++
+	if _, err = receiver.Decrypt(tampered); err == nil {
+		t.Fatal("tampered parameters accepted")
+	}
+
+	// crafted parameters are rejected before key derivation
+	tampered = append([]byte{}, first...)
+	tampered[5] = 0xFF
+	if _, err = receiver.Decrypt(tampered); err == nil {
+		t.Fatal("excessive memory parameter accepted")
+	}
+}
+
+func TestNewPassphraseEncryptor(t *testing.T) {
+	if _, err := NewPassphraseEncryptor(nil, DefaultParams); err == nil {
+		t.Fatal("empty passphrase accepted")
+	}
+
+	for _, params := range []Params{
+		{Time: 0, Memory: 64, Threads: 1},
+		{Time: 1, Memory: 4, Threads: 1},
+		{Time: 1, Memory: 64, Threads: 0},
+		{Time: 1, Memory: MaxMemory + 1, Threads: 1},
+	} {
+		if _, err := NewPassphraseEncryptor([]byte("passphrase"), params); err == nil {
+			t.Fatal("incorrect params accepted", params)
+		}
+	}
+}
+
+func TestPassphraseEncryptor_SetMaxParams(t *testing.T) {
+	params := Params{Time: DefaultMaxParams.Time + 1, Memory: 64, Threads: 1}
+
+	sender, err := NewPassphraseEncryptor([]byte("passphrase"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := sender.Encrypt([]byte("secret message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver, err := NewPassphraseEncryptor([]byte("passphrase"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = receiver.Decrypt(data); err == nil {
+		t.Fatal("parameters exceeding receiver limits accepted")
+	}
+
+	if _, err = receiver.SetMaxParams(params).Decrypt(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPassphraseEncryptor_KeysCache(t *testing.T) {
+	receiver, err := NewPassphraseEncryptor([]byte("passphrase"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stranger, err := NewPassphraseEncryptor([]byte("incorrect passphrase"), testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := stranger.Encrypt([]byte("secret message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = receiver.Decrypt(data); err == nil || len(receiver.keys) != 0 {
+		t.Fatal("key of undecrypted payload is cached")
+	}
+
+	for i := 0; i < maxCachedKeys*2; i++ {
+		sender, err := NewPassphraseEncryptor([]byte("passphrase"), testParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err = sender.Encrypt([]byte("secret message"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = receiver.Decrypt(data); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(receiver.keys) > maxCachedKeys {
+			t.Fatal("keys cache isn't bounded", len(receiver.keys))
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pgpcrypt wraps go-airgap payloads in OpenPGP encryption and signature,
+// for air-gapped procedures built around GnuPG verification.
+package pgpcrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+const (
+	messageType = "PGP MESSAGE"
+)
+
+// EncryptorDecryptor implements go_airgap.EncryptorDecryptor with OpenPGP messages
+type EncryptorDecryptor struct {
+	recipients openpgp.EntityList
+	keyring    openpgp.EntityList
+	signer     *openpgp.Entity
+
+	armored          bool
+	requireSignature bool
+}
+
+// NewEncryptorDecryptor initiates OpenPGP envelope for recipients. Keyring holds
+// private keys for decryption and public keys for signature verification, signer
+// is optional. Output is ASCII-armored by default.
+func NewEncryptorDecryptor(recipients, keyring openpgp.EntityList, signer *openpgp.Entity) *EncryptorDecryptor {
+	return &EncryptorDecryptor{
+		recipients: recipients,
+		keyring:    keyring,
+		signer:     signer,
+		armored:    true,
+	}
+}
+
+// SetArmor enables ASCII-armored output, binary messages are more compact
+// for QR frames
+func (ed *EncryptorDecryptor) SetArmor(armored bool) *EncryptorDecryptor {
+	ed.armored = armored
+	return ed
+}
+
+// SetRequireSignature rejects messages without valid signature of keyring entity
+func (ed *EncryptorDecryptor) SetRequireSignature(required bool) *EncryptorDecryptor {
+	ed.requireSignature = required
+	return ed
+}
+
+func (ed *EncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	if len(ed.recipients) == 0 {
+		return nil, errors.New("openpgp recipients are not defined")
+	}
+
+	var (
+		buf bytes.Buffer
+		out io.WriteCloser
+		err error
+	)
+
+	if ed.armored {
+		out, err = armor.Encode(&buf, messageType, nil)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		out = nopCloser{&buf}
+	}
+
+	w, err := openpgp.Encrypt(out, ed.recipients, ed.signer, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err = out.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (ed *EncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(data)
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		block, err := armor.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if block.Type != messageType {
+			return nil, errors.New("openpgp armor has incorrect type")
+		}
+		r = block.Body
+	}
+
+	md, err := openpgp.ReadMessage(r, ed.keyring, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// signature is verified after whole body is read
+	result, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if md.IsSigned && md.SignatureError != nil {
+		return nil, md.SignatureError
+	}
+
+	if ed.requireSignature && (!md.IsSigned || md.SignedBy == nil) {
+		return nil, errors.New("openpgp message is not signed by keyring entity")
+	}
+
+	return result, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgpcrypt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+func TestEncryptorDecryptor(t *testing.T) {
+	sender, err := openpgp.NewEntity("sender", "", "sender@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipient, err := openpgp.NewEntity("recipient", "", "recipient@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := NewEncryptorDecryptor(openpgp.EntityList{recipient}, nil, sender).Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(encrypted, []byte("-----BEGIN PGP MESSAGE-----")) {
+		t.Fatal("message is not armored")
+	}
+
+	decrypted, err := NewEncryptorDecryptor(nil, openpgp.EntityList{recipient, sender}, nil).
+		SetRequireSignature(true).
+		Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	unsigned, err := NewEncryptorDecryptor(openpgp.EntityList{recipient}, nil, nil).SetArmor(false).Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewEncryptorDecryptor(nil, openpgp.EntityList{recipient}, nil).SetRequireSignature(true).Decrypt(unsigned); err == nil {
+		t.Fatal("unsigned message accepted")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+)
+
+// pipeline flags of messages with SetCompressBeforeEncrypt
+const (
+	pipelineFlagRaw        = 0
+	pipelineFlagCompressed = 1
+)
+
+// SetCompressBeforeEncrypt compresses message body before encryption, since
+// encrypted payload is incompressible. Marshaled message is prefixed with
+// pipeline flag, body is sent uncompressed, when compression doesn't reduce
+// its size. Chunks compression of encrypted payload is useless, so it's
+// reasonable to combine with SetSkipIncompressible. Receiver must enable it too.
+func (a *AirGap) SetCompressBeforeEncrypt(enabled bool) *AirGap {
+	a.compressFirst = enabled
+	return a
+}
+
+// compressBody compresses serialized message body and returns pipeline flag
+func (m *Message) compressBody(body []byte) (byte, []byte, error) {
+	compressed, err := m.chunksOpts.compress(body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(compressed) >= len(body) {
+		return pipelineFlagRaw, body, nil
+	}
+	return pipelineFlagCompressed, compressed, nil
+}
+
+// trimPipelineFlag removes pipeline flag of marshaled message
+func trimPipelineFlag(data []byte) (byte, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, errors.New("go-airgap message to small")
+	}
+
+	if data[0] != pipelineFlagRaw && data[0] != pipelineFlagCompressed {
+		return 0, nil, errors.New("go-airgap message has incorrect pipeline flag")
+	}
+
+	return data[0], data[1:], nil
+}
+
+// uncompressBody uncompresses message body according to pipeline flag
+func (a *AirGap) uncompressBody(flag byte, body []byte) ([]byte, error) {
+	if flag != pipelineFlagCompressed {
+		return body, nil
+	}
+	return a.chunksOpts.uncompress(body)
+}
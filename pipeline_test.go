@@ -0,0 +1,83 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestAirGap_SetCompressBeforeEncrypt(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newAirGap := func() *AirGap {
+		return NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+			SetEncryptorDecryptor(NewHybridEncryptorDecryptor(NewECIESIdentity(privKey), NewECIESRecipient(&privKey.PublicKey)))
+	}
+
+	compressible := bytes.Repeat([]byte(`{"key": "value"}`), 200)
+	random := make([]byte, 1000)
+	_, _ = rand.Read(random)
+
+	for _, payload := range [][]byte{compressible, random} {
+		encryptFirst, err := newAirGap().CreateMessage().AddOperation(opCodeTest1, payload).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		airGap := newAirGap().SetCompressBeforeEncrypt(true)
+		compressFirst, err := airGap.CreateMessage().AddOperation(opCodeTest1, payload).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(compressFirst) > len(encryptFirst)+1 {
+			t.Fatal("incorrect compressed message size", len(compressFirst), len(encryptFirst))
+		}
+
+		message, err := airGap.Unmarshal(compressFirst)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+
+	message, err := newAirGap().SetCompressBeforeEncrypt(true).CreateMessage().AddOperation(opCodeTest1, compressible).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(message) >= len(compressible) {
+		t.Fatal("message body is not compressed")
+	}
+
+	if _, err = newAirGap().Unmarshal(message); err == nil {
+		t.Fatal("message is accepted without pipeline flag")
+	}
+
+	message[0] = 0xFF
+	if _, err = newAirGap().SetCompressBeforeEncrypt(true).Unmarshal(message); err == nil {
+		t.Fatal("incorrect pipeline flag is accepted")
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pivcrypt adapts PIV hardware tokens to go-airgap signing and
+// decryption, so identity and decryption keys of the air-gapped side live on
+// a YubiKey rather than on disk.
+//
+// Package doesn't depend on PC/SC stack, keys returned by piv-go are accepted as is:
+//
+//	yk, _ := piv.Open(card)
+//	priv, _ := yk.PrivateKey(piv.SlotKeyManagement, pub, piv.KeyAuth{PIN: pin})
+//	ed := pivcrypt.NewEncryptorDecryptor(priv.(*piv.ECDSAPrivateKey))
+package pivcrypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+// Key is PIV slot private key, implemented by *piv.ECDSAPrivateKey
+type Key interface {
+	crypto.Signer
+	SharedKey(peer *ecdsa.PublicKey) ([]byte, error)
+}
+
+// Signer implements go_airgap.Signer with PIV slot key, data is hashed
+// with SHA-256 and signed in ASN.1 DER format
+type Signer struct {
+	key crypto.Signer
+}
+
+// Verifier implements go_airgap.Verifier for signatures of Signer
+type Verifier struct {
+	pub *ecdsa.PublicKey
+}
+
+// NewEncryptorDecryptor initiates hybrid encryption, where payload keys are
+// unwrapped with ECDH on the token
+func NewEncryptorDecryptor(key Key, recipients ...airgap.KeyWrapper) *airgap.HybridEncryptorDecryptor {
+	return airgap.NewHybridEncryptorDecryptor(airgap.NewECIESIdentityFromKey(key), recipients...)
+}
+
+func NewSigner(key crypto.Signer) *Signer {
+	return &Signer{key: key}
+}
+
+func (s *Signer) Sign(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// InstanceId returns compressed public key of the slot, suitable for NewAirGap
+func (s *Signer) InstanceId() ([]byte, error) {
+	pub, ok := s.key.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("piv slot must have EC public key")
+	}
+
+	return elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y), nil
+}
+
+func NewVerifier(pub *ecdsa.PublicKey) *Verifier {
+	return &Verifier{pub: pub}
+}
+
+func (v *Verifier) Verify(data, signature []byte) error {
+	digest := sha256.Sum256(data)
+
+	if !ecdsa.VerifyASN1(v.pub, digest[:], signature) {
+		return errors.New("piv signature verification failed")
+	}
+	return nil
+}
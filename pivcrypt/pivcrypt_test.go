@@ -0,0 +1,96 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pivcrypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+// dummyToken emulates *piv.ECDSAPrivateKey
+type dummyToken struct {
+	*ecdsa.PrivateKey
+}
+
+func (k dummyToken) SharedKey(peer *ecdsa.PublicKey) ([]byte, error) {
+	x, _ := k.Curve.ScalarMult(peer.X, peer.Y, k.D.Bytes())
+	shared := make([]byte, 32)
+	return x.FillBytes(shared), nil
+}
+
+func newDummyToken(t *testing.T) dummyToken {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+	return dummyToken{privKey}
+}
+
+func TestEncryptorDecryptor(t *testing.T) {
+	token := newDummyToken(t)
+
+	data := []byte(`{"key": "secret message"}`)
+
+	encrypted, err := airgap.NewHybridEncryptorDecryptor(nil, airgap.NewECIESRecipient(&token.PublicKey)).Encrypt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := NewEncryptorDecryptor(token).Decrypt(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, data) {
+		t.Fatal("mismatch decrypted data")
+	}
+}
+
+func TestSigner(t *testing.T) {
+	token := newDummyToken(t)
+
+	signer := NewSigner(token)
+
+	instanceId, err := signer.InstanceId()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(instanceId) != 33 {
+		t.Fatal("incorrect instance id size")
+	}
+
+	data := []byte(`{"key": "signed message"}`)
+
+	signature, err := signer.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := NewVerifier(&token.PublicKey)
+
+	if err = verifier.Verify(data, signature); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = verifier.Verify([]byte("forged"), signature); err == nil {
+		t.Fatal("forged data verified")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+const (
+	// RoutingTagSize is size of cleartext routing tag of privacy mode messages
+	RoutingTagSize = 8
+
+	anonymousNonceSize = 8
+)
+
+// RoutingTag returns cleartext tag of privacy mode messages addressed to
+// instance id, routing key is shared by paired devices
+func RoutingTag(routingKey, instanceId []byte) []byte {
+	mac := hmac.New(sha256.New, routingKey)
+	mac.Write(instanceId)
+	return mac.Sum(nil)[:RoutingTagSize]
+}
+
+// MessageRoutingTag returns routing tag of message marshaled in privacy mode,
+// so receiver with several paired devices can select AirGap instance before
+// decryption
+func MessageRoutingTag(data []byte) ([]byte, error) {
+	if len(data) < RoutingTagSize {
+		return nil, errors.New("go-airgap message to small")
+	}
+
+	return data[:RoutingTagSize], nil
+}
+
+// SetPrivacyMode enables encryption of the whole message including version,
+// instance id and op codes, only routing tag derived with routingKey stays
+// cleartext. Requires EncryptorDecryptor, nil routingKey disables privacy mode.
+func (a *AirGap) SetPrivacyMode(routingKey []byte) *AirGap {
+	a.routingKey = routingKey
+	return a
+}
+
+// sealPrivate prepends routing tag to the encrypted message
+func (m *Message) sealPrivate(data []byte) ([]byte, error) {
+	if m.e == nil {
+		return nil, errors.New("go-airgap privacy mode requires encryptor")
+	}
+
+	encrypted, err := m.encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(RoutingTag(m.routingKey, m.InstanceId), encrypted...), nil
+}
+
+// openPrivate checks routing tag and returns encrypted message
+func (a *AirGap) openPrivate(data []byte) ([]byte, error) {
+	if a.ed == nil {
+		return nil, errors.New("go-airgap privacy mode requires decryptor")
+	}
+
+	tag, err := MessageRoutingTag(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(tag, RoutingTag(a.routingKey, a.instanceId)) {
+		return nil, errors.New("go-airgap message has incorrect routing tag")
+	}
+
+	return data[RoutingTagSize:], nil
+}
+
+// SetAnonymousMode replaces instance id in message header with random nonce and
+// HMAC over shared pairing secret, so paired devices authenticate each other
+// without broadcasting linkable identity. Nil pairingSecret disables the mode.
+func (a *AirGap) SetAnonymousMode(pairingSecret []byte) *AirGap {
+	a.pairingSecret = pairingSecret
+	return a
+}
+
+// anonymousInstanceId returns nonce || HMAC(pairing_secret, nonce || instance_id)
+// with the size of instance id
+func anonymousInstanceId(pairingSecret, instanceId, nonce []byte) []byte {
+	mac := hmac.New(sha256.New, pairingSecret)
+	mac.Write(nonce)
+	mac.Write(instanceId)
+
+	return append(append([]byte{}, nonce...), mac.Sum(nil)[:compressedPubKeySize-anonymousNonceSize]...)
+}
+
+func newAnonymousInstanceId(pairingSecret, instanceId []byte) ([]byte, error) {
+	nonce := make([]byte, anonymousNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return anonymousInstanceId(pairingSecret, instanceId, nonce), nil
+}
+
+func verifyAnonymousInstanceId(pairingSecret, instanceId, anonymousId []byte) bool {
+	if len(anonymousId) != compressedPubKeySize {
+		return false
+	}
+
+	return hmac.Equal(anonymousId, anonymousInstanceId(pairingSecret, instanceId, anonymousId[:anonymousNonceSize]))
+}
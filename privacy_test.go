@@ -0,0 +1,117 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestPrivacy_SetPrivacyMode(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+	routingKey := []byte("dummy routing key")
+
+	airGap := NewAirGap(VersionDefault, instanceId).
+		SetEncryptorDecryptor(NewDummyEncryptorDecryptor()).
+		SetPrivacyMode(routingKey)
+
+	serialized, err := airGap.CreateMessage().
+		AddOperation(opCodeTest1, []byte(`{"key": "secret message 1"}`)).
+		Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(serialized, instanceId) {
+		t.Fatal("instance id is not encrypted")
+	}
+
+	tag, err := MessageRoutingTag(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(tag, RoutingTag(routingKey, instanceId)) {
+		t.Fatal("mismatch routing tag")
+	}
+
+	message, err := airGap.Unmarshal(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Operations[0].OpCode != opCodeTest1 {
+		t.Fatal("mismatch op code")
+	}
+
+	airGap.SetPrivacyMode([]byte("other routing key"))
+	if _, err = airGap.Unmarshal(serialized); err == nil {
+		t.Fatal("message with foreign routing tag accepted")
+	}
+}
+
+func TestPrivacy_SetAnonymousMode(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate private key")
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+	pairingSecret := []byte("dummy pairing secret")
+
+	airGap := NewAirGap(VersionDefault, instanceId).SetAnonymousMode(pairingSecret)
+
+	message := airGap.CreateMessage().AddOperation(opCodeTest1, []byte(`{"key": "message 1"}`))
+
+	serialized, err := message.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serialized2, err := message.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(serialized, instanceId) {
+		t.Fatal("instance id is broadcasted")
+	}
+
+	if bytes.Equal(serialized[:airGapMessagesOffset], serialized2[:airGapMessagesOffset]) {
+		t.Fatal("anonymous header is linkable")
+	}
+
+	unserialized, err := airGap.Unmarshal(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(unserialized.InstanceId, instanceId) {
+		t.Fatal("mismatch instance id")
+	}
+
+	airGap.SetAnonymousMode([]byte("other pairing secret"))
+	if _, err = airGap.Unmarshal(serialized); err == nil {
+		t.Fatal("message with foreign pairing secret accepted")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+	"time"
+)
+
+// Profile is preset of transmission settings for the display and scanner pair
+type Profile struct {
+	Name string
+	// ChunkSize is chunk payload size, it's used when FrameSize isn't defined
+	ChunkSize int
+	// FrameSize is max length of encoded frame, see AirGap.SetFrameSize
+	FrameSize int
+	// FrameTag enables self-describing frames prefix
+	FrameTag bool
+	// Encoding defines text encoding of frames, see AirGap.SetEncoding
+	Encoding FrameEncoding
+	// Compressor defines payload compression, default is used when nil
+	Compressor Compressor
+	// Parity, Redundancy and Fountain define forward error correction, see
+	// AirGap.SetParity, AirGap.SetRedundancy and AirGap.SetFountain
+	Parity     int
+	Redundancy int
+	Fountain   bool
+	// FrameInterval is recommended display time of every animated frame,
+	// zero for static frames, see AirGap.FrameRate
+	FrameInterval time.Duration
+	// Checksummer defines integrity checksum algorithm, default is used when nil
+	Checksummer Checksummer
+}
+
+var (
+	// ProfileTerminal fits ANSI QR codes to the standard terminal window,
+	// base45 frames use QR alphanumeric mode
+	ProfileTerminal = Profile{
+		Name:          "terminal",
+		FrameSize:     256,
+		Encoding:      EncodingBase45,
+		Redundancy:    2,
+		FrameInterval: 200 * time.Millisecond,
+	}
+
+	// ProfileMobileCamera is tuned for phone cameras scanning from monitor,
+	// fountain frames tolerate missed frames of any loop
+	ProfileMobileCamera = Profile{
+		Name:          "mobile-camera",
+		FrameSize:     600,
+		FrameTag:      true,
+		Fountain:      true,
+		FrameInterval: 150 * time.Millisecond,
+	}
+
+	// ProfilePrint uses dense static codes for paper backups, parity codes
+	// recover a damaged code of every group
+	ProfilePrint = Profile{
+		Name:       "print",
+		FrameSize:  2000,
+		FrameTag:   true,
+		Compressor: CompressorGzip,
+		Parity:     4,
+	}
+
+	// ProfileEInk is tuned for slow refresh of e-ink displays
+	ProfileEInk = Profile{
+		Name:          "e-ink",
+		FrameSize:     1000,
+		FrameTag:      true,
+		Parity:        8,
+		FrameInterval: 1500 * time.Millisecond,
+	}
+
+	profiles = []Profile{ProfileTerminal, ProfileMobileCamera, ProfilePrint, ProfileEInk}
+)
+
+// ProfileByName returns preset profile by name
+func ProfileByName(name string) (Profile, error) {
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return Profile{}, errors.New("unknown go-airgap profile")
+}
+
+// SetProfile applies profile settings, which overrides chunk and frame size,
+// encoding and forward error correction
+func (a *AirGap) SetProfile(profile Profile) *AirGap {
+	a.profile = profile
+	if profile.ChunkSize > 0 {
+		a.SetChunkSize(profile.ChunkSize)
+	}
+	a.SetFrameSize(profile.FrameSize)
+	a.SetFrameTag(profile.FrameTag)
+	a.SetEncoding(profile.Encoding)
+	if profile.Compressor != nil {
+		a.SetCompressor(profile.Compressor)
+	}
+	a.SetParity(profile.Parity)
+	a.SetRedundancy(profile.Redundancy)
+	a.SetFountain(profile.Fountain)
+	if profile.Checksummer != nil {
+		a.SetChecksummer(profile.Checksummer)
+	}
+	return a
+}
+
+// Profile returns applied profile, or empty profile when not defined
+func (a *AirGap) Profile() Profile {
+	return a.profile
+}
+
+// FrameInterval returns recommended display time of every frame
+func (a *AirGap) FrameInterval() time.Duration {
+	return a.profile.FrameInterval
+}
+
+// FrameRate returns recommended frames per second, zero for static frames
+func (a *AirGap) FrameRate() float64 {
+	if a.profile.FrameInterval <= 0 {
+		return 0
+	}
+	return float64(time.Second) / float64(a.profile.FrameInterval)
+}
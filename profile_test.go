@@ -0,0 +1,113 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestProfileByName(t *testing.T) {
+	for _, expected := range []Profile{ProfileTerminal, ProfileMobileCamera, ProfilePrint, ProfileEInk} {
+		profile, err := ProfileByName(expected.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(profile, expected) {
+			t.Fatal("incorrect profile", profile.Name)
+		}
+	}
+
+	if _, err := ProfileByName("unknown"); err == nil {
+		t.Fatal("unknown profile is accepted")
+	}
+}
+
+func TestAirGap_SetProfile(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := make([]byte, 4096)
+	_, _ = rand.Read(payload)
+
+	for _, profile := range profiles {
+		instance := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+			SetProfile(profile)
+
+		if instance.FrameSize() != profile.FrameSize || instance.FrameInterval() != profile.FrameInterval {
+			t.Fatal("profile is not applied", profile.Name)
+		}
+
+		opts := instance.chunksOpts
+		if opts.encoding != profile.Encoding || opts.parity != profile.Parity ||
+			opts.redundancy != profile.Redundancy || opts.fountain != profile.Fountain {
+			t.Fatal("profile frames options are not applied", profile.Name)
+		}
+
+		if profile.FrameInterval > 0 && instance.FrameRate() <= 0 {
+			t.Fatal("incorrect frame rate", profile.Name)
+		}
+
+		frames, err := instance.CreateMessage().AddOperation(opCodeTest1, payload).MarshalTextChunks()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+			SetProfile(profile)
+		chunks := receiver.CreateMessage().NewChunks()
+
+		for _, frame := range frames {
+			if len(frame) > profile.FrameSize {
+				t.Fatal("frame exceeds profile frame size", profile.Name, len(frame))
+			}
+
+			if HasFrameTag(frame) != profile.FrameTag {
+				t.Fatal("incorrect frame tag", profile.Name)
+			}
+
+			if _, err = chunks.ReadTextChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		data, err := chunks.DataE()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message, err := receiver.Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, payload) {
+			t.Fatal("incorrect received payload", profile.Name)
+		}
+	}
+
+	instance := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetProfile(Profile{Name: "custom", ChunkSize: 300})
+	if instance.ChunkSize() != 300 {
+		t.Fatal("profile chunk size is not applied")
+	}
+}
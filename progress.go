@@ -0,0 +1,35 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// SetOnChunkReceived defines callback, which is called after every new chunk
+// with received and total chunks count, e.g. to render progress bar
+func (ch *Chunks) SetOnChunkReceived(callback func(received, total uint32)) *Chunks {
+	ch.onChunkReceived = callback
+	return ch
+}
+
+// Progress returns count of received chunks, total count of chunks and
+// percent of received chunks, total is 0 before the first frame
+func (ch *Chunks) Progress() (received, total uint32, percent float64) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if ch.count == 0 {
+		return 0, 0, 0
+	}
+
+	return ch.filled, ch.count, float64(ch.filled) / float64(ch.count) * 100
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_Progress(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []uint32
+	receiver := NewChunks().SetOnChunkReceived(func(received, total uint32) {
+		if total != uint32(sender.Count()) {
+			t.Fatal("incorrect total", total)
+		}
+		calls = append(calls, received)
+	})
+
+	if received, total, percent := receiver.Progress(); received != 0 || total != 0 || percent != 0 {
+		t.Fatal("incorrect initial progress")
+	}
+
+	frames := sender.SerializeB64()
+	for _, frame := range []string{frames[0], frames[1], frames[0]} {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	received, total, percent := receiver.Progress()
+	if received != 2 || total != uint32(sender.Count()) || percent != 200/float64(total) {
+		t.Fatal("incorrect progress", received, total, percent)
+	}
+
+	// duplicates are not reported
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+		t.Fatal("incorrect callback calls", calls)
+	}
+}
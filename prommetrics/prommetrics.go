@@ -0,0 +1,98 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prommetrics exports go-airgap receiver events as Prometheus
+// metrics, so scanning performance can be monitored on kiosks and
+// signing stations.
+package prommetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+var _ airgap.Observer = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Collector implements prometheus.Collector and go_airgap.Observer,
+// to be attached with Chunks.SetObserver
+type Collector struct {
+	frames      *prometheus.CounterVec
+	failures    prometheus.Counter
+	completed   prometheus.Counter
+	payloadSize prometheus.Histogram
+}
+
+// NewCollector initiates collector with metrics prefixed with namespace
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		frames: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "airgap",
+			Name:      "frames_processed_total",
+			Help:      "Number of decoded go-airgap frames by result.",
+		}, []string{"result"}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "airgap",
+			Name:      "decode_failures_total",
+			Help:      "Number of go-airgap frames, which cannot be decoded.",
+		}),
+		completed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "airgap",
+			Name:      "transmissions_completed_total",
+			Help:      "Number of completely received go-airgap transmissions.",
+		}),
+		payloadSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "airgap",
+			Name:      "payload_size_bytes",
+			Help:      "Size of completely received go-airgap payloads.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+		}),
+	}
+}
+
+func (c *Collector) FrameProcessed(wasAdded bool) {
+	if wasAdded {
+		c.frames.WithLabelValues("added").Inc()
+	} else {
+		c.frames.WithLabelValues("duplicate").Inc()
+	}
+}
+
+func (c *Collector) FrameFailed(_ error) {
+	c.failures.Inc()
+}
+
+func (c *Collector) TransmissionCompleted(payloadSize int) {
+	c.completed.Inc()
+	c.payloadSize.Observe(float64(payloadSize))
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.frames.Describe(ch)
+	c.failures.Describe(ch)
+	c.completed.Describe(ch)
+	c.payloadSize.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.frames.Collect(ch)
+	c.failures.Collect(ch)
+	c.completed.Collect(ch)
+	c.payloadSize.Collect(ch)
+}
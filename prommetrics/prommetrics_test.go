@@ -0,0 +1,78 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector("test")
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := make([]byte, 1000)
+	_, _ = rand.Read(payload)
+
+	sender, err := (&airgap.Chunks{}).SetData(payload, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeB64()
+	if len(frames) < 2 {
+		t.Fatal("incorrect frames count", len(frames))
+	}
+
+	receiver := (&airgap.Chunks{}).SetObserver(collector)
+
+	for _, frame := range frames {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _ = receiver.ReadB64Chunk(frames[0])
+	_, _ = receiver.ReadB64Chunk("not base64!")
+
+	if got := testutil.ToFloat64(collector.frames.WithLabelValues("added")); got != float64(len(frames)) {
+		t.Fatal("incorrect added frames", got)
+	}
+
+	if got := testutil.ToFloat64(collector.frames.WithLabelValues("duplicate")); got != 1 {
+		t.Fatal("incorrect duplicate frames", got)
+	}
+
+	if got := testutil.ToFloat64(collector.failures); got != 1 {
+		t.Fatal("incorrect decode failures", got)
+	}
+
+	if got := testutil.ToFloat64(collector.completed); got != 1 {
+		t.Fatal("incorrect completed transmissions", got)
+	}
+
+	if count := testutil.CollectAndCount(collector, "test_airgap_payload_size_bytes"); count != 1 {
+		t.Fatal("payload size histogram is not collected")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package airgappb
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	airgap "github.com/censync/go-airgap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// airGapFile mirrors airgap.proto
+func airGapFile(t *testing.T) protoreflect.FileDescriptor {
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     kind.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+
+	operations := field("operations", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_LABEL_REPEATED)
+	operations.TypeName = proto.String(".goairgap.v1.Operation")
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("airgap.proto"),
+		Package: proto.String("goairgap.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Message"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("version", 1, descriptorpb.FieldDescriptorProto_TYPE_UINT32, optional),
+					field("instance_id", 2, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional),
+					operations,
+				},
+			},
+			{
+				Name: proto.String("Operation"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("op_code", 1, descriptorpb.FieldDescriptorProto_TYPE_UINT32, optional),
+					field("data", 2, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional),
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestMessage_WireCompatibility(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	airGap := airgap.NewAirGap(airgap.VersionDefault, instanceId).SetMessageFormat(airgap.MessageFormatProtobuf)
+
+	file := airGapFile(t)
+	messageType := file.Messages().ByName("Message")
+	operationType := file.Messages().ByName("Operation")
+
+	data, err := airGap.CreateMessage().
+		AddOperation(0x0102, []byte("payload")).
+		AddOperation(0, nil).
+		AddOperation(0xFFFF, bytes.Repeat([]byte{0xAB}, 300)).
+		Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := dynamicpb.NewMessage(messageType)
+	if err = proto.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Get(messageType.Fields().ByName("version")).Uint() != uint64(airgap.VersionDefault) ||
+		!bytes.Equal(decoded.Get(messageType.Fields().ByName("instance_id")).Bytes(), instanceId) {
+		t.Fatal("incorrect decoded message header")
+	}
+
+	operations := decoded.Get(messageType.Fields().ByName("operations")).List()
+	if operations.Len() != 3 {
+		t.Fatal("incorrect decoded operations count", operations.Len())
+	}
+
+	for i, expected := range []struct {
+		opCode uint64
+		data   []byte
+	}{{0x0102, []byte("payload")}, {0, nil}, {0xFFFF, bytes.Repeat([]byte{0xAB}, 300)}} {
+		operation := operations.Get(i).Message()
+		if operation.Get(operationType.Fields().ByName("op_code")).Uint() != expected.opCode ||
+			!bytes.Equal(operation.Get(operationType.Fields().ByName("data")).Bytes(), expected.data) {
+			t.Fatal("incorrect decoded operation", i)
+		}
+	}
+
+	// deterministic encoding of protobuf runtime matches marshaled message
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(encoded, data) {
+		t.Fatal("mismatch protobuf encoding")
+	}
+
+	// message encoded by protobuf runtime is unmarshaled
+	built := dynamicpb.NewMessage(messageType)
+	built.Set(messageType.Fields().ByName("version"), protoreflect.ValueOfUint32(uint32(airgap.VersionDefault)))
+	built.Set(messageType.Fields().ByName("instance_id"), protoreflect.ValueOfBytes(instanceId))
+
+	operation := dynamicpb.NewMessage(operationType)
+	operation.Set(operationType.Fields().ByName("op_code"), protoreflect.ValueOfUint32(0x0A0B))
+	operation.Set(operationType.Fields().ByName("data"), protoreflect.ValueOfBytes([]byte("built")))
+	built.Mutable(messageType.Fields().ByName("operations")).List().Append(protoreflect.ValueOfMessage(operation))
+
+	if data, err = proto.Marshal(built); err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := airGap.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(message.Operations) != 1 || message.Operations[0].OpCode != 0x0A0B ||
+		!bytes.Equal(message.Operations[0].Data, []byte("built")) {
+		t.Fatal("incorrect unmarshaled message")
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/censync/go-airgap/proto
+
+go 1.18
+
+require (
+	github.com/censync/go-airgap v0.1.0
+	google.golang.org/protobuf v1.28.1
+)
+
+require golang.org/x/crypto v0.7.0 // indirect
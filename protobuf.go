@@ -0,0 +1,187 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// field numbers of proto/airgap.proto
+const (
+	protoMessageVersion    = 1
+	protoMessageInstanceId = 2
+	protoMessageOperations = 3
+
+	protoOperationOpCode = 1
+	protoOperationData   = 2
+)
+
+// protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// marshalProtobuf serializes message with deterministic protobuf encoding,
+// fields are ordered by number and default values are omitted
+func (m *Message) marshalProtobuf(instanceId []byte) []byte {
+	var result []byte
+	if m.Version != 0 {
+		result = appendProtoVarint(result, protoMessageVersion, uint64(m.Version))
+	}
+	if len(instanceId) > 0 {
+		result = appendProtoBytes(result, protoMessageInstanceId, instanceId)
+	}
+
+	for _, op := range m.Operations {
+		payload := make([]byte, op.Size)
+		copy(payload, op.Data)
+
+		var operation []byte
+		if op.OpCode != 0 {
+			operation = appendProtoVarint(operation, protoOperationOpCode, uint64(op.OpCode))
+		}
+		if len(payload) > 0 {
+			operation = appendProtoBytes(operation, protoOperationData, payload)
+		}
+		result = appendProtoBytes(result, protoMessageOperations, operation)
+	}
+	return result
+}
+
+func (a *AirGap) unmarshalProtobuf(data []byte) (*Message, error) {
+	var (
+		version    uint64
+		instanceId []byte
+		operations [][]byte
+	)
+
+	err := readProtoFields(data, func(field int, value uint64, payload []byte) error {
+		switch field {
+		case protoMessageVersion:
+			version = value
+		case protoMessageInstanceId:
+			instanceId = payload
+		case protoMessageOperations:
+			operations = append(operations, payload)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if version > 0xFF {
+		return nil, errors.New("go-airgap message has incorrect version")
+	}
+
+	if err = a.verifyMessageHeader(uint8(version), instanceId); err != nil {
+		return nil, err
+	}
+
+	message := a.CreateMessage()
+
+	for _, operation := range operations {
+		var (
+			opCode  uint64
+			payload []byte
+		)
+
+		err = readProtoFields(operation, func(field int, value uint64, data []byte) error {
+			switch field {
+			case protoOperationOpCode:
+				opCode = value
+			case protoOperationData:
+				payload = data
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if opCode > 0xFFFF {
+			return nil, errors.New("go-airgap operation has incorrect code")
+		}
+
+		message.AddOperation(uint16(opCode), payload)
+	}
+
+	return message, nil
+}
+
+func appendProtoVarint(dst []byte, field int, value uint64) []byte {
+	dst = appendUvarint(dst, uint64(field)<<3|protoVarint)
+	return appendUvarint(dst, value)
+}
+
+func appendProtoBytes(dst []byte, field int, value []byte) []byte {
+	dst = appendUvarint(dst, uint64(field)<<3|protoBytes)
+	dst = appendUvarint(dst, uint64(len(value)))
+	return append(dst, value...)
+}
+
+// readProtoFields calls fn for every field of protobuf message, value is
+// defined for varint fields and payload for length-delimited fields. Fixed
+// size fields of unknown numbers are skipped.
+func readProtoFields(data []byte, fn func(field int, value uint64, payload []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 || key>>3 > 1<<29-1 {
+			return errors.New("go-airgap protobuf message has incorrect field")
+		}
+		data = data[n:]
+
+		var (
+			value   uint64
+			payload []byte
+		)
+
+		switch key & 7 {
+		case protoVarint:
+			if value, n = binary.Uvarint(data); n <= 0 {
+				return errors.New("go-airgap protobuf message is truncated")
+			}
+			data = data[n:]
+		case protoBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return errors.New("go-airgap protobuf message is truncated")
+			}
+			payload = data[n : n+int(size)]
+			data = data[n+int(size):]
+		case protoFixed64, protoFixed32:
+			size := 8
+			if key&7 == protoFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return errors.New("go-airgap protobuf message is truncated")
+			}
+			data = data[size:]
+			continue
+		default:
+			return errors.New("go-airgap protobuf message has unsupported wire type")
+		}
+
+		if err := fn(int(key>>3), value, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestAirGap_SetMessageFormatProtobuf(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	airGap := NewAirGap(VersionDefault, instanceId).SetMessageFormat(MessageFormatProtobuf)
+
+	data, err := airGap.CreateMessage().
+		AddOperation(0x0102, []byte("payload")).
+		AddOperation(0, nil).
+		Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// version: 1, instance_id, operations: [{op_code: 258, data: "payload"}, {}]
+	expected := "0801" + "1221" + hex.EncodeToString(instanceId) +
+		"1a0c" + "088202" + "1207" + hex.EncodeToString([]byte("payload")) +
+		"1a00"
+	if hex.EncodeToString(data) != expected {
+		t.Fatal("incorrect protobuf message", hex.EncodeToString(data))
+	}
+
+	message, err := airGap.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(message.Operations) != 2 || message.Operations[0].OpCode != 0x0102 ||
+		!bytes.Equal(message.Operations[0].Data, []byte("payload")) || message.Operations[1].OpCode != 0 {
+		t.Fatal("incorrect unmarshaled message")
+	}
+
+	// unknown fields are skipped
+	extended := append(append([]byte{}, data...), 0x20, 0x01, 0x2d, 1, 2, 3, 4, 0x32, 0x01, 0xff)
+	if message, err = airGap.Unmarshal(extended); err != nil || len(message.Operations) != 2 {
+		t.Fatal("message with unknown fields is rejected", err)
+	}
+
+	if _, err = airGap.Unmarshal(data[:len(data)-3]); err == nil {
+		t.Fatal("truncated message is accepted")
+	}
+}
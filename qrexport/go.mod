@@ -0,0 +1,10 @@
+module github.com/censync/go-airgap/qrexport
+
+go 1.18
+
+require (
+	github.com/censync/go-airgap v0.1.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+)
+
+require golang.org/x/crypto v0.7.0 // indirect
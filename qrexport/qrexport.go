@@ -0,0 +1,131 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package qrexport renders go-airgap frames to QR code images as data URIs
+// and self-contained HTML, which animates the transmission in any browser.
+package qrexport
+
+import (
+	"encoding/base64"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	defaultSize     = 320
+	defaultInterval = 200 * time.Millisecond
+)
+
+var htmlTemplate = template.Must(template.New("airgap").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>go-airgap transmission</title>
+<style>
+body { margin: 0; height: 100vh; display: flex; flex-direction: column; align-items: center; justify-content: center; background: #fff; font-family: sans-serif; }
+img { image-rendering: pixelated; width: {{.Size}}px; height: {{.Size}}px; }
+</style>
+</head>
+<body>
+<img id="frame" src="{{index .Frames 0}}" alt="go-airgap frame">
+<p id="counter">1 / {{len .Frames}}</p>
+<script>
+var frames = [{{range $i, $f := .Frames}}{{if $i}},{{end}}{{$f}}{{end}}];
+var current = 0;
+setInterval(function () {
+  current = (current + 1) % frames.length;
+  document.getElementById("frame").src = frames[current];
+  document.getElementById("counter").textContent = (current + 1) + " / " + frames.length;
+}, {{.Interval}});
+</script>
+</body>
+</html>
+`))
+
+// Exporter renders frames to PNG QR codes
+type Exporter struct {
+	size     int
+	interval time.Duration
+	level    qrcode.RecoveryLevel
+}
+
+func NewExporter() *Exporter {
+	return &Exporter{
+		size:     defaultSize,
+		interval: defaultInterval,
+		level:    qrcode.Medium,
+	}
+}
+
+// SetSize defines QR code image size in pixels
+func (e *Exporter) SetSize(size int) *Exporter {
+	e.size = size
+	return e
+}
+
+// SetInterval defines display time of every frame in HTML animation,
+// e.g. Profile.FrameInterval
+func (e *Exporter) SetInterval(interval time.Duration) *Exporter {
+	e.interval = interval
+	return e
+}
+
+// SetRecoveryLevel defines QR code error correction level
+func (e *Exporter) SetRecoveryLevel(level qrcode.RecoveryLevel) *Exporter {
+	e.level = level
+	return e
+}
+
+// DataURIs returns data URI with PNG QR code for every frame
+func (e *Exporter) DataURIs(frames []string) ([]string, error) {
+	result := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		png, err := qrcode.Encode(frame, e.level, e.size)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(png))
+	}
+	return result, nil
+}
+
+// WriteHTML writes self-contained HTML page, which loops frames animation
+func (e *Exporter) WriteHTML(w io.Writer, frames []string) error {
+	uris, err := e.DataURIs(frames)
+	if err != nil {
+		return err
+	}
+
+	images := make([]template.URL, len(uris))
+	for i := range uris {
+		images[i] = template.URL(uris[i])
+	}
+
+	if len(images) == 0 {
+		images = []template.URL{""}
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Size     int
+		Interval int64
+		Frames   []template.URL
+	}{
+		Size:     e.size,
+		Interval: e.interval.Milliseconds(),
+		Frames:   images,
+	})
+}
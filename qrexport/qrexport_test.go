@@ -0,0 +1,87 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrexport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+func TestExporter_DataURIs(t *testing.T) {
+	sender, err := airgap.NewChunks().SetData(bytes.Repeat([]byte("airgap"), 200), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeB64()
+
+	uris, err := NewExporter().SetSize(200).DataURIs(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(uris) != len(frames) {
+		t.Fatal("incorrect data uris count", len(uris))
+	}
+
+	for _, uri := range uris {
+		if !strings.HasPrefix(uri, "data:image/png;base64,") {
+			t.Fatal("incorrect data uri prefix")
+		}
+
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if img.Bounds().Dx() != 200 {
+			t.Fatal("incorrect image size", img.Bounds().Dx())
+		}
+	}
+}
+
+func TestExporter_WriteHTML(t *testing.T) {
+	frames := []string{"AG1:AAAA", "AG1:BBBB", "AG1:CCCC"}
+
+	var buf bytes.Buffer
+	if err := NewExporter().SetInterval(150*time.Millisecond).WriteHTML(&buf, frames); err != nil {
+		t.Fatal(err)
+	}
+
+	page := buf.String()
+
+	if strings.Count(page, "data:image/png;base64,") != len(frames)+1 {
+		t.Fatal("frames are not embedded")
+	}
+
+	if !strings.Contains(page, " 150 )") {
+		t.Fatal("interval is not applied")
+	}
+
+	if strings.Contains(page, "ZgotmplZ") {
+		t.Fatal("data uri is escaped")
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// SetRedundancy emits every chunk k times per loop. Copies are interleaved
+// across the loop, every pass is rotated, so receivers, which drop bursts of
+// consecutive frames, miss different chunks in every pass.
+func (ch *Chunks) SetRedundancy(k int) *Chunks {
+	ch.opts.redundancy = k
+	return ch
+}
+
+// SetRedundancy emits every chunk k times per loop, see Chunks.SetRedundancy
+func (a *AirGap) SetRedundancy(k int) *AirGap {
+	a.chunksOpts.redundancy = k
+	return a
+}
+
+// withRedundancy returns k rotated passes of frames
+func (o chunksOptions) withRedundancy(frames [][]byte) [][]byte {
+	if o.redundancy <= 1 || len(frames) == 0 {
+		return frames
+	}
+
+	result := make([][]byte, 0, len(frames)*o.redundancy)
+	for pass := 0; pass < o.redundancy; pass++ {
+		offset := pass * len(frames) / o.redundancy
+		result = append(result, frames[offset:]...)
+		result = append(result, frames[:offset]...)
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetRedundancy(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetRedundancy(3).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeRaw()
+	count := int(sender.Count())
+
+	if len(frames) != count*3 {
+		t.Fatal("incorrect frames count", len(frames))
+	}
+
+	for i := 1; i < len(frames); i++ {
+		if bytes.Equal(frames[i], frames[i-1]) {
+			t.Fatal("chunk copies are back-to-back", i)
+		}
+	}
+
+	// receiver misses a burst of the first pass
+	receiver := NewChunks()
+	for i, frame := range frames {
+		if i < count/2 {
+			continue
+		}
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"sync"
+)
+
+const (
+	// OpCodeRekeyRequest is standard operation with ephemeral public key of
+	// session key rotation initiator
+	OpCodeRekeyRequest uint16 = 0xFF09
+	// OpCodeRekeyResponse is standard operation with ephemeral public key of
+	// session key rotation responder
+	OpCodeRekeyResponse uint16 = 0xFF0A
+
+	pairingRekeyInfo = "go-airgap pairing rekey"
+)
+
+// SessionEncryptorDecryptor encrypts messages with the current session key,
+// messages of the previous key are decrypted until the next rotation, so
+// messages in flight survive key switch
+type SessionEncryptorDecryptor struct {
+	mu       sync.RWMutex
+	current  *AESGCMEncryptorDecryptor
+	previous *AESGCMEncryptorDecryptor
+}
+
+// NewSessionEncryptorDecryptor initiates session encryption with AES-GCM key
+func NewSessionEncryptorDecryptor(key []byte) (*SessionEncryptorDecryptor, error) {
+	current, err := NewAESGCMEncryptorDecryptor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionEncryptorDecryptor{current: current}, nil
+}
+
+// Rotate switches encryption to key, the current key is kept for decryption
+func (ed *SessionEncryptorDecryptor) Rotate(key []byte) error {
+	next, err := NewAESGCMEncryptorDecryptor(key)
+	if err != nil {
+		return err
+	}
+
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	ed.previous, ed.current = ed.current, next
+	return nil
+}
+
+func (ed *SessionEncryptorDecryptor) Encrypt(data []byte) ([]byte, error) {
+	return ed.EncryptAD(data, nil)
+}
+
+func (ed *SessionEncryptorDecryptor) Decrypt(data []byte) ([]byte, error) {
+	return ed.DecryptAD(data, nil)
+}
+
+func (ed *SessionEncryptorDecryptor) EncryptAD(data, additionalData []byte) ([]byte, error) {
+	return ed.encryptor().EncryptAD(data, additionalData)
+}
+
+func (ed *SessionEncryptorDecryptor) DecryptAD(data, additionalData []byte) ([]byte, error) {
+	ed.mu.RLock()
+	current, previous := ed.current, ed.previous
+	ed.mu.RUnlock()
+
+	result, err := current.DecryptAD(data, additionalData)
+	if err != nil && previous != nil {
+		return previous.DecryptAD(data, additionalData)
+	}
+	return result, err
+}
+
+// encryptor returns encryptor of the current key, which isn't affected by rotation
+func (ed *SessionEncryptorDecryptor) encryptor() *AESGCMEncryptorDecryptor {
+	ed.mu.RLock()
+	defer ed.mu.RUnlock()
+
+	return ed.current
+}
+
+// Rekey returns session key rotation request of initiator, message is
+// encrypted with the current session key. Rekey may be called again while
+// response isn't received, e.g. request is lost, the previous request is
+// discarded.
+func (p *Pairing) Rekey() (*Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateCompleted && p.state != PairingStateRekeying {
+		return nil, errors.New("go-airgap pairing is not completed")
+	}
+
+	priv, err := ecdsa.GenerateKey(p.priv.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	p.rekeyPriv = priv
+	p.state = PairingStateRekeying
+
+	return p.airGap.CreateMessage().AddOperation(OpCodeRekeyRequest, elliptic.MarshalCompressed(priv.Curve, priv.X, priv.Y)), nil
+}
+
+// CancelRekey discards requested rekey, the current session key is kept
+func (p *Pairing) CancelRekey() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateRekeying {
+		return errors.New("go-airgap rekey is not requested")
+	}
+
+	p.rekeyPriv = nil
+	p.state = PairingStateCompleted
+	return nil
+}
+
+// RespondRekey handles marshaled rekey request and returns response message,
+// which is encrypted with the current session key. Request must be encrypted
+// with the current session key, so replayed request of the previous key
+// doesn't rotate key again. Encryption switches to the new session key after
+// the response is created.
+func (p *Pairing) RespondRekey(data []byte) (*Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateCompleted {
+		return nil, errors.New("go-airgap pairing is not completed")
+	}
+
+	request, err := p.airGap.UnmarshalWithDecryptor(data, p.session.encryptor())
+	if err != nil {
+		return nil, err
+	}
+
+	peer, err := p.peerKey(request, OpCodeRekeyRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := ecdsa.GenerateKey(p.priv.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := elliptic.MarshalCompressed(priv.Curve, priv.X, priv.Y)
+
+	key, err := p.rekeyKey(priv, peer, peer, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	response := p.airGap.CreateMessage().AddOperation(OpCodeRekeyResponse, pub)
+	response.e = p.session.encryptor()
+
+	if err = p.rotate(key); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CompleteRekey handles rekey response and switches encryption to the new
+// session key
+func (p *Pairing) CompleteRekey(response *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != PairingStateRekeying {
+		return errors.New("go-airgap rekey is not requested")
+	}
+
+	peer, err := p.peerKey(response, OpCodeRekeyResponse)
+	if err != nil {
+		return err
+	}
+
+	key, err := p.rekeyKey(p.rekeyPriv, peer, elliptic.MarshalCompressed(p.rekeyPriv.Curve, p.rekeyPriv.X, p.rekeyPriv.Y), peer)
+	if err != nil {
+		return err
+	}
+
+	if err = p.rotate(key); err != nil {
+		return err
+	}
+
+	p.rekeyPriv = nil
+	p.state = PairingStateCompleted
+	return nil
+}
+
+// rekeyKey derives the next session key from ephemeral ECDH, chained with
+// the current session key
+func (p *Pairing) rekeyKey(priv *ecdsa.PrivateKey, peer, initiatorPub, responderPub []byte) ([]byte, error) {
+	peerKey, err := PublicKeyFromInstanceId(priv.Curve, peer)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := ecdsaECDHKey{priv}.SharedKey(peerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info := append([]byte(pairingRekeyInfo), initiatorPub...)
+	info = append(info, responderPub...)
+	return hkdfSHA256(shared, p.sessionKey, info, pairingKeySize), nil
+}
+
+func (p *Pairing) rotate(key []byte) error {
+	if err := p.session.Rotate(key); err != nil {
+		return err
+	}
+
+	p.sessionKey = key
+	return nil
+}
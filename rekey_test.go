@@ -0,0 +1,199 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestPairing_Rekey(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	initiatorAirGap := NewAirGap(VersionDefault, instanceId)
+	responderAirGap := NewAirGap(VersionDefault, instanceId)
+
+	// transfer marshals message and unmarshals it on the other side
+	transfer := func(message *Message, receiver *AirGap) (*Message, error) {
+		data, err := message.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return receiver.Unmarshal(data)
+	}
+
+	initiator, err := initiatorAirGap.NewPairing(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responder, err := responderAirGap.NewPairing(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := initiator.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received, err := transfer(request, responderAirGap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := responder.Respond(received)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received, err = transfer(response, initiatorAirGap); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = initiator.Complete(received); err != nil {
+		t.Fatal(err)
+	}
+
+	reveal, err := initiator.Reveal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received, err = transfer(reveal, responderAirGap); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = responder.Confirm(received); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionKey := append([]byte{}, initiator.sessionKey...)
+
+	// message of the current key is in flight during rotation
+	inFlight, err := initiatorAirGap.CreateMessage().AddOperation(opCodeTest1, []byte("in flight")).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := initiatorAirGap.CreateMessage().AddOperation(opCodeTest1, []byte("not rekey")).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = responder.RespondRekey(data); err == nil {
+		t.Fatal("incorrect rekey request is accepted")
+	}
+
+	if _, err = initiator.Rekey(); err != nil {
+		t.Fatal(err)
+	}
+
+	if initiator.State() != PairingStateRekeying {
+		t.Fatal("incorrect initiator state")
+	}
+
+	if err = initiator.CancelRekey(); err != nil || initiator.State() != PairingStateCompleted {
+		t.Fatal("rekey is not cancelled", err)
+	}
+
+	if err = initiator.CancelRekey(); err == nil {
+		t.Fatal("rekey is cancelled twice")
+	}
+
+	// lost request is retried with the new ephemeral key
+	if _, err = initiator.Rekey(); err != nil {
+		t.Fatal(err)
+	}
+
+	rekeyRequest, err := initiator.Rekey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requestData, err := rekeyRequest.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rekeyResponse, err := responder.RespondRekey(requestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(responder.sessionKey, sessionKey) {
+		t.Fatal("session key is not rotated")
+	}
+
+	// response is encrypted with the previous key, which initiator still uses
+	if received, err = transfer(rekeyResponse, initiatorAirGap); err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := responderAirGap.Unmarshal(inFlight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(message.Operations[0].Data, []byte("in flight")) {
+		t.Fatal("incorrect message in flight")
+	}
+
+	if err = initiator.CompleteRekey(received); err != nil {
+		t.Fatal(err)
+	}
+
+	if initiator.State() != PairingStateCompleted || !bytes.Equal(initiator.sessionKey, responder.sessionKey) {
+		t.Fatal("mismatch rotated session key")
+	}
+
+	if err = initiator.CompleteRekey(received); err == nil {
+		t.Fatal("rekey is completed twice")
+	}
+
+	// replayed request is encrypted with the previous key
+	if _, err = responder.RespondRekey(requestData); err == nil {
+		t.Fatal("replayed rekey request is accepted")
+	}
+
+	for _, pair := range [][2]*AirGap{{initiatorAirGap, responderAirGap}, {responderAirGap, initiatorAirGap}} {
+		message, err = transfer(pair[0].CreateMessage().AddOperation(opCodeTest1, []byte("rotated")), pair[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, []byte("rotated")) {
+			t.Fatal("incorrect message of rotated key")
+		}
+	}
+
+	stale, err := NewSessionEncryptorDecryptor(sessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = transfer(initiatorAirGap.CreateMessage().AddOperation(opCodeTest1, []byte("rotated")),
+		NewAirGap(VersionDefault, instanceId).SetEncryptorDecryptor(stale)); err == nil {
+		t.Fatal("message of rotated key is decrypted with previous key")
+	}
+}
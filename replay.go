@@ -0,0 +1,209 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	sequenceSize    = 8
+	replayEntrySize = compressedPubKeySize + sequenceSize
+)
+
+// ErrReplayed is returned by Unmarshal, when message sequence is already processed
+var ErrReplayed = errors.New("go-airgap message is already processed")
+
+var errReplayGuardUnauthenticated = errors.New("go-airgap replay guard requires decryptor or verifier")
+
+// ReplayGuard keeps the last processed sequence number of every instance
+type ReplayGuard interface {
+	// Accept checks that sequence is greater than the last processed sequence
+	// of instance and saves it, otherwise returns ErrReplayed
+	Accept(instanceId []byte, sequence uint64) error
+}
+
+// SetSequence enables monotonic sequence number of messages, which is
+// prepended to message body before encryption. Sequence of every marshaled
+// message is assigned starting at next, which must be persisted by sender, see
+// Sequence. Receiver must enable sequence numbers with SetReplayGuard.
+func (a *AirGap) SetSequence(next uint64) *AirGap {
+	if next == 0 {
+		next = 1
+	}
+	a.sequence = &next
+	return a
+}
+
+// Sequence returns sequence number of the next marshaled message, or 0 when
+// sequence numbers aren't enabled
+func (a *AirGap) Sequence() uint64 {
+	if a.sequence == nil {
+		return 0
+	}
+	return atomic.LoadUint64(a.sequence)
+}
+
+// SetReplayGuard enables sequence numbers of received messages, Unmarshal
+// returns ErrReplayed for already processed messages. Sequence is accepted only
+// from authenticated messages, so decryptor or verifier is required.
+func (a *AirGap) SetReplayGuard(guard ReplayGuard) *AirGap {
+	a.replayGuard = guard
+	return a
+}
+
+func (a *AirGap) isSequenced() bool {
+	return a.sequence != nil || a.replayGuard != nil
+}
+
+// appendSequence assigns message sequence once and prepends it to body
+func (m *Message) appendSequence(body []byte) []byte {
+	if m.Sequence == 0 {
+		m.Sequence = atomic.AddUint64(m.sequence, 1) - 1
+	}
+
+	result := make([]byte, sequenceSize, sequenceSize+len(body))
+	binary.BigEndian.PutUint64(result, m.Sequence)
+	return append(result, body...)
+}
+
+func trimSequence(data []byte) (uint64, []byte, error) {
+	if len(data) < sequenceSize {
+		return 0, nil, errors.New("go-airgap message to small")
+	}
+	return binary.BigEndian.Uint64(data), data[sequenceSize:], nil
+}
+
+// MemoryReplayGuard keeps sequence numbers in memory
+type MemoryReplayGuard struct {
+	mu        sync.Mutex
+	sequences map[string]uint64
+}
+
+func NewMemoryReplayGuard() *MemoryReplayGuard {
+	return &MemoryReplayGuard{sequences: make(map[string]uint64)}
+}
+
+func (g *MemoryReplayGuard) Accept(instanceId []byte, sequence uint64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if sequence <= g.sequences[string(instanceId)] {
+		return ErrReplayed
+	}
+
+	g.sequences[string(instanceId)] = sequence
+	return nil
+}
+
+// FileReplayGuard keeps sequence numbers in file, which is replaced atomically
+// on every accepted message, so processed messages stay rejected after restart.
+//
+// Serialized format:
+// [instance_id(33) + sequence(8)] * count
+type FileReplayGuard struct {
+	mu        sync.Mutex
+	path      string
+	sequences map[string]uint64
+}
+
+// NewFileReplayGuard loads sequence numbers from file at path, missing file
+// is created on the first accepted message
+func NewFileReplayGuard(path string) (*FileReplayGuard, error) {
+	g := &FileReplayGuard{path: path, sequences: make(map[string]uint64)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return g, nil
+		}
+		return nil, err
+	}
+
+	if len(data)%replayEntrySize != 0 {
+		return nil, errors.New("go-airgap replay guard file is corrupted")
+	}
+
+	for offset := 0; offset < len(data); offset += replayEntrySize {
+		instanceId := string(data[offset : offset+compressedPubKeySize])
+		g.sequences[instanceId] = binary.BigEndian.Uint64(data[offset+compressedPubKeySize:])
+	}
+
+	return g, nil
+}
+
+func (g *FileReplayGuard) Accept(instanceId []byte, sequence uint64) error {
+	if len(instanceId) != compressedPubKeySize {
+		return errors.New("incorrect instance pub key size")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	previous, ok := g.sequences[string(instanceId)]
+	if sequence <= previous {
+		return ErrReplayed
+	}
+
+	g.sequences[string(instanceId)] = sequence
+	if err := g.save(); err != nil {
+		if ok {
+			g.sequences[string(instanceId)] = previous
+		} else {
+			delete(g.sequences, string(instanceId))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// save writes sequences to temporary file and renames it to path
+func (g *FileReplayGuard) save() error {
+	data := make([]byte, 0, len(g.sequences)*replayEntrySize)
+	for instanceId, sequence := range g.sequences {
+		data = append(data, instanceId...)
+		var buf [sequenceSize]byte
+		binary.BigEndian.PutUint64(buf[:], sequence)
+		data = append(data, buf[:]...)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(g.path), filepath.Base(g.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), g.path)
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestAirGap_SetReplayGuard(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId := elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)
+
+	sender := NewAirGap(VersionDefault, instanceId).
+		SetEncryptorDecryptor(NewDummyEncryptorDecryptor()).
+		SetSequence(10)
+
+	path := filepath.Join(t.TempDir(), "sequences")
+
+	var messages [][]byte
+	for i := 0; i < 3; i++ {
+		message := sender.CreateMessage().AddOperation(opCodeTest1, []byte("sign transaction"))
+
+		data, err := message.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if message.Sequence != uint64(10+i) {
+			t.Fatal("incorrect message sequence", message.Sequence)
+		}
+
+		// sequence is assigned once
+		again, err := message.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = NewAirGap(VersionDefault, instanceId).
+			SetEncryptorDecryptor(NewDummyEncryptorDecryptor()).
+			SetReplayGuard(NewMemoryReplayGuard()).
+			Unmarshal(again); err != nil {
+			t.Fatal(err)
+		}
+
+		messages = append(messages, data)
+	}
+
+	if sender.Sequence() != 13 {
+		t.Fatal("incorrect next sequence", sender.Sequence())
+	}
+
+	for _, newGuard := range []func() (ReplayGuard, error){
+		func() (ReplayGuard, error) { return NewMemoryReplayGuard(), nil },
+		func() (ReplayGuard, error) { return NewFileReplayGuard(path) },
+	} {
+		guard, err := newGuard()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewAirGap(VersionDefault, instanceId).
+			SetEncryptorDecryptor(NewDummyEncryptorDecryptor()).
+			SetReplayGuard(guard)
+
+		message, err := receiver.Unmarshal(messages[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if message.Sequence != 11 || !bytes.Equal(message.Operations[0].Data, []byte("sign transaction")) {
+			t.Fatal("incorrect received message")
+		}
+
+		for _, data := range messages[:2] {
+			if _, err = receiver.Unmarshal(data); err != ErrReplayed {
+				t.Fatal("replayed message is accepted", err)
+			}
+		}
+
+		if _, err = receiver.Unmarshal(messages[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// forged sequence of unauthenticated message must not block the guard
+	forged, err := NewAirGap(VersionDefault, instanceId).SetSequence(1<<64-1).
+		CreateMessage().AddOperation(opCodeTest1, []byte("forged")).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewAirGap(VersionDefault, instanceId).SetReplayGuard(NewMemoryReplayGuard()).Unmarshal(forged); err == nil {
+		t.Fatal("unauthenticated sequence is accepted")
+	}
+
+	// file guard keeps sequences after restart
+	guard, err := NewFileReplayGuard(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = guard.Accept(instanceId, 12); err != ErrReplayed {
+		t.Fatal("replayed sequence is accepted after restart", err)
+	}
+
+	if err = guard.Accept(instanceId, 13); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// Reset clears received chunks, so receiver can be reused for the new transfer.
+// Options, storage, observer and callbacks are kept.
+func (ch *Chunks) Reset() error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.storage != nil {
+		if err := ch.storage.Reset(0, 0); err != nil {
+			return err
+		}
+	}
+
+	ch.count = 0
+	ch.size = 0
+	ch.filled = 0
+	ch.received = 0
+	ch.parityFrames = nil
+	ch.fountain = nil
+	ch.ur = nil
+	ch.transferId = nil
+	ch.openedMessageId = nil
+	ch.ingest = nil
+	ch.stats = ReceiverStats{}
+	if !ch.merkleTrusted {
+		ch.merkleRoot = nil
+	}
+
+	return nil
+}
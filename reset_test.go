@@ -0,0 +1,53 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_Reset(t *testing.T) {
+	receiver := NewChunks().SetTransferId(true)
+
+	for i := 0; i < 2; i++ {
+		payload := make([]byte, 1000+i*2000)
+		_, _ = rand.Read(payload)
+
+		sender, err := NewChunks().SetTransferId(true).SetData(payload, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, frame := range sender.SerializeRaw() {
+			if _, err = receiver.ReadRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !receiver.Complete() || !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload", i)
+		}
+
+		if err = receiver.Reset(); err != nil {
+			t.Fatal(err)
+		}
+
+		if receiver.Complete() || receiver.Count() != 0 || receiver.TransferId() != nil {
+			t.Fatal("receiver is not reset")
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+)
+
+const (
+	// FrameErrorRateThreshold is frame error rate reported by receiver,
+	// which makes sender to drop chunk size
+	FrameErrorRateThreshold = 0.2
+
+	minAdaptiveChunkSize = 64
+)
+
+// Resize splits the same compressed payload to chunks with another chunk size.
+// Receiver keeps already received bytes and continues transfer seamlessly.
+// Resized chunks keep transfer id, both sides must enable it, see SetTransferId.
+func (ch *Chunks) Resize(chunkSize int) (*Chunks, error) {
+	ch.mu.RLock()
+	var compressedData []byte
+	for index := 0; index < int(ch.count); index++ {
+		data, err := ch.storage.Get(index)
+		if err != nil || data == nil {
+			ch.mu.RUnlock()
+			return nil, errors.New("cannot resize incomplete chunks")
+		}
+		compressedData = append(compressedData, data...)
+	}
+	ch.mu.RUnlock()
+
+	if chunkSize <= ch.opts.frameOverhead() {
+		return nil, errors.New("min chunk size 32")
+	}
+
+	if chunkSize > 1<<16-chunkHeaderOffset {
+		return nil, errors.New("max chunk size 65531")
+	}
+
+	chunkSize = ch.opts.chunkPayloadSize(len(compressedData), chunkSize)
+
+	if chunkSize <= 0 {
+		return nil, errors.New("min chunk size 32")
+	}
+
+	data := splitChunks(compressedData, chunkSize)
+
+	if err := ch.opts.verifyChunksCount(len(data)); err != nil {
+		return nil, err
+	}
+
+	// frames of another chunk size get another message id, so nonces aren't reused
+	frameCipher, messageId, err := ch.opts.newFrameCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Chunks{
+		count:      uint32(len(data)),
+		filled:     uint32(len(data)),
+		size:       uint16(chunkSize),
+		opts:       ch.opts,
+		storage:    &memoryStorage{data: data},
+		transferId: ch.transferId,
+
+		frameCipher: frameCipher,
+		messageId:   messageId,
+	}
+
+	if err := result.buildMerkleTree(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AdaptiveChunkSize returns chunk size for frame error rate reported by receiver,
+// chunk size is halved when error rate exceeds FrameErrorRateThreshold
+func AdaptiveChunkSize(chunkSize int, frameErrorRate float64) int {
+	if frameErrorRate <= FrameErrorRateThreshold || chunkSize/2 < minAdaptiveChunkSize {
+		return chunkSize
+	}
+	return chunkSize / 2
+}
+
+// isResized returns true for frame of the same transfer with another chunk
+// size, frames without transfer id can't be told from frames of another transfer
+func (ch *Chunks) isResized(count uint32, capacity uint16) bool {
+	return ch.opts.transferId && ch.count != 0 && count != ch.count && capacity != ch.size
+}
+
+// resize migrates received chunks to the new chunk size, chunks of the new size,
+// which bytes are already received, are filled
+func (ch *Chunks) resize(count uint32, size uint16) error {
+	oldSize := int(ch.size)
+
+	received := make([][]byte, ch.count)
+	for index := range received {
+		chunk, err := ch.storage.Get(index)
+		if err != nil {
+			return err
+		}
+		received[index] = chunk
+	}
+
+	// payload size is known only when the last chunk is received
+	payloadSize := -1
+	if ch.count > 0 && received[ch.count-1] != nil {
+		payloadSize = (int(ch.count)-1)*oldSize + len(received[ch.count-1])
+	}
+
+	data := make([][]byte, count)
+
+	for index := 0; index < int(count); index++ {
+		start := index * int(size)
+		end := start + int(size)
+
+		if index == int(count)-1 {
+			if payloadSize < 0 {
+				continue
+			}
+			end = payloadSize
+		}
+
+		if start >= end {
+			continue
+		}
+
+		buf := make([]byte, end-start)
+		isReceived := true
+		for offset := start; offset < end; {
+			oldIndex := offset / oldSize
+			if oldIndex >= int(ch.count) || received[oldIndex] == nil || offset-oldIndex*oldSize >= len(received[oldIndex]) {
+				isReceived = false
+				break
+			}
+			offset += copy(buf[offset-start:], received[oldIndex][offset-oldIndex*oldSize:])
+		}
+
+		if isReceived {
+			data[index] = buf
+		}
+	}
+
+	if err := ch.storage.Reset(int(count), int(size)); err != nil {
+		return err
+	}
+
+	ch.count = count
+	ch.size = size
+	ch.filled = 0
+	ch.received = 0
+	ch.parityFrames = nil
+	ch.fountain = nil
+	if !ch.merkleTrusted {
+		ch.merkleRoot = nil
+	}
+
+	for index := range data {
+		if data[index] == nil {
+			continue
+		}
+
+		if err := ch.storage.Put(index, data[index]); err != nil {
+			return err
+		}
+		ch.filled++
+		ch.received += len(data[index])
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	payload := make([]byte, 4096)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	chunks, err := NewChunks().SetTransferId(true).SetData(payload, defaultChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readedChunks := NewChunks().SetTransferId(true)
+
+	// receiver misses every third frame
+	for i, frame := range chunks.SerializeRaw() {
+		if i%3 == 0 {
+			continue
+		}
+		if _, err = readedChunks.AddRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chunkSize := AdaptiveChunkSize(defaultChunkSize, 0.33)
+	if chunkSize != defaultChunkSize/2 {
+		t.Fatal("chunk size is not dropped")
+	}
+
+	if AdaptiveChunkSize(defaultChunkSize, 0.01) != defaultChunkSize {
+		t.Fatal("chunk size is dropped for low error rate")
+	}
+
+	resizedChunks, err := chunks.Resize(chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := resizedChunks.SerializeRaw()
+
+	added := 0
+	for i := range frames {
+		wasAdded, err := readedChunks.AddRawChunk(frames[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wasAdded {
+			added++
+		}
+	}
+
+	if added >= len(frames) {
+		t.Fatal("received bytes are not kept")
+	}
+
+	if !readedChunks.IsFilled() {
+		t.Fatal("chunks are not filled")
+	}
+
+	if !reflect.DeepEqual(payload, readedChunks.Data()) {
+		t.Fatal("mismatch marshalled data")
+	}
+
+	// frames of another chunk size without transfer id aren't treated as resized transfer
+	chunks, err = NewChunks().SetData(payload, defaultChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resizedChunks, err = chunks.Resize(chunkSize); err != nil {
+		t.Fatal(err)
+	}
+
+	readedChunks = NewChunks()
+	if _, err = readedChunks.AddRawChunk(chunks.SerializeRaw()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readedChunks.AddRawChunk(resizedChunks.SerializeRaw()[1])
+	if frameErr, ok := err.(*FrameError); !ok || frameErr.Check != FrameCheckCount {
+		t.Fatal("frame of another chunk size is accepted", err)
+	}
+}
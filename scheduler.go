@@ -0,0 +1,87 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+// FrameScheduler emits frames in interleaved order, which is rotated on every
+// loop, so receiver, which missed frame early, catches it sooner on subsequent
+// loops than with sequential looped display
+type FrameScheduler struct {
+	frames []string
+	// stride is step of interleaving, which is coprime with frames count
+	stride int
+	// shift is rotation of every loop
+	shift    int
+	loop     int
+	position int
+}
+
+// NewFrameScheduler initiates scheduler of serialized frames, e.g. SerializeB64
+func NewFrameScheduler(frames []string) *FrameScheduler {
+	return &FrameScheduler{
+		frames: frames,
+		stride: schedulerStride(len(frames)),
+		shift:  schedulerPrime(len(frames)/3, len(frames)),
+	}
+}
+
+// Next returns frame to display, frames are emitted endlessly
+func (s *FrameScheduler) Next() string {
+	if len(s.frames) == 0 {
+		return ""
+	}
+
+	index := (s.position*s.stride + s.loop*s.shift) % len(s.frames)
+
+	s.position++
+	if s.position == len(s.frames) {
+		s.position = 0
+		s.loop++
+	}
+
+	return s.frames[index]
+}
+
+// Loop returns count of completed loops
+func (s *FrameScheduler) Loop() int {
+	return s.loop
+}
+
+// schedulerStride returns step, which places neighbour frames far apart
+func schedulerStride(count int) int {
+	return schedulerPrime(count/2, count)
+}
+
+// schedulerPrime returns the min prime, which is greater than min and coprime
+// with count, or 1 for small count
+func schedulerPrime(min, count int) int {
+	for candidate := min + 1; candidate < count; candidate++ {
+		if isPrime(candidate) && count%candidate != 0 {
+			return candidate
+		}
+	}
+	return 1
+}
+
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for i := 2; i*i <= n; i++ {
+		if n%i == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestFrameScheduler(t *testing.T) {
+	frames := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	scheduler := NewFrameScheduler(frames)
+
+	var previous []string
+	for loop := 0; loop < 3; loop++ {
+		if scheduler.Loop() != loop {
+			t.Fatal("incorrect loop", scheduler.Loop())
+		}
+
+		var emitted []string
+		for range frames {
+			emitted = append(emitted, scheduler.Next())
+		}
+
+		if emitted[0] == emitted[1] || (previous != nil && emitted[0] == previous[0]) {
+			t.Fatal("frames are not interleaved", emitted)
+		}
+		previous = append([]string{}, emitted...)
+
+		// every loop emits all frames
+		sort.Strings(emitted)
+		for i := range frames {
+			if emitted[i] != frames[i] {
+				t.Fatal("incorrect loop frames", emitted)
+			}
+		}
+	}
+
+	if NewFrameScheduler(nil).Next() != "" {
+		t.Fatal("incorrect empty scheduler")
+	}
+}
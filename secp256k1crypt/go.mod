@@ -0,0 +1,9 @@
+module github.com/censync/go-airgap/secp256k1crypt
+
+go 1.18
+
+require (
+	github.com/censync/go-airgap v0.1.0
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
+	golang.org/x/crypto v0.7.0
+)
@@ -0,0 +1,130 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secp256k1crypt wraps go-airgap payload keys with ECIES on secp256k1,
+// so instances with Bitcoin-style keys encrypt messages to paired instance ids,
+// see go_airgap.HybridEncryptorDecryptor
+package secp256k1crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	go_airgap "github.com/censync/go-airgap"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	keyWrapInfo = "go-airgap secp256k1 ecies key wrap"
+	kekSize     = 32
+)
+
+// Recipient wraps payload keys to the recipient secp256k1 public key with
+// ephemeral ECDH, HKDF-SHA256 and AES-256-GCM
+type Recipient struct {
+	pub *secp256k1.PublicKey
+}
+
+// Identity unwraps payload keys wrapped with Recipient
+type Identity struct {
+	priv *secp256k1.PrivateKey
+}
+
+func NewRecipient(pub *secp256k1.PublicKey) *Recipient {
+	return &Recipient{pub: pub}
+}
+
+func NewIdentity(priv *secp256k1.PrivateKey) *Identity {
+	return &Identity{priv: priv}
+}
+
+// NewEncryptorDecryptor initiates ECIES encryption to the paired instances,
+// peerInstanceIds are compressed secp256k1 public keys, see
+// go_airgap.NewECIESEncryptorDecryptor
+func NewEncryptorDecryptor(priv *secp256k1.PrivateKey, peerInstanceIds ...[]byte) (*go_airgap.HybridEncryptorDecryptor, error) {
+	if len(peerInstanceIds) == 0 || len(peerInstanceIds) > 255 {
+		return nil, errors.New("secp256k1 ecies requires 1-255 peer instance ids")
+	}
+
+	recipients := make([]go_airgap.KeyWrapper, len(peerInstanceIds))
+	for i := range peerInstanceIds {
+		peer, err := secp256k1.ParsePubKey(peerInstanceIds[i])
+		if err != nil {
+			return nil, err
+		}
+		recipients[i] = NewRecipient(peer)
+	}
+	return go_airgap.NewHybridEncryptorDecryptor(NewIdentity(priv), recipients...), nil
+}
+
+// WrapKey returns ephemeral_pub_key || AES-GCM(kek, payload_key)
+func (r *Recipient) WrapKey(payloadKey []byte) ([]byte, error) {
+	ephemeral, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	defer ephemeral.Zero()
+
+	ephemeralPub := ephemeral.PubKey().SerializeCompressed()
+
+	aead, err := keyWrapAEAD(secp256k1.GenerateSharedSecret(ephemeral, r.pub), ephemeralPub, r.pub.SerializeCompressed())
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(ephemeralPub, make([]byte, aead.NonceSize()), payloadKey, nil), nil
+}
+
+func (id *Identity) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) < secp256k1.PubKeyBytesLenCompressed {
+		return nil, errors.New("secp256k1 ecies wrapped key to small")
+	}
+
+	ephemeralPub := wrappedKey[:secp256k1.PubKeyBytesLenCompressed]
+
+	ephemeral, err := secp256k1.ParsePubKey(ephemeralPub)
+	if err != nil {
+		return nil, errors.New("secp256k1 ecies wrapped key has incorrect ephemeral key")
+	}
+
+	aead, err := keyWrapAEAD(secp256k1.GenerateSharedSecret(id.priv, ephemeral), ephemeralPub, id.priv.PubKey().SerializeCompressed())
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, make([]byte, aead.NonceSize()), wrappedKey[len(ephemeralPub):], nil)
+}
+
+// keyWrapAEAD derives key encryption key from ECDH shared secret, bound to
+// both ephemeral and recipient public keys. Every KEK is unique, so zero nonce is used.
+func keyWrapAEAD(shared, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	info := append([]byte(keyWrapInfo), ephemeralPub...)
+	info = append(info, recipientPub...)
+
+	kek := make([]byte, kekSize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, info), kek); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
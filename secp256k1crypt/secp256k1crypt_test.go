@@ -0,0 +1,94 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secp256k1crypt
+
+import (
+	"bytes"
+	"testing"
+
+	go_airgap "github.com/censync/go-airgap"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+func TestNewEncryptorDecryptor(t *testing.T) {
+	senderKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiverKey, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	senderId, err := go_airgap.InstanceIdFromPublicKey(senderKey.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiverId, err := go_airgap.InstanceIdFromPublicKey(receiverKey.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	senderED, err := NewEncryptorDecryptor(senderKey, receiverId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiverED, err := NewEncryptorDecryptor(receiverKey, senderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := go_airgap.NewAirGap(go_airgap.VersionDefault, receiverId).SetEncryptorDecryptor(senderED)
+	receiver := go_airgap.NewAirGap(go_airgap.VersionDefault, receiverId).SetEncryptorDecryptor(receiverED)
+
+	data, err := sender.CreateMessage().AddOperation(0x0101, []byte("secret message")).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := receiver.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(message.Operations[0].Data, []byte("secret message")) {
+		t.Fatal("mismatch decrypted data")
+	}
+
+	if _, err = senderED.Decrypt(data); err == nil {
+		t.Fatal("message decrypted by sender")
+	}
+
+	if _, err = NewEncryptorDecryptor(senderKey, make([]byte, secp256k1.PubKeyBytesLenCompressed)); err == nil {
+		t.Fatal("incorrect instance id is accepted")
+	}
+
+	wrapped, err := NewRecipient(receiverKey.PubKey()).WrapKey(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped[len(wrapped)-1] ^= 1
+	if _, err = NewIdentity(receiverKey).UnwrapKey(wrapped); err == nil {
+		t.Fatal("modified wrapped key is accepted")
+	}
+
+	if _, err = NewIdentity(receiverKey).UnwrapKey(wrapped[:10]); err == nil {
+		t.Fatal("truncated wrapped key is accepted")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+)
+
+// SetShuffle emits frames in deterministic pseudo-random order derived from
+// transfer id, or from payload, when transfer id is disabled. Burst frame losses
+// are spread over the whole payload, so parity frames recover more chunks.
+func (ch *Chunks) SetShuffle(enabled bool) *Chunks {
+	ch.opts.shuffle = enabled
+	return ch
+}
+
+// SetShuffle emits frames in deterministic pseudo-random order, see Chunks.SetShuffle
+func (a *AirGap) SetShuffle(enabled bool) *AirGap {
+	a.chunksOpts.shuffle = enabled
+	return a
+}
+
+// withShuffle returns frames in pseudo-random order, when enabled
+func (ch *Chunks) withShuffle(frames [][]byte) [][]byte {
+	if !ch.opts.shuffle || len(frames) < 2 {
+		return frames
+	}
+
+	rand.New(rand.NewSource(ch.shuffleSeed())).Shuffle(len(frames), func(i, j int) {
+		frames[i], frames[j] = frames[j], frames[i]
+	})
+	return frames
+}
+
+// shuffleSeed returns seed of frames order
+func (ch *Chunks) shuffleSeed() int64 {
+	if ch.transferId != nil {
+		return int64(binary.BigEndian.Uint32(ch.transferId))
+	}
+
+	chunk, _ := ch.storage.Get(0)
+	digest := sha256.Sum256(chunk)
+	return int64(binary.BigEndian.Uint64(digest[:]))
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestChunks_SetShuffle(t *testing.T) {
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetShuffle(true).SetParity(4).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeRaw()
+	if !reflect.DeepEqual(frames, sender.SerializeRaw()) {
+		t.Fatal("frames order is not deterministic")
+	}
+
+	if bytes.Equal(frames[0], sender.getChunkWithHeader(0)) && bytes.Equal(frames[1], sender.getChunkWithHeader(1)) {
+		t.Fatal("frames are not shuffled")
+	}
+
+	receiver := NewChunks().SetParity(4)
+	for _, frame := range frames {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
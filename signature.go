@@ -0,0 +1,245 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// OpCodeSignedOperation is standard operation with detached signature of
+	// wrapped operation and instance id of its author
+	OpCodeSignedOperation uint16 = 0xFF08
+
+	maxSignatureSize = 255
+
+	operationSignatureInfo = "go-airgap operation"
+)
+
+// VerifierLookup returns Verifier of operation author by instance id
+type VerifierLookup func(signerId []byte) (Verifier, bool)
+
+// ErrSignature is returned by Unmarshal, when message signature is missing or invalid
+var ErrSignature = errors.New("go-airgap message signature verification failed")
+
+// ECDSASigner implements Signer, data is hashed with SHA-256 and signed
+// in ASN.1 DER format
+type ECDSASigner struct {
+	priv *ecdsa.PrivateKey
+}
+
+// Ed25519Signer implements Signer with ed25519 signatures
+type Ed25519Signer struct {
+	priv ed25519.PrivateKey
+}
+
+// ECDSAVerifier implements Verifier for signatures of ECDSASigner
+type ECDSAVerifier struct {
+	pub *ecdsa.PublicKey
+}
+
+// Ed25519Verifier implements Verifier for signatures of Ed25519Signer
+type Ed25519Verifier struct {
+	pub ed25519.PublicKey
+}
+
+func NewECDSASigner(priv *ecdsa.PrivateKey) *ECDSASigner {
+	return &ECDSASigner{priv: priv}
+}
+
+func (s *ECDSASigner) Sign(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	return ecdsa.SignASN1(rand.Reader, s.priv, digest[:])
+}
+
+func NewEd25519Signer(priv ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{priv: priv}
+}
+
+func (s *Ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.priv, data), nil
+}
+
+func NewECDSAVerifier(pub *ecdsa.PublicKey) *ECDSAVerifier {
+	return &ECDSAVerifier{pub: pub}
+}
+
+func (v *ECDSAVerifier) Verify(data, signature []byte) error {
+	digest := sha256.Sum256(data)
+	if !ecdsa.VerifyASN1(v.pub, digest[:], signature) {
+		return ErrSignature
+	}
+	return nil
+}
+
+func NewEd25519Verifier(pub ed25519.PublicKey) *Ed25519Verifier {
+	return &Ed25519Verifier{pub: pub}
+}
+
+func (v *Ed25519Verifier) Verify(data, signature []byte) error {
+	if len(v.pub) != ed25519.PublicKeySize || !ed25519.Verify(v.pub, data, signature) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// NewInstanceVerifier returns Verifier of the paired instance key, instance id
+// with InstanceTypeEd25519 prefix is ed25519 key, otherwise compressed EC key
+// on curve, see InstanceIdFromPublicKey
+func NewInstanceVerifier(curve elliptic.Curve, instanceId []byte) (Verifier, error) {
+	if len(instanceId) == compressedPubKeySize && instanceId[0] == InstanceTypeEd25519 {
+		return NewEd25519Verifier(append(ed25519.PublicKey{}, instanceId[1:]...)), nil
+	}
+
+	pub, err := PublicKeyFromInstanceId(curve, instanceId)
+	if err != nil {
+		return nil, err
+	}
+	return NewECDSAVerifier(pub), nil
+}
+
+// SetSigner enables signing of marshaled messages, signature is appended as
+// trailer: signature + signature_size(1)
+func (a *AirGap) SetSigner(signer Signer) *AirGap {
+	a.signer = signer
+	return a
+}
+
+// SetVerifier requires marshaled messages signed by Signer of the paired
+// instance, e.g. NewInstanceVerifier
+func (a *AirGap) SetVerifier(verifier Verifier) *AirGap {
+	a.verifier = verifier
+	return a
+}
+
+// AddSignedOperation adds OpCodeSignedOperation operation with operation
+// signed by author with signerId instance id, so message may contain operations
+// of several authors and unsigned operations, see Message.VerifyOperations.
+//
+// Serialized format:
+// op_code(2) + signer_id(33) + signature_size(1) + signature + data
+func (m *Message) AddSignedOperation(opCode uint16, data []byte, signerId []byte, signer Signer) (*Message, error) {
+	if len(signerId) != compressedPubKeySize {
+		return nil, errors.New("incorrect instance pub key size")
+	}
+
+	signature, err := signer.Sign(operationSignedData(opCode, data))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signature) == 0 || len(signature) > maxSignatureSize {
+		return nil, errors.New("go-airgap operation signature has incorrect size")
+	}
+
+	payload := make([]byte, 2, 2+compressedPubKeySize+1+len(signature)+len(data))
+	binary.BigEndian.PutUint16(payload, opCode)
+	payload = append(payload, signerId...)
+	payload = append(payload, byte(len(signature)))
+	payload = append(payload, signature...)
+	payload = append(payload, data...)
+
+	return m.AddOperation(OpCodeSignedOperation, payload), nil
+}
+
+// VerifyOperations replaces OpCodeSignedOperation operations with verified
+// operations, SignerId of which is instance id of the author. Verifiers are
+// requested with lookup, ErrSignature is returned for unknown authors or
+// invalid signatures.
+func (m *Message) VerifyOperations(lookup VerifierLookup) error {
+	for i, op := range m.Operations {
+		if op.OpCode != OpCodeSignedOperation {
+			continue
+		}
+
+		offset := 2 + compressedPubKeySize + 1
+		if len(op.Data) < offset || len(op.Data) < offset+int(op.Data[offset-1]) {
+			return errors.New("go-airgap signed operation is corrupted")
+		}
+
+		opCode := binary.BigEndian.Uint16(op.Data)
+		signerId := op.Data[2 : 2+compressedPubKeySize]
+		signature := op.Data[offset : offset+int(op.Data[offset-1])]
+		data := op.Data[offset+len(signature):]
+
+		verifier, ok := lookup(signerId)
+		if !ok {
+			return ErrSignature
+		}
+
+		if err := verifier.Verify(operationSignedData(opCode, data), signature); err != nil {
+			return ErrSignature
+		}
+
+		m.Operations[i] = &Operation{
+			OpCode:   opCode,
+			Size:     uint32(len(data)),
+			Data:     data,
+			SignerId: append([]byte{}, signerId...),
+		}
+		m.cached = nil
+	}
+	return nil
+}
+
+// operationSignedData returns signed representation of operation
+func operationSignedData(opCode uint16, data []byte) []byte {
+	result := make([]byte, 0, len(operationSignatureInfo)+2+len(data))
+	result = append(result, operationSignatureInfo...)
+	result = append(result, byte(opCode>>8), byte(opCode))
+	return append(result, data...)
+}
+
+// appendSignature signs data and appends signature trailer
+func appendSignature(signer Signer, data []byte) ([]byte, error) {
+	signature, err := signer.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signature) == 0 || len(signature) > maxSignatureSize {
+		return nil, errors.New("go-airgap message signature has incorrect size")
+	}
+
+	result := make([]byte, 0, len(data)+len(signature)+1)
+	result = append(result, data...)
+	result = append(result, signature...)
+	return append(result, byte(len(signature))), nil
+}
+
+// trimSignature verifies and removes signature trailer
+func (a *AirGap) trimSignature(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrSignature
+	}
+
+	size := int(data[len(data)-1])
+	if size == 0 || size > len(data)-1 {
+		return nil, ErrSignature
+	}
+
+	signed := data[:len(data)-1-size]
+	if err := a.verifier.Verify(signed, data[len(signed):len(data)-1]); err != nil {
+		return nil, ErrSignature
+	}
+
+	return signed, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAirGap_SetSigner(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edInstanceId, err := InstanceIdFromPublicKey(edPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		instanceId []byte
+		signer     Signer
+	}{
+		{elliptic.MarshalCompressed(elliptic.P256(), ecKey.X, ecKey.Y), NewECDSASigner(ecKey)},
+		{edInstanceId, NewEd25519Signer(edKey)},
+	}
+
+	payload := []byte(`{"key": "signed message"}`)
+
+	for _, c := range cases {
+		verifier, err := NewInstanceVerifier(elliptic.P256(), c.instanceId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sender := NewAirGap(VersionDefault, c.instanceId).SetSigner(c.signer).SetMagic(true)
+		receiver := NewAirGap(VersionDefault, c.instanceId).SetVerifier(verifier).SetMagic(true)
+
+		data, err := sender.CreateMessage().AddOperation(opCodeTest1, payload).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message, err := receiver.Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, payload) {
+			t.Fatal("incorrect received payload")
+		}
+
+		tampered := append([]byte{}, data...)
+		tampered[len(MessageMagic)+airGapMessagesOffset+operationPayloadOffset] ^= 1
+		if _, err = receiver.Unmarshal(tampered); err != ErrSignature {
+			t.Fatal("tampered message is accepted", err)
+		}
+
+		unsigned, err := NewAirGap(VersionDefault, c.instanceId).SetMagic(true).CreateMessage().AddOperation(opCodeTest1, payload).Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = receiver.Unmarshal(unsigned); err != ErrSignature {
+			t.Fatal("unsigned message is accepted", err)
+		}
+	}
+
+	stranger, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewAirGap(VersionDefault, cases[0].instanceId).SetSigner(NewECDSASigner(stranger)).
+		CreateMessage().AddOperation(opCodeTest1, payload).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewAirGap(VersionDefault, cases[0].instanceId).SetVerifier(NewECDSAVerifier(&ecKey.PublicKey)).Unmarshal(data); err != ErrSignature {
+		t.Fatal("message signed by stranger is accepted", err)
+	}
+}
+
+func TestMessage_VerifyOperations(t *testing.T) {
+	instanceKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), instanceKey.X, instanceKey.Y))
+
+	verifiers := map[string]Verifier{}
+	var (
+		signerIds [][]byte
+		signers   []Signer
+	)
+
+	for i := 0; i < 2; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signerId := elliptic.MarshalCompressed(elliptic.P256(), key.X, key.Y)
+		signerIds = append(signerIds, signerId)
+		signers = append(signers, NewECDSASigner(key))
+		verifiers[string(signerId)] = NewECDSAVerifier(&key.PublicKey)
+	}
+
+	lookup := func(signerId []byte) (Verifier, bool) {
+		verifier, ok := verifiers[string(signerId)]
+		return verifier, ok
+	}
+
+	message := airGap.CreateMessage().AddOperation(opCodeTest1, []byte("unsigned"))
+
+	if _, err = message.AddSignedOperation(opCodeTest2, []byte("first device"), signerIds[0], signers[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = message.AddSignedOperation(opCodeTest3, []byte("second device"), signerIds[1], signers[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := message.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received, err := airGap.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = received.VerifyOperations(lookup); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Operation{
+		{OpCode: opCodeTest1, Size: 8, Data: []byte("unsigned")},
+		{OpCode: opCodeTest2, Size: 12, Data: []byte("first device"), SignerId: signerIds[0]},
+		{OpCode: opCodeTest3, Size: 13, Data: []byte("second device"), SignerId: signerIds[1]},
+	}
+
+	if !reflect.DeepEqual(received.Operations, expected) {
+		t.Fatal("incorrect verified operations")
+	}
+
+	// signature of the first device is attributed to the second one
+	forged, err := airGap.CreateMessage().AddSignedOperation(opCodeTest2, []byte("first device"), signerIds[1], signers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = forged.VerifyOperations(lookup); err != ErrSignature {
+		t.Fatal("forged operation is accepted", err)
+	}
+
+	tampered, err := airGap.CreateMessage().AddSignedOperation(opCodeTest2, []byte("first device"), signerIds[0], signers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered.Operations[0].Data[1] ^= 1
+
+	if err = tampered.VerifyOperations(lookup); err != ErrSignature {
+		t.Fatal("tampered operation code is accepted", err)
+	}
+
+	unknown, err := airGap.CreateMessage().AddSignedOperation(opCodeTest2, []byte("unknown"), airGap.instanceId, NewECDSASigner(instanceKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = unknown.VerifyOperations(lookup); err != ErrSignature {
+		t.Fatal("operation of unknown author is accepted", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	stateVersion    = 3
+	stateHeaderSize = 7 // state_version(1) + chunks_count(4) + chunk_size(2)
+	stateChunkSize  = 6 // chunk_index(4) + data_size(2)
+)
+
+// MarshalState serializes received chunks, so interrupted transfer can be
+// persisted and resumed later with UnmarshalState. Transfer id, message id of
+// encrypted frames and merkle root are persisted, so resumed receiver accepts
+// frames of the same transfer only. Received parity and fountain frames, which
+// are not decoded yet, are not persisted.
+//
+// Serialized format:
+// state_version(1) + chunks_count(4) + chunk_size(2) + [id_size(1) + id] * 3 +
+// [chunk_index(4) + data_size(2) + data] * filled
+func (ch *Chunks) MarshalState() ([]byte, error) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	result := make([]byte, stateHeaderSize, stateHeaderSize+ch.received+stateChunkSize*int(ch.filled))
+	result[0] = stateVersion
+	binary.BigEndian.PutUint32(result[1:], ch.count)
+	binary.BigEndian.PutUint16(result[5:], ch.size)
+
+	for _, id := range [][]byte{ch.transferId, ch.openedMessageId, ch.merkleRoot} {
+		result = append(result, byte(len(id)))
+		result = append(result, id...)
+	}
+
+	for index := 0; index < int(ch.count); index++ {
+		if !ch.storage.Has(index) {
+			continue
+		}
+
+		data, err := ch.storage.Get(index)
+		if err != nil {
+			return nil, err
+		}
+
+		var header [stateChunkSize]byte
+		binary.BigEndian.PutUint32(header[0:], uint32(index))
+		binary.BigEndian.PutUint16(header[4:], uint16(len(data)))
+		result = append(result, header[:]...)
+		result = append(result, data...)
+	}
+
+	return result, nil
+}
+
+// UnmarshalState restores chunks serialized with MarshalState, already
+// received chunks and ids of transfer are replaced
+func (ch *Chunks) UnmarshalState(data []byte) error {
+	if len(data) < stateHeaderSize {
+		return errors.New("go-airgap state has incorrect size")
+	}
+
+	if data[0] != stateVersion {
+		return errors.New("go-airgap state version is not supported")
+	}
+
+	count := binary.BigEndian.Uint32(data[1:])
+	size := binary.BigEndian.Uint16(data[5:])
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	// state is untrusted as frames, limits are checked before allocation
+	if count > 0 && size == 0 {
+		return errors.New("go-airgap state has incorrect chunk size")
+	}
+
+	if count > maxChunksCount && !ch.opts.wide {
+		return errors.New("go-airgap state has incorrect chunks count")
+	}
+
+	if err := ch.opts.verifyPayloadCapacity(count, size); err != nil {
+		return err
+	}
+
+	var ids [3][]byte
+	data = data[stateHeaderSize:]
+	for i, idSize := range []int{transferIdSize, chunkMessageIdSize, merkleHashSize} {
+		if len(data) == 0 || int(data[0]) > len(data)-1 || (data[0] != 0 && int(data[0]) != idSize) {
+			return errors.New("go-airgap state has incorrect id")
+		}
+
+		if data[0] != 0 {
+			ids[i] = append([]byte{}, data[1:1+idSize]...)
+		}
+		data = data[1+int(data[0]):]
+	}
+
+	merkleRoot := ids[2]
+	if ch.merkleTrusted {
+		if merkleRoot != nil && !bytes.Equal(merkleRoot, ch.merkleRoot) {
+			return errors.New("go-airgap state has incorrect merkle root")
+		}
+		merkleRoot = ch.merkleRoot
+	}
+
+	if ch.storage == nil {
+		ch.storage = &memoryStorage{}
+	}
+
+	if err := ch.storage.Reset(int(count), int(size)); err != nil {
+		return err
+	}
+
+	ch.count = count
+	ch.size = size
+	ch.filled = 0
+	ch.received = 0
+	ch.parityFrames = nil
+	ch.fountain = nil
+	ch.ur = nil
+	ch.transferId = ids[0]
+	ch.openedMessageId = ids[1]
+	ch.merkleRoot = merkleRoot
+
+	for len(data) > 0 {
+		if len(data) < stateChunkSize {
+			return errors.New("go-airgap state has incorrect size")
+		}
+
+		index := binary.BigEndian.Uint32(data[0:])
+		chunkSize := binary.BigEndian.Uint16(data[4:])
+		data = data[stateChunkSize:]
+
+		if index >= count || chunkSize > size || int(chunkSize) > len(data) || ch.storage.Has(int(index)) {
+			return errors.New("go-airgap state has incorrect chunk")
+		}
+
+		if err := ch.storage.Put(int(index), data[:chunkSize]); err != nil {
+			return err
+		}
+
+		ch.filled++
+		ch.received += int(chunkSize)
+		data = data[chunkSize:]
+	}
+
+	return nil
+}
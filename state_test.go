@@ -0,0 +1,165 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_MarshalState(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeRaw()
+
+	receiver := NewChunks()
+	for _, frame := range frames[:len(frames)/2] {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, err := receiver.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := NewChunks()
+	if err = resumed.UnmarshalState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	if resumed.Count() != receiver.Count() || resumed.Filled() != receiver.Filled() {
+		t.Fatal("incorrect resumed state")
+	}
+
+	for _, frame := range frames {
+		if _, err = resumed.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !resumed.IsFilled() || !bytes.Equal(resumed.Data(), payload) {
+		t.Fatal("incorrect resumed payload")
+	}
+
+	if err = NewChunks().UnmarshalState(state[:len(state)-1]); err == nil {
+		t.Fatal("truncated state is accepted")
+	}
+
+	// crafted header must not allocate storage
+	for _, header := range [][]byte{
+		{stateVersion, 0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0xC8},
+		{stateVersion, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00},
+		{stateVersion, 0x00, 0x01, 0x00, 0x00, 0x00, 0xC8},
+	} {
+		if err = NewChunks().UnmarshalState(header); err == nil {
+			t.Fatal("state with incorrect header is accepted", header)
+		}
+	}
+
+	if err = NewChunks().SetWideHeaders(true).SetMaxPayloadSize(1 << 20).UnmarshalState([]byte{stateVersion, 0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0xC8}); err != ErrPayloadTooLarge {
+		t.Fatal("state payload limit is not enforced", err)
+	}
+}
+
+func TestChunks_MarshalStateIds(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	key := make([]byte, 32)
+	newChunks := func() *Chunks {
+		return NewChunks().SetCompressor(CompressorNone).SetTransferId(true).SetChunkKey(key).SetMerkleProofs(true)
+	}
+
+	sender, err := newChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	another, err := newChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeRaw()
+
+	receiver := newChunks()
+	for _, frame := range frames[:len(frames)/2] {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, err := receiver.MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// stale ids of reused receiver are replaced
+	resumed := newChunks()
+	if _, err = resumed.ReadRawChunk(another.SerializeRaw()[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = resumed.UnmarshalState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(resumed.TransferId(), sender.TransferId()) ||
+		!bytes.Equal(resumed.openedMessageId, sender.messageId) ||
+		!bytes.Equal(resumed.MerkleRoot(), sender.MerkleRoot()) {
+		t.Fatal("incorrect resumed ids")
+	}
+
+	if _, err = resumed.ReadRawChunk(another.SerializeRaw()[len(frames)-1]); err != ErrTransferMismatch {
+		t.Fatal("frame of another transfer is accepted by resumed receiver", err)
+	}
+
+	for _, frame := range frames[len(frames)/2:] {
+		if _, err = resumed.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !resumed.IsFilled() || !bytes.Equal(resumed.Data(), payload) {
+		t.Fatal("incorrect resumed payload")
+	}
+
+	// state without ids clears ids of receiver
+	plain, err := NewChunks().MarshalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = resumed.UnmarshalState(plain); err != nil {
+		t.Fatal(err)
+	}
+
+	if resumed.TransferId() != nil || resumed.openedMessageId != nil || resumed.MerkleRoot() != nil {
+		t.Fatal("stale ids are kept")
+	}
+
+	if err = NewChunks().SetMerkleRoot(make([]byte, merkleHashSize)).UnmarshalState(state); err == nil {
+		t.Fatal("state of another trusted merkle root is accepted")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+)
+
+// ReceiverStats contains counters of received frames, so scanner apps can tune
+// frame rate and diagnose slow transfers
+type ReceiverStats struct {
+	// Frames is count of all read frames
+	Frames int
+	// Added is count of frames, which added new chunks
+	Added int
+	// Duplicates is count of frames with already received chunks
+	Duplicates int
+	// OutOfMessage is count of frames of another transfer
+	OutOfMessage int
+	// Failed is count of frames, which cannot be decoded or verified
+	Failed int
+}
+
+// Stats returns counters of received frames
+func (ch *Chunks) Stats() ReceiverStats {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return ch.stats
+}
+
+// recordFrame updates counters of received frames, duplicates are counted on ingest
+func (ch *Chunks) recordFrame(wasAdded bool, err error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	ch.stats.Frames++
+
+	if wasAdded {
+		ch.stats.Added++
+	}
+
+	if err == nil {
+		return
+	}
+
+	var frameErr *FrameError
+	if errors.Is(err, ErrTransferMismatch) ||
+		(errors.As(err, &frameErr) && (frameErr.Check == FrameCheckCount || frameErr.Check == FrameCheckIndex)) {
+		ch.stats.OutOfMessage++
+	} else {
+		ch.stats.Failed++
+	}
+}
+
+// frameFailed records frame, which cannot be decoded
+func (ch *Chunks) frameFailed(err error) {
+	ch.recordFrame(false, err)
+
+	if ch.observer != nil {
+		ch.observer.FrameFailed(err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_Stats(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetTransferId(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewChunks().SetTransferId(true).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks().SetTransferId(true)
+
+	frames := sender.SerializeB64()
+	for _, frame := range frames {
+		_, _ = receiver.ReadB64Chunk(frame)
+	}
+
+	_, _ = receiver.ReadB64Chunk(frames[0])
+	_, _ = receiver.ReadB64Chunk(frames[1])
+	_, _ = receiver.ReadB64Chunk(other.SerializeB64()[0])
+	_, _ = receiver.ReadB64Chunk("!")
+
+	expected := ReceiverStats{
+		Frames:       len(frames) + 4,
+		Added:        len(frames),
+		Duplicates:   2,
+		OutOfMessage: 1,
+		Failed:       1,
+	}
+
+	if stats := receiver.Stats(); stats != expected {
+		t.Fatal("incorrect stats", stats)
+	}
+}
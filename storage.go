@@ -0,0 +1,131 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+	"os"
+)
+
+// ChunkStorage keeps chunks of transmission. Chunks use memory storage by default,
+// receivers of very large transmissions may keep chunks on disk, see FileStorage.
+type ChunkStorage interface {
+	// Reset prepares storage for count chunks up to size bytes, stored chunks are dropped
+	Reset(count, size int) error
+	// Put stores chunk with index
+	Put(index int, chunk []byte) error
+	// Get returns chunk with index, or nil when chunk is not stored
+	Get(index int) ([]byte, error)
+	// Has checks that chunk with index is stored
+	Has(index int) bool
+}
+
+// memoryStorage keeps chunks in memory
+type memoryStorage struct {
+	data [][]byte
+}
+
+func (s *memoryStorage) Reset(count, _ int) error {
+	s.data = make([][]byte, count)
+	return nil
+}
+
+func (s *memoryStorage) Put(index int, chunk []byte) error {
+	if index < 0 || index >= len(s.data) {
+		return errors.New("go-airgap chunk index out of range")
+	}
+	s.data[index] = append(make([]byte, 0, len(chunk)), chunk...)
+	return nil
+}
+
+func (s *memoryStorage) Get(index int) ([]byte, error) {
+	if index < 0 || index >= len(s.data) {
+		return nil, errors.New("go-airgap chunk index out of range")
+	}
+	return s.data[index], nil
+}
+
+func (s *memoryStorage) Has(index int) bool {
+	return index >= 0 && index < len(s.data) && s.data[index] != nil
+}
+
+// FileStorage keeps chunks in file at fixed offsets, only chunk sizes are kept
+// in memory. File is truncated on reset.
+type FileStorage struct {
+	file  *os.File
+	size  int
+	sizes []int
+}
+
+func NewFileStorage(file *os.File) *FileStorage {
+	return &FileStorage{file: file}
+}
+
+func (s *FileStorage) Reset(count, size int) error {
+	if err := s.file.Truncate(0); err != nil {
+		return err
+	}
+
+	s.size = size
+	s.sizes = make([]int, count)
+	for index := range s.sizes {
+		s.sizes[index] = -1
+	}
+	return nil
+}
+
+func (s *FileStorage) Put(index int, chunk []byte) error {
+	if index < 0 || index >= len(s.sizes) {
+		return errors.New("go-airgap chunk index out of range")
+	}
+
+	if len(chunk) > s.size {
+		return errors.New("go-airgap chunk has incorrect size")
+	}
+
+	if _, err := s.file.WriteAt(chunk, int64(index)*int64(s.size)); err != nil {
+		return err
+	}
+
+	s.sizes[index] = len(chunk)
+	return nil
+}
+
+func (s *FileStorage) Get(index int) ([]byte, error) {
+	if index < 0 || index >= len(s.sizes) {
+		return nil, errors.New("go-airgap chunk index out of range")
+	}
+
+	if s.sizes[index] < 0 {
+		return nil, nil
+	}
+
+	chunk := make([]byte, s.sizes[index])
+	if _, err := s.file.ReadAt(chunk, int64(index)*int64(s.size)); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+func (s *FileStorage) Has(index int) bool {
+	return index >= 0 && index < len(s.sizes) && s.sizes[index] >= 0
+}
+
+// SetStorage defines storage of received chunks, must be called before
+// the first chunk is received
+func (ch *Chunks) SetStorage(storage ChunkStorage) *Chunks {
+	ch.storage = storage
+	return ch
+}
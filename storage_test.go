@@ -0,0 +1,108 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "chunks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	payload := make([]byte, 8000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetTransferId(true).SetData(payload, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sender.SerializeB64()
+
+	receiver := NewChunks().SetTransferId(true).SetStorage(NewFileStorage(file))
+
+	for _, frame := range frames[:len(frames)/2] {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// migration to another chunk size keeps received chunks on disk
+	resized, err := sender.Resize(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, frame := range resized.SerializeB64() {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !receiver.IsFilled() || !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() < int64(len(payload)) {
+		t.Fatal("chunks are not stored in file", info.Size())
+	}
+}
+
+func TestFileStorage_Get(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "chunks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	storage := NewFileStorage(file)
+	if err = storage.Reset(3, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = storage.Put(2, []byte{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = storage.Put(0, []byte{1, 2, 3, 4, 5}); err == nil {
+		t.Fatal("oversized chunk is stored")
+	}
+
+	if storage.Has(0) || !storage.Has(2) {
+		t.Fatal("incorrect stored chunks")
+	}
+
+	chunk, err := storage.Get(2)
+	if err != nil || !bytes.Equal(chunk, []byte{1, 2}) {
+		t.Fatal("incorrect stored chunk", chunk, err)
+	}
+
+	if chunk, err = storage.Get(1); chunk != nil || err != nil {
+		t.Fatal("missing chunk is returned")
+	}
+}
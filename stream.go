@@ -0,0 +1,271 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+)
+
+// SetDataFromReader compresses and splits data from r incrementally, so the whole
+// payload isn't kept in memory. Compressed chunks are kept in memory, or in
+// storage defined with SetStorage, which is limited by 65535 chunks.
+func (ch *Chunks) SetDataFromReader(r io.Reader, chunkSize int) (*Chunks, error) {
+	if chunkSize <= ch.opts.frameOverhead() {
+		return nil, errors.New("min chunk size 32")
+	}
+
+	if chunkSize > 1<<16-chunkHeaderOffset {
+		return nil, errors.New("max chunk size 65531")
+	}
+
+	// compressed size is unknown, so compact header is sized for max chunks count
+	maxDataSize := maxChunksCount * chunkSize
+	if ch.opts.wide {
+		maxDataSize = math.MaxInt32
+	}
+
+	chunkSize = ch.opts.chunkPayloadSize(maxDataSize, chunkSize)
+
+	if chunkSize <= 0 {
+		return nil, errors.New("min chunk size 32")
+	}
+
+	w := &chunksWriter{size: chunkSize, storage: ch.storage}
+	if w.storage == nil {
+		w.memory = &memoryStorage{}
+		w.storage = w.memory
+	} else if err := w.storage.Reset(maxChunksCount, chunkSize); err != nil {
+		return nil, err
+	}
+
+	if ch.opts.digest {
+		w.digest = sha256.New()
+	}
+
+	zw, err := ch.opts.newWriter(w)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot compress data: %s", err.Error()))
+	}
+
+	if _, err = io.Copy(zw, &limitedReader{r: r, limit: ch.opts.payloadLimit(), err: ErrPayloadTooLarge}); err != nil {
+		if err == ErrPayloadTooLarge {
+			return nil, err
+		}
+		return nil, errors.New(fmt.Sprintf("cannot write compressed data: %s", err.Error()))
+	}
+
+	if err = zw.Close(); err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot close writer: %s", err.Error()))
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err = ch.opts.verifyChunksCount(w.count); err != nil {
+		return nil, err
+	}
+
+	var transferId []byte
+	if ch.opts.transferId {
+		if transferId, err = newTransferId(); err != nil {
+			return nil, err
+		}
+	}
+
+	frameCipher, messageId, err := ch.opts.newFrameCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Chunks{
+		count:      uint32(w.count),
+		filled:     uint32(w.count),
+		size:       uint16(chunkSize),
+		opts:       ch.opts,
+		storage:    w.storage,
+		transferId: transferId,
+
+		frameCipher: frameCipher,
+		messageId:   messageId,
+	}
+
+	if err := result.buildMerkleTree(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// chunksWriter splits compressed data to chunks of size
+type chunksWriter struct {
+	size    int
+	storage ChunkStorage
+	// memory is grown storage, when storage isn't defined
+	memory *memoryStorage
+	digest hash.Hash
+	buf    []byte
+	count  int
+}
+
+func (w *chunksWriter) Write(p []byte) (int, error) {
+	if w.digest != nil {
+		w.digest.Write(p)
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 0, w.size)
+		}
+
+		written := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+written]
+		p = p[written:]
+
+		if len(w.buf) == w.size {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close writes payload digest, when enabled, and the last chunk
+func (w *chunksWriter) Close() error {
+	if w.digest != nil {
+		digest := w.digest.Sum(nil)
+		w.digest = nil
+		if _, err := w.Write(digest); err != nil {
+			return err
+		}
+	}
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	return w.flush()
+}
+
+func (w *chunksWriter) flush() error {
+	if w.memory != nil {
+		w.memory.data = append(w.memory.data, w.buf)
+	} else {
+		if w.count >= maxChunksCount {
+			return errors.New("max chunks count 65535")
+		}
+
+		if err := w.storage.Put(w.count, w.buf); err != nil {
+			return err
+		}
+	}
+
+	w.count++
+	w.buf = nil
+	return nil
+}
+
+// WriteTo streams decompressed payload to w, e.g. file or pipe, so large
+// transfers don't need to fit in memory. Chunks implements io.WriterTo.
+func (ch *Chunks) WriteTo(w io.Writer) (int64, error) {
+	return ch.WriteDataTo(w, nil)
+}
+
+// WriteDataTo streams received payload to w chunk by chunk, decompressed payload
+// is not kept in memory. Payload is decrypted with d, when defined, which
+// requires buffering of the whole ciphertext for authentication.
+func (ch *Chunks) WriteDataTo(w io.Writer, d Decryptor) (int64, error) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if ch.count == 0 || ch.filled != ch.count {
+		return 0, ErrIncomplete
+	}
+
+	if ch.opts.digest {
+		if err := ch.verifyPayloadDigest(); err != nil {
+			return 0, err
+		}
+	}
+
+	zr, err := ch.opts.newReader(ch.compressedReader())
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("cannot uncompress data: %s", err.Error()))
+	}
+	defer zr.Close()
+
+	if d == nil {
+		return io.Copy(w, zr)
+	}
+
+	var buf bytes.Buffer
+	if _, err = io.Copy(&buf, zr); err != nil {
+		if err == ErrDecompressionLimit {
+			return 0, err
+		}
+		return 0, errors.New(fmt.Sprintf("cannot read uncompressed data: %s", err.Error()))
+	}
+
+	data, err := d.Decrypt(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// compressedReader reads compressed payload of stored chunks without payload
+// digest, chunks lock must be held
+func (ch *Chunks) compressedReader() io.Reader {
+	if ch.opts.digest {
+		return &trailerReader{r: &chunksReader{ch: ch}, size: sha256.Size}
+	}
+	return &chunksReader{ch: ch}
+}
+
+// chunksReader reads stored chunks sequentially, chunks lock must be held
+type chunksReader struct {
+	ch    *Chunks
+	index int
+	buf   []byte
+}
+
+func (r *chunksReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.index >= int(r.ch.count) {
+			return 0, io.EOF
+		}
+
+		chunk, err := r.ch.storage.Get(r.index)
+		if err != nil {
+			return 0, err
+		}
+
+		r.buf = chunk
+		r.index++
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChunks_WriteDataTo(t *testing.T) {
+	payload := make([]byte, 20000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "chunks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	receiver := NewChunks().SetStorage(NewFileStorage(file))
+
+	frames := sender.SerializeB64()
+	for _, frame := range frames[1:] {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err = receiver.WriteDataTo(&buf, nil); err == nil {
+		t.Fatal("incomplete chunks are written")
+	}
+
+	if _, err = receiver.ReadB64Chunk(frames[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := receiver.WriteDataTo(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != int64(len(payload)) || !bytes.Equal(buf.Bytes(), payload) {
+		t.Fatal("incorrect written payload")
+	}
+}
+
+func TestChunks_WriteTo(t *testing.T) {
+	payload := make([]byte, 20000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks()
+	for _, frame := range sender.SerializeB64() {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var writerTo io.WriterTo = receiver
+	n, err := writerTo.WriteTo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != int64(len(payload)) || !bytes.Equal(written, payload) {
+		t.Fatal("incorrect written payload")
+	}
+}
+
+func TestChunks_WriteDataToDecrypted(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ed := NewHybridEncryptorDecryptor(NewECIESIdentity(privKey), NewECIESRecipient(&privKey.PublicKey))
+
+	payload := make([]byte, 5000)
+	_, _ = rand.Read(payload)
+
+	ciphertext, err := ed.Encrypt(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, err := NewChunks().SetData(ciphertext, 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks()
+	for _, frame := range sender.SerializeRaw() {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err = receiver.WriteDataTo(&buf, ed); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), payload) {
+		t.Fatal("incorrect decrypted payload")
+	}
+}
+
+func TestChunks_SetDataFromReader(t *testing.T) {
+	payload := make([]byte, 20000)
+	_, _ = rand.Read(payload)
+
+	for _, compact := range []bool{false, true} {
+		expected, err := NewChunks().SetCompactHeaders(compact).SetPayloadDigest(true).SetData(payload, 400)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sender, err := NewChunks().SetCompactHeaders(compact).SetPayloadDigest(true).SetDataFromReader(bytes.NewReader(payload), 400)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !compact && sender.Count() != expected.Count() {
+			t.Fatal("incorrect chunks count", sender.Count(), expected.Count())
+		}
+
+		receiver := NewChunks().SetCompactHeaders(compact).SetPayloadDigest(true)
+		for _, frame := range sender.SerializeRaw() {
+			if _, err = receiver.ReadRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+}
+
+func TestChunks_SetDataFromReaderStorage(t *testing.T) {
+	payload := make([]byte, 20000)
+	_, _ = rand.Read(payload)
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "chunks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	sender, err := NewChunks().SetStorage(NewFileStorage(file)).SetDataFromReader(bytes.NewReader(payload), 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(sender.Data(), payload) {
+		t.Fatal("incorrect stored payload")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+const (
+	// OpCodeTemplate is standard operation with variable fields of payload
+	// template, pre-registered on both sides
+	OpCodeTemplate uint16 = 0xFF03
+)
+
+// Template is field layout of operation payload: constant parts and variable
+// fields, so message carries only variable fields
+type Template struct {
+	opCode   uint16
+	segments []templateSegment
+}
+
+type templateSegment struct {
+	constant []byte
+	// size of field, or 0 for variable length field
+	size  int
+	field bool
+}
+
+// Templates is registry of templates referenced by ID
+type Templates struct {
+	mu        sync.RWMutex
+	templates map[uint16]*Template
+}
+
+func NewTemplate(opCode uint16) *Template {
+	return &Template{opCode: opCode}
+}
+
+// Constant appends constant part of payload
+func (t *Template) Constant(data []byte) *Template {
+	t.segments = append(t.segments, templateSegment{constant: data})
+	return t
+}
+
+// Field appends fixed size field
+func (t *Template) Field(size int) *Template {
+	t.segments = append(t.segments, templateSegment{size: size, field: true})
+	return t
+}
+
+// VarField appends variable length field
+func (t *Template) VarField() *Template {
+	t.segments = append(t.segments, templateSegment{field: true})
+	return t
+}
+
+// pack serializes field values
+func (t *Template) pack(fields [][]byte) ([]byte, error) {
+	var result []byte
+	index := 0
+
+	for _, segment := range t.segments {
+		if !segment.field {
+			continue
+		}
+
+		if index >= len(fields) {
+			return nil, errors.New("go-airgap template fields count mismatch")
+		}
+
+		if segment.size > 0 {
+			if len(fields[index]) != segment.size {
+				return nil, errors.New("go-airgap template field has incorrect size")
+			}
+		} else {
+			result = appendUvarint(result, uint64(len(fields[index])))
+		}
+
+		result = append(result, fields[index]...)
+		index++
+	}
+
+	if index != len(fields) {
+		return nil, errors.New("go-airgap template fields count mismatch")
+	}
+
+	return result, nil
+}
+
+// expand restores payload from serialized field values
+func (t *Template) expand(data []byte) ([]byte, error) {
+	var result []byte
+
+	for _, segment := range t.segments {
+		if !segment.field {
+			result = append(result, segment.constant...)
+			continue
+		}
+
+		size := uint64(segment.size)
+		if segment.size == 0 {
+			var n int
+			size, n = binary.Uvarint(data)
+			if n <= 0 {
+				return nil, errors.New("go-airgap template operation is corrupted")
+			}
+			data = data[n:]
+		}
+
+		if size > uint64(len(data)) {
+			return nil, errors.New("go-airgap template operation is corrupted")
+		}
+
+		result = append(result, data[:size]...)
+		data = data[size:]
+	}
+
+	if len(data) != 0 {
+		return nil, errors.New("go-airgap template operation is corrupted")
+	}
+
+	return result, nil
+}
+
+func NewTemplates() *Templates {
+	return &Templates{templates: make(map[uint16]*Template)}
+}
+
+// Register adds template with ID, which must be identical on both sides
+func (r *Templates) Register(id uint16, template *Template) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[id]; ok {
+		return errors.New("go-airgap template is already registered")
+	}
+
+	r.templates[id] = template
+	return nil
+}
+
+func (r *Templates) get(id uint16) (*Template, error) {
+	if r == nil {
+		return nil, errors.New("go-airgap templates are not defined")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, ok := r.templates[id]
+	if !ok {
+		return nil, errors.New("go-airgap template is not registered")
+	}
+	return template, nil
+}
+
+// SetTemplates defines payload templates registry
+func (a *AirGap) SetTemplates(templates *Templates) *AirGap {
+	a.templates = templates
+	return a
+}
+
+// AddTemplateOperation adds OpCodeTemplate operation with variable fields of
+// template id, see Message.ExpandTemplates
+func (m *Message) AddTemplateOperation(id uint16, fields ...[]byte) (*Message, error) {
+	template, err := m.templates.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	packed, err := template.pack(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, 2, 2+len(packed))
+	binary.BigEndian.PutUint16(payload, id)
+
+	return m.AddOperation(OpCodeTemplate, append(payload, packed...)), nil
+}
+
+// ExpandTemplates replaces OpCodeTemplate operations with restored operations
+func (m *Message) ExpandTemplates() error {
+	for i, op := range m.Operations {
+		if op.OpCode != OpCodeTemplate {
+			continue
+		}
+
+		if len(op.Data) < 2 {
+			return errors.New("go-airgap template operation to small")
+		}
+
+		template, err := m.templates.get(binary.BigEndian.Uint16(op.Data))
+		if err != nil {
+			return err
+		}
+
+		data, err := template.expand(op.Data[2:])
+		if err != nil {
+			return err
+		}
+
+		m.Operations[i] = &Operation{
+			OpCode: template.opCode,
+			Size:   uint32(len(data)),
+			Data:   data,
+		}
+		m.cached = nil
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestMessage_AddTemplateOperation(t *testing.T) {
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates := NewTemplates()
+	err = templates.Register(1, NewTemplate(opCodeTest2).
+		Constant([]byte(`{"type":"transfer","amount":"`)).
+		VarField().
+		Constant([]byte(`","to":"0x`)).
+		Field(40).
+		Constant([]byte(`"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = templates.Register(1, NewTemplate(opCodeTest3)); err == nil {
+		t.Fatal("template is registered twice")
+	}
+
+	airGap := NewAirGap(VersionDefault, elliptic.MarshalCompressed(elliptic.P256(), privKey.X, privKey.Y)).
+		SetTemplates(templates)
+
+	address := []byte("52908400098527886e0f7030069857d2e4169ee7")
+	expected := []byte(`{"type":"transfer","amount":"1.5","to":"0x52908400098527886e0f7030069857d2e4169ee7"}`)
+
+	message, err := airGap.CreateMessage().AddTemplateOperation(1, []byte("1.5"), address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if int(message.Operations[0].Size) != 2+1+3+len(address) {
+		t.Fatal("incorrect template operation size", message.Operations[0].Size)
+	}
+
+	data, err := message.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received, err := airGap.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = received.ExpandTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	if received.Operations[0].OpCode != opCodeTest2 || !bytes.Equal(received.Operations[0].Data, expected) {
+		t.Fatal("incorrect expanded operation", string(received.Operations[0].Data))
+	}
+
+	if _, err = airGap.CreateMessage().AddTemplateOperation(1, []byte("1.5"), address[1:]); err == nil {
+		t.Fatal("field with incorrect size is accepted")
+	}
+
+	if _, err = airGap.CreateMessage().AddTemplateOperation(1, []byte("1.5")); err == nil {
+		t.Fatal("incorrect fields count is accepted")
+	}
+
+	if _, err = airGap.CreateMessage().AddTemplateOperation(2); err == nil {
+		t.Fatal("unknown template is accepted")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"time"
+)
+
+const (
+	// throughputWindow is count of recent ingested chunks used for estimation
+	throughputWindow = 32
+)
+
+type ingestEvent struct {
+	at   time.Time
+	size int
+}
+
+// recordIngest remembers time and size of new chunk, must be called under lock
+func (ch *Chunks) recordIngest(size int) {
+	now := time.Now
+	if ch.clock != nil {
+		now = ch.clock
+	}
+
+	ch.ingest = append(ch.ingest, ingestEvent{at: now(), size: size})
+	if len(ch.ingest) > throughputWindow {
+		ch.ingest = ch.ingest[len(ch.ingest)-throughputWindow:]
+	}
+}
+
+// Throughput returns effective bytes per second of new chunks, based on recent
+// ingest rate, for display in scanning UIs
+func (ch *Chunks) Throughput() float64 {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return ch.throughput()
+}
+
+func (ch *Chunks) throughput() float64 {
+	if len(ch.ingest) < 2 {
+		return 0
+	}
+
+	elapsed := ch.ingest[len(ch.ingest)-1].at.Sub(ch.ingest[0].at)
+	if elapsed <= 0 {
+		return 0
+	}
+
+	// the first event opens the window
+	bytes := 0
+	for _, event := range ch.ingest[1:] {
+		bytes += event.size
+	}
+
+	return float64(bytes) / elapsed.Seconds()
+}
+
+// ETA returns estimated time to receive missing chunks, false is returned
+// while throughput is unknown
+func (ch *Chunks) ETA() (time.Duration, bool) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	rate := ch.throughput()
+	if rate == 0 {
+		return 0, false
+	}
+
+	remaining := float64(ch.count-ch.filled) * float64(ch.size)
+	return time.Duration(remaining / rate * float64(time.Second)), true
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func TestThroughput_ETA(t *testing.T) {
+	payload := make([]byte, 4096)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal("cannot read random")
+	}
+
+	chunks, err := NewChunks().SetData(payload, defaultChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := chunks.SerializeRaw()
+
+	// receiver ingests 10 chunks per second
+	now := time.Now()
+	readedChunks := NewChunks()
+	readedChunks.clock = func() time.Time {
+		now = now.Add(100 * time.Millisecond)
+		return now
+	}
+
+	if _, ok := readedChunks.ETA(); ok {
+		t.Fatal("eta is known before ingest")
+	}
+
+	for i := 0; i < 11; i++ {
+		if _, err = readedChunks.AddRawChunk(frames[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chunkSize := float64(defaultChunkSize - chunkHeaderOffset)
+
+	if throughput := readedChunks.Throughput(); throughput != chunkSize*10 {
+		t.Fatalf("incorrect throughput %f", throughput)
+	}
+
+	eta, ok := readedChunks.ETA()
+	if !ok {
+		t.Fatal("eta is not known")
+	}
+
+	expected := time.Duration(len(frames)-11) * 100 * time.Millisecond
+	if eta < expected-time.Millisecond || eta > expected+time.Millisecond {
+		t.Fatalf("incorrect eta %s, expected %s", eta, expected)
+	}
+}
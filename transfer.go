@@ -0,0 +1,82 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+const (
+	transferIdSize = 4
+)
+
+// ErrTransferMismatch is returned for frame of another transfer, e.g. when
+// receiver scans frames of two different animations. The first authenticated
+// frame defines transfer id of receiver, so receiver, which keeps getting
+// ErrTransferMismatch without progress, must be Reset to follow another transfer.
+var ErrTransferMismatch = errors.New("go-airgap frame belongs to another transfer")
+
+// SetTransferId enables random transfer id in every frame, so receiver rejects
+// frames of another transfer instead of assembling corrupted payload
+func (ch *Chunks) SetTransferId(enabled bool) *Chunks {
+	ch.opts.transferId = enabled
+	return ch
+}
+
+// SetTransferId enables random transfer id in every frame, see Chunks.SetTransferId
+func (a *AirGap) SetTransferId(enabled bool) *AirGap {
+	a.chunksOpts.transferId = enabled
+	return a
+}
+
+// TransferId returns id of transfer, or nil when it is disabled or not received yet
+func (ch *Chunks) TransferId() []byte {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	return ch.transferId
+}
+
+func (o chunksOptions) transferIdSize() int {
+	if !o.transferId {
+		return 0
+	}
+	return transferIdSize
+}
+
+func newTransferId() ([]byte, error) {
+	id := make([]byte, transferIdSize)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// verifyTransferId returns frame without transfer id, the first authenticated
+// frame defines transfer id of receiver until Reset
+func (ch *Chunks) verifyTransferId(frame []byte) ([]byte, error) {
+	id := frame[:transferIdSize]
+
+	if ch.transferId == nil {
+		ch.transferId = append([]byte{}, id...)
+	} else if !bytes.Equal(ch.transferId, id) {
+		return nil, ErrTransferMismatch
+	}
+
+	return frame[transferIdSize:], nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetTransferId(t *testing.T) {
+	payload := make([]byte, 2000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetTransferId(true).SetFrameKey([]byte("key")).SetDecoyFrames(2).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewChunks().SetTransferId(true).SetFrameKey([]byte("key")).SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.TransferId()) != transferIdSize || bytes.Equal(sender.TransferId(), other.TransferId()) {
+		t.Fatal("incorrect transfer id")
+	}
+
+	receiver := NewChunks().SetTransferId(true).SetFrameKey([]byte("key"))
+	isMismatchChecked := false
+	for _, frame := range sender.SerializeRaw() {
+		if _, err = receiver.ReadRawChunk(frame); err != nil {
+			t.Fatal(err)
+		}
+
+		// decoys are skipped, transfer id is defined by the first real frame
+		if receiver.TransferId() != nil && !isMismatchChecked {
+			isMismatchChecked = true
+			if _, err = receiver.ReadRawChunk(other.SerializeRaw()[1]); err != ErrTransferMismatch {
+				t.Fatal("frame of another transfer is accepted", err)
+			}
+		}
+	}
+
+	if !bytes.Equal(receiver.TransferId(), sender.TransferId()) || !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	// reset receiver follows another transfer
+	receiver.Reset()
+	if _, err = receiver.ReadRawChunk(other.SerializeRaw()[1]); err != nil || !bytes.Equal(receiver.TransferId(), other.TransferId()) {
+		t.Fatal("frame of another transfer is rejected after reset", err)
+	}
+}
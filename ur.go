@@ -0,0 +1,677 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// URTypeBytes is BC-UR type of go-airgap payload, see
+// https://github.com/BlockchainCommons/Research/blob/master/papers/bcr-2020-005-ur.md
+const URTypeBytes = "bytes"
+
+const (
+	urScheme          = "ur:"
+	urMinFragmentLen  = 10
+	urPartFieldsCount = 5
+	// urChunkSize is chunk size of payload decoded with ReadURPart
+	urChunkSize = 1<<16 - chunkHeaderOffset
+)
+
+const (
+	cborUnsigned = 0
+	cborBytes    = 2
+	cborArray    = 4
+)
+
+// bytewords is BCR-2020-012 words list, minimal style keeps the first and
+// the last letters of word
+const bytewords = "" +
+	"ableacidalsoapexaquaarchatomauntawayaxisbackbaldbarnbeltbetabias" +
+	"bluebodybragbrewbulbbuzzcalmcashcatschefcityclawcodecolacookcost" +
+	"cruxcurlcuspcyandarkdatadaysdelidicedietdoordowndrawdropdrumdull" +
+	"dutyeacheasyechoedgeepicevenexamexiteyesfactfairfernfigsfilmfish" +
+	"fizzflapflewfluxfoxyfreefrogfuelfundgalagamegeargemsgiftgirlglow" +
+	"goodgraygrimgurugushgyrohalfhanghardhawkheathelphighhillholyhope" +
+	"hornhutsicedideaidleinchinkyintoirisironitemjadejazzjoinjoltjowl" +
+	"judojugsjumpjunkjurykeepkenokeptkeyskickkilnkingkitekiwiknoblamb" +
+	"lavalazyleaflegsliarlimplionlistlogoloudloveluaulucklungmainmany" +
+	"mathmazememomenumeowmildmintmissmonknailnavyneednewsnextnoonnote" +
+	"numbobeyoboeomitonyxopenovalowlspaidpartpeckplaypluspoempoolpose" +
+	"puffpumapurrquadquizraceramprealredorichroadrockroofrubyruinruns" +
+	"rustsafesagascarsetssilkskewslotsoapsolosongstubsurfswantacotask" +
+	"taxitenttiedtimetinytoiltombtoystriptunatwinuglyundouniturgeuser" +
+	"vastveryvetovialvibeviewvisavoidvowswallwandwarmwaspwavewaxywebs" +
+	"whatwhenwhizwolfworkyankyawnyellyogayurtzapszerozestzinczonezoom"
+
+// bytewordsMinimal maps minimal bytewords to bytes
+var bytewordsMinimal = func() map[string]byte {
+	result := make(map[string]byte, 256)
+	for i := 0; i < 256; i++ {
+		result[bytewords[i*4:i*4+1]+bytewords[i*4+3:i*4+4]] = byte(i)
+	}
+	return result
+}()
+
+// UREncoder splits payload to BC-UR parts, parts after the first SeqLen parts
+// are fountain coded, so receiver decodes payload from any sufficiently large
+// subset of parts
+type UREncoder struct {
+	message   []byte
+	checksum  uint32
+	fragments [][]byte
+	seqNum    uint32
+}
+
+// NewUREncoder returns encoder of ur:bytes parts with fragments up to
+// maxFragmentLen bytes
+func NewUREncoder(data []byte, maxFragmentLen int) (*UREncoder, error) {
+	if maxFragmentLen < urMinFragmentLen {
+		return nil, errors.New("go-airgap min UR fragment length 10")
+	}
+
+	message := append(cborAppendHead(nil, cborBytes, uint64(len(data))), data...)
+	fragmentLen := urFragmentLen(len(message), maxFragmentLen)
+
+	padded := make([]byte, (len(message)+fragmentLen-1)/fragmentLen*fragmentLen)
+	copy(padded, message)
+
+	return &UREncoder{
+		message:   message,
+		checksum:  crc32.ChecksumIEEE(message),
+		fragments: splitChunks(padded, fragmentLen),
+	}, nil
+}
+
+// SeqLen returns count of fragments
+func (e *UREncoder) SeqLen() int {
+	return len(e.fragments)
+}
+
+// IsSinglePart checks that payload fits to the single part
+func (e *UREncoder) IsSinglePart() bool {
+	return len(e.fragments) == 1
+}
+
+// NextPart returns the next part, ready for QR code. Single part payload is
+// returned without sequence number.
+func (e *UREncoder) NextPart() string {
+	if e.IsSinglePart() {
+		return urScheme + URTypeBytes + "/" + encodeBytewords(e.message)
+	}
+
+	e.seqNum++
+	return e.Part(e.seqNum)
+}
+
+// Part returns part with sequence number seqNum starting from 1
+func (e *UREncoder) Part(seqNum uint32) string {
+	fragment := make([]byte, len(e.fragments[0]))
+	for _, index := range urFragmentIndexes(seqNum, len(e.fragments), e.checksum) {
+		for i, b := range e.fragments[index] {
+			fragment[i] ^= b
+		}
+	}
+
+	part := cborAppendHead(nil, cborArray, urPartFieldsCount)
+	part = cborAppendHead(part, cborUnsigned, uint64(seqNum))
+	part = cborAppendHead(part, cborUnsigned, uint64(len(e.fragments)))
+	part = cborAppendHead(part, cborUnsigned, uint64(len(e.message)))
+	part = cborAppendHead(part, cborUnsigned, uint64(e.checksum))
+	part = cborAppendHead(part, cborBytes, uint64(len(fragment)))
+	part = append(part, fragment...)
+
+	return fmt.Sprintf("%s%s/%d-%d/%s", urScheme, URTypeBytes, seqNum, len(e.fragments), encodeBytewords(part))
+}
+
+// URDecoder decodes ur:bytes parts of UREncoder
+type URDecoder struct {
+	seqLen      int
+	messageLen  int
+	checksum    uint32
+	fragmentLen int
+	fragments   [][]byte
+	solved      int
+	seen        map[uint32]bool
+	pending     []*fountainEquation
+	result      []byte
+}
+
+func NewURDecoder() *URDecoder {
+	return &URDecoder{}
+}
+
+// ReceivePart decodes part, wasAdded is true for the first copy of part
+func (d *URDecoder) ReceivePart(part string) (wasAdded bool, err error) {
+	part = strings.ToLower(normalizeFrame(part))
+	if !strings.HasPrefix(part, urScheme) {
+		return false, newFrameError("unsupported go-airgap frame tag", FrameCheckTag, -1, 0, 0)
+	}
+
+	components := strings.Split(part[len(urScheme):], "/")
+	if components[0] != URTypeBytes || len(components) < 2 || len(components) > 3 {
+		return false, newFrameError("unsupported go-airgap frame tag", FrameCheckTag, -1, 0, 0)
+	}
+
+	data, err := decodeBytewords(components[len(components)-1])
+	if err != nil {
+		return false, err
+	}
+
+	if len(components) == 2 {
+		return d.receiveMessage(data, crc32.ChecksumIEEE(data))
+	}
+
+	return d.receiveFragment(components[1], data)
+}
+
+// IsComplete checks that payload is decoded
+func (d *URDecoder) IsComplete() bool {
+	return d.result != nil
+}
+
+// Progress returns count of decoded fragments and count of all fragments
+func (d *URDecoder) Progress() (received, total int) {
+	if d.IsComplete() {
+		return d.seqLen, d.seqLen
+	}
+	return d.solved, d.seqLen
+}
+
+// Result returns decoded payload
+func (d *URDecoder) Result() ([]byte, error) {
+	if !d.IsComplete() {
+		return nil, ErrIncomplete
+	}
+	return d.result, nil
+}
+
+func (d *URDecoder) receiveMessage(message []byte, checksum uint32) (wasAdded bool, err error) {
+	if d.IsComplete() {
+		return false, nil
+	}
+
+	if crc32.ChecksumIEEE(message) != checksum {
+		return false, ErrCorrupted
+	}
+
+	major, size, data, err := cborReadHead(message)
+	if err != nil || major != cborBytes || uint64(len(data)) != size {
+		return false, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, 0)
+	}
+
+	if d.seqLen == 0 {
+		d.seqLen = 1
+	}
+	d.result = append([]byte{}, data...)
+	return true, nil
+}
+
+func (d *URDecoder) receiveFragment(seq string, data []byte) (wasAdded bool, err error) {
+	var fields [urPartFieldsCount - 1]uint64
+
+	major, count, data, err := cborReadHead(data)
+	if err != nil || major != cborArray || count != urPartFieldsCount {
+		return false, newFrameError("incorrect go-airgap message", FrameCheckHeader, -1, urPartFieldsCount, int(count))
+	}
+
+	for i := range fields {
+		if major, fields[i], data, err = cborReadHead(data); err != nil || major != cborUnsigned {
+			return false, newFrameError("incorrect go-airgap message", FrameCheckHeader, -1, 0, 0)
+		}
+	}
+
+	major, size, fragment, err := cborReadHead(data)
+	if err != nil || major != cborBytes || uint64(len(fragment)) != size {
+		return false, newFrameError("incorrect go-airgap message", FrameCheckHeader, -1, 0, 0)
+	}
+
+	seqNum, seqLen, messageLen, checksum := fields[0], fields[1], fields[2], fields[3]
+
+	if seq != strconv.FormatUint(seqNum, 10)+"-"+strconv.FormatUint(seqLen, 10) ||
+		seqNum == 0 || seqLen == 0 || seqNum > 0xFFFFFFFF || checksum > 0xFFFFFFFF ||
+		messageLen == 0 || messageLen > seqLen*uint64(len(fragment)) ||
+		messageLen <= (seqLen-1)*uint64(len(fragment)) {
+		return false, newFrameError("incorrect go-airgap message", FrameCheckHeader, -1, 0, 0)
+	}
+
+	if d.seqLen == 0 {
+		d.seqLen = int(seqLen)
+		d.messageLen = int(messageLen)
+		d.checksum = uint32(checksum)
+		d.fragmentLen = len(fragment)
+		d.fragments = make([][]byte, seqLen)
+		d.seen = make(map[uint32]bool)
+	} else if int(seqLen) != d.seqLen || int(messageLen) != d.messageLen || uint32(checksum) != d.checksum ||
+		len(fragment) != d.fragmentLen {
+		return false, newFrameError("go-airgap chunk has incorrect count",
+			FrameCheckCount, -1, d.seqLen, int(seqLen))
+	}
+
+	if d.IsComplete() || d.seen[uint32(seqNum)] {
+		return false, nil
+	}
+	d.seen[uint32(seqNum)] = true
+
+	equation := &fountainEquation{
+		indexes: urFragmentIndexes(uint32(seqNum), d.seqLen, d.checksum),
+		data:    append([]byte{}, fragment...),
+	}
+
+	d.reduceEquation(equation)
+	if len(equation.indexes) == 0 {
+		return true, nil
+	}
+	d.pending = append(d.pending, equation)
+
+	for {
+		solved := -1
+		for i, pending := range d.pending {
+			if len(pending.indexes) == 1 {
+				solved = i
+				break
+			}
+		}
+
+		if solved < 0 {
+			break
+		}
+
+		equation = d.pending[solved]
+		d.pending = append(d.pending[:solved], d.pending[solved+1:]...)
+		d.fragments[equation.indexes[0]] = equation.data
+		d.solved++
+
+		remaining := d.pending[:0]
+		for _, pending := range d.pending {
+			d.reduceEquation(pending)
+			if len(pending.indexes) > 0 {
+				remaining = append(remaining, pending)
+			}
+		}
+		d.pending = remaining
+	}
+
+	if d.solved < d.seqLen {
+		return true, nil
+	}
+
+	var message []byte
+	for _, fragment := range d.fragments {
+		message = append(message, fragment...)
+	}
+
+	if _, err = d.receiveMessage(message[:d.messageLen], d.checksum); err != nil {
+		// corrupted fragments cannot be identified, decoding restarts
+		*d = URDecoder{}
+		return false, err
+	}
+
+	d.fragments = nil
+	d.pending = nil
+	return true, nil
+}
+
+// reduceEquation removes already decoded fragments from equation
+func (d *URDecoder) reduceEquation(equation *fountainEquation) {
+	indexes := equation.indexes[:0]
+	for _, index := range equation.indexes {
+		if d.fragments[index] == nil {
+			indexes = append(indexes, index)
+			continue
+		}
+
+		for i, b := range d.fragments[index] {
+			equation.data[i] ^= b
+		}
+	}
+	equation.indexes = indexes
+}
+
+// UREncoder returns BC-UR encoder of compressed payload, receiver reads parts
+// with ReadURPart
+func (ch *Chunks) UREncoder(maxFragmentLen int) (*UREncoder, error) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	if ch.count == 0 || ch.filled != ch.count {
+		return nil, ErrIncomplete
+	}
+
+	var data []byte
+	for index := 0; index < int(ch.count); index++ {
+		chunk, err := ch.storage.Get(index)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+
+	return NewUREncoder(data, maxFragmentLen)
+}
+
+// ReadURPart reads part of UREncoder, payload is available with Data, when
+// enough parts are received
+func (ch *Chunks) ReadURPart(part string) (wasAdded bool, err error) {
+	ch.mu.Lock()
+	wasAdded, err = ch.readURPart(part)
+	ch.mu.Unlock()
+
+	ch.recordFrame(wasAdded, err)
+
+	if ch.observer != nil {
+		if err != nil {
+			ch.observer.FrameFailed(err)
+		} else {
+			ch.observer.FrameProcessed(wasAdded)
+		}
+	}
+
+	return wasAdded, err
+}
+
+func (ch *Chunks) readURPart(part string) (wasAdded bool, err error) {
+	if ch.ur == nil {
+		ch.ur = NewURDecoder()
+	}
+
+	if wasAdded, err = ch.ur.ReceivePart(part); err != nil {
+		return false, err
+	}
+
+	if int64(ch.ur.messageLen) > ch.opts.payloadLimit() {
+		ch.ur = nil
+		return false, ErrPayloadTooLarge
+	}
+
+	if !ch.ur.IsComplete() || ch.count > 0 {
+		if !wasAdded {
+			ch.stats.Duplicates++
+		}
+		return wasAdded, nil
+	}
+
+	data, _ := ch.ur.Result()
+	if int64(len(data)) > ch.opts.payloadLimit() {
+		return false, ErrPayloadTooLarge
+	}
+
+	chunks := splitChunks(data, urChunkSize)
+	if ch.storage == nil {
+		ch.storage = &memoryStorage{}
+	}
+
+	if err = ch.storage.Reset(len(chunks), urChunkSize); err != nil {
+		return false, err
+	}
+
+	for index, chunk := range chunks {
+		if err = ch.storage.Put(index, chunk); err != nil {
+			return false, err
+		}
+		ch.received += len(chunk)
+	}
+
+	ch.count = uint32(len(chunks))
+	ch.size = urChunkSize
+	ch.filled = ch.count
+	return true, nil
+}
+
+// urFragmentLen returns the min fragment length not exceeding maxFragmentLen
+func urFragmentLen(messageLen, maxFragmentLen int) int {
+	maxFragmentCount := messageLen / urMinFragmentLen
+	if maxFragmentCount < 1 {
+		maxFragmentCount = 1
+	}
+
+	fragmentLen := 0
+	for fragmentCount := 1; fragmentCount <= maxFragmentCount; fragmentCount++ {
+		fragmentLen = (messageLen + fragmentCount - 1) / fragmentCount
+		if fragmentLen <= maxFragmentLen {
+			break
+		}
+	}
+	return fragmentLen
+}
+
+// urFragmentIndexes returns indexes of fragments mixed to part seqNum
+func urFragmentIndexes(seqNum uint32, seqLen int, checksum uint32) []int {
+	if int64(seqNum) <= int64(seqLen) {
+		return []int{int(seqNum) - 1}
+	}
+
+	var seed [8]byte
+	binary.BigEndian.PutUint32(seed[0:], seqNum)
+	binary.BigEndian.PutUint32(seed[4:], checksum)
+	rng := newXoshiro256(seed[:])
+
+	probabilities := make([]float64, seqLen)
+	for i := range probabilities {
+		probabilities[i] = 1 / float64(i+1)
+	}
+	degree := newAliasSampler(probabilities).next(rng) + 1
+
+	remaining := make([]int, seqLen)
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	indexes := make([]int, 0, degree)
+	for len(indexes) < degree {
+		i := int(rng.nextInt(0, uint64(len(remaining)-1)))
+		indexes = append(indexes, remaining[i])
+		remaining = append(remaining[:i], remaining[i+1:]...)
+	}
+	return indexes
+}
+
+// xoshiro256 is xoshiro256** generator, seeded with SHA-256 digest of seed
+type xoshiro256 struct {
+	s [4]uint64
+}
+
+func newXoshiro256(seed []byte) *xoshiro256 {
+	digest := sha256.Sum256(seed)
+
+	rng := &xoshiro256{}
+	for i := range rng.s {
+		rng.s[i] = binary.BigEndian.Uint64(digest[i*8:])
+	}
+	return rng
+}
+
+func (r *xoshiro256) next() uint64 {
+	result := bits.RotateLeft64(r.s[1]*5, 7) * 9
+	t := r.s[1] << 17
+
+	r.s[2] ^= r.s[0]
+	r.s[3] ^= r.s[1]
+	r.s[1] ^= r.s[2]
+	r.s[0] ^= r.s[3]
+	r.s[2] ^= t
+	r.s[3] = bits.RotateLeft64(r.s[3], 45)
+
+	return result
+}
+
+func (r *xoshiro256) nextDouble() float64 {
+	return float64(r.next()) / (1 << 64)
+}
+
+func (r *xoshiro256) nextInt(low, high uint64) uint64 {
+	return uint64(r.nextDouble()*float64(high-low+1)) + low
+}
+
+// aliasSampler is Walker's alias method sampler of weighted distribution
+type aliasSampler struct {
+	probabilities []float64
+	aliases       []int
+}
+
+func newAliasSampler(weights []float64) *aliasSampler {
+	n := len(weights)
+
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
+	}
+
+	p := make([]float64, n)
+	for i, weight := range weights {
+		p[i] = weight * float64(n) / sum
+	}
+
+	var small, large []int
+	for i := n - 1; i >= 0; i-- {
+		if p[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	sampler := &aliasSampler{
+		probabilities: make([]float64, n),
+		aliases:       make([]int, n),
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		a := small[len(small)-1]
+		small = small[:len(small)-1]
+		g := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		sampler.probabilities[a] = p[a]
+		sampler.aliases[a] = g
+		p[g] = p[g] + p[a] - 1
+
+		if p[g] < 1 {
+			small = append(small, g)
+		} else {
+			large = append(large, g)
+		}
+	}
+
+	for _, i := range large {
+		sampler.probabilities[i] = 1
+	}
+	for _, i := range small {
+		sampler.probabilities[i] = 1
+	}
+
+	return sampler
+}
+
+func (s *aliasSampler) next(rng *xoshiro256) int {
+	r1 := rng.nextDouble()
+	r2 := rng.nextDouble()
+
+	i := int(float64(len(s.probabilities)) * r1)
+	if r2 < s.probabilities[i] {
+		return i
+	}
+	return s.aliases[i]
+}
+
+// encodeBytewords encodes data with CRC-32 checksum as minimal bytewords
+func encodeBytewords(data []byte) string {
+	var checksum [4]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(data))
+
+	var sb strings.Builder
+	sb.Grow((len(data) + len(checksum)) * 2)
+	for _, b := range append(append([]byte{}, data...), checksum[:]...) {
+		sb.WriteByte(bytewords[int(b)*4])
+		sb.WriteByte(bytewords[int(b)*4+3])
+	}
+	return sb.String()
+}
+
+func decodeBytewords(frame string) ([]byte, error) {
+	if len(frame)%2 != 0 || len(frame) < 8 {
+		return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, len(frame))
+	}
+
+	data := make([]byte, len(frame)/2)
+	for i := range data {
+		b, ok := bytewordsMinimal[frame[i*2:i*2+2]]
+		if !ok {
+			return nil, newFrameError("incorrect go-airgap message", FrameCheckEncoding, -1, 0, i*2)
+		}
+		data[i] = b
+	}
+
+	checksum := binary.BigEndian.Uint32(data[len(data)-4:])
+	data = data[:len(data)-4]
+
+	if crc32.ChecksumIEEE(data) != checksum {
+		return nil, newFrameError("go-airgap chunk checksum mismatch", FrameCheckChecksum, -1, int(checksum), int(crc32.ChecksumIEEE(data)))
+	}
+	return data, nil
+}
+
+// cborAppendHead appends CBOR head of major type with argument value
+func cborAppendHead(dst []byte, major byte, value uint64) []byte {
+	switch {
+	case value < 24:
+		return append(dst, major<<5|byte(value))
+	case value <= 0xFF:
+		return append(dst, major<<5|24, byte(value))
+	case value <= 0xFFFF:
+		return append(dst, major<<5|25, byte(value>>8), byte(value))
+	case value <= 0xFFFFFFFF:
+		return append(dst, major<<5|26, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+	default:
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], value)
+		return append(append(dst, major<<5|27), buf[:]...)
+	}
+}
+
+// cborReadHead reads CBOR head and returns the rest of data
+func cborReadHead(data []byte) (major byte, value uint64, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, 0, nil, errors.New("go-airgap CBOR data is too short")
+	}
+
+	major, info := data[0]>>5, data[0]&0x1F
+	data = data[1:]
+
+	if info < 24 {
+		return major, uint64(info), data, nil
+	}
+
+	if info > 27 {
+		return 0, 0, nil, errors.New("go-airgap unsupported CBOR argument")
+	}
+
+	size := 1 << (info - 24)
+	if len(data) < size {
+		return 0, 0, nil, errors.New("go-airgap CBOR data is too short")
+	}
+
+	for _, b := range data[:size] {
+		value = value<<8 | uint64(b)
+	}
+	return major, value, data[size:], nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"hash/crc32"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// urTestMessage returns message of BC-UR reference test vectors
+func urTestMessage(size int) []byte {
+	rng := newXoshiro256([]byte("Wolf"))
+	message := make([]byte, size)
+	for i := range message {
+		message[i] = byte(rng.nextInt(0, 255))
+	}
+	return message
+}
+
+func TestUREncoder(t *testing.T) {
+	encoder, err := NewUREncoder(urTestMessage(50), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if part := encoder.NextPart(); part != "ur:bytes/hdeymejtswhhylkepmykhhtsytsnoyoyaxaedsuttydmmhhpktpmsrjtgwdpfnsboxgwlbaawzuefywkdplrsrjynbvygabwjldapfcsdwkbrkch" {
+		t.Fatal("incorrect single part", part)
+	}
+
+	if encoder, err = NewUREncoder(urTestMessage(256), 30); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"ur:bytes/1-9/lpadascfadaxcywenbpljkhdcahkadaemejtswhhylkepmykhhtsytsnoyoyaxaedsuttydmmhhpktpmsrjtdkgslpgh",
+		"ur:bytes/2-9/lpaoascfadaxcywenbpljkhdcagwdpfnsboxgwlbaawzuefywkdplrsrjynbvygabwjldapfcsgmghhkhstlrdcxaefz",
+		"ur:bytes/3-9/lpaxascfadaxcywenbpljkhdcahelbknlkuejnbadmssfhfrdpsbiegecpasvssovlgeykssjykklronvsjksopdzmol",
+	}
+
+	for _, part := range expected {
+		if next := encoder.NextPart(); next != part {
+			t.Fatal("incorrect part", next)
+		}
+	}
+
+	if _, err = NewUREncoder(urTestMessage(256), 9); err == nil {
+		t.Fatal("too short fragment length is accepted")
+	}
+}
+
+func TestURFragmentIndexes(t *testing.T) {
+	message := urTestMessage(1024)
+	checksum := crc32.ChecksumIEEE(message)
+
+	expected := [][]int{
+		{9}, {2, 5, 6, 8, 9, 10}, {8}, {1, 5}, {1}, {0, 2, 4, 5, 8, 10}, {5}, {2}, {2},
+		{0, 1, 3, 4, 5, 7, 9, 10}, {0, 1, 2, 3, 5, 6, 8, 9, 10}, {0, 2, 4, 5, 7, 8, 9, 10},
+		{3, 5}, {4}, {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, {0, 1, 3, 4, 5, 6, 7, 9, 10}, {6}, {5, 6}, {7},
+	}
+
+	for seqNum := uint32(1); seqNum <= 11; seqNum++ {
+		if indexes := urFragmentIndexes(seqNum, 11, checksum); !reflect.DeepEqual(indexes, []int{int(seqNum) - 1}) {
+			t.Fatal("incorrect fragment indexes", seqNum, indexes)
+		}
+	}
+
+	for i, fragments := range expected {
+		indexes := urFragmentIndexes(uint32(12+i), 11, checksum)
+		sort.Ints(indexes)
+		if !reflect.DeepEqual(indexes, fragments) {
+			t.Fatal("incorrect fragment indexes", 12+i, indexes)
+		}
+	}
+}
+
+func TestURDecoder(t *testing.T) {
+	message := urTestMessage(32767)
+
+	encoder, err := NewUREncoder(message, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := NewURDecoder()
+	for !decoder.IsComplete() {
+		part := encoder.NextPart()
+
+		// every third part is lost
+		if encoder.seqNum%3 == 0 {
+			continue
+		}
+
+		if _, err = decoder.ReceivePart(part); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := decoder.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result, message) {
+		t.Fatal("incorrect decoded message")
+	}
+
+	if _, err = NewURDecoder().ReceivePart("ur:bytes/1-9/lpadascfadaxcywenbpljkhdcahkadaemejtswhhylkepmykhhtsytsnoyoyaxaedsuttydmmhhpktpmsrjtdkgslpgs"); err == nil {
+		t.Fatal("corrupted part is accepted")
+	}
+
+	if _, err = NewURDecoder().ReceivePart("ur:crypto-psbt/hdeymejtswhhylkepmykhhtsytsnoyoyaxaedsuttydmmhhpktpmsrjtgwdpfnsboxgwlbaawzuefywkdplrsrjynbvygabwjldapfcsdwkbrkch"); err == nil {
+		t.Fatal("unsupported UR type is accepted")
+	}
+}
+
+func TestChunks_ReadURPart(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	sender, err := NewChunks().SetData(payload, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoder, err := sender.UREncoder(150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := NewChunks()
+	for i := 0; !receiver.Complete(); i++ {
+		part := encoder.NextPart()
+		if i%4 == 1 {
+			continue
+		}
+
+		if _, err = receiver.ReadURPart(part); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := receiver.DataE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, payload) {
+		t.Fatal("incorrect received payload")
+	}
+
+	if _, err = NewChunks().UREncoder(150); err != ErrIncomplete {
+		t.Fatal("incomplete chunks are encoded", err)
+	}
+}
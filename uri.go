@@ -0,0 +1,49 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SetURIScheme prefixes frames with URI, e.g. "airgap://v1/", so scanned QR
+// codes deep-link to the paired app. Base64 frames use URL-safe alphabet
+// without padding, FrameTag is not added. Receiver with the same prefix
+// rejects frames without it.
+func (ch *Chunks) SetURIScheme(prefix string) *Chunks {
+	ch.opts.uriScheme = prefix
+	return ch
+}
+
+// SetURIScheme prefixes frames with URI, see Chunks.SetURIScheme
+func (a *AirGap) SetURIScheme(prefix string) *AirGap {
+	a.chunksOpts.uriScheme = prefix
+	return a
+}
+
+// trimURIScheme removes URI prefix, scheme and host are case-insensitive
+func (o chunksOptions) trimURIScheme(frame string) (string, error) {
+	if o.uriScheme == "" {
+		return frame, nil
+	}
+
+	frame = strings.TrimLeftFunc(frame, unicode.IsSpace)
+	if len(frame) < len(o.uriScheme) || !strings.EqualFold(frame[:len(o.uriScheme)], o.uriScheme) {
+		return "", newFrameError("unsupported go-airgap frame tag", FrameCheckTag, -1, 0, 0)
+	}
+
+	return frame[len(o.uriScheme):], nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestChunks_SetURIScheme(t *testing.T) {
+	payload := make([]byte, 3000)
+	_, _ = rand.Read(payload)
+
+	for _, encoding := range []FrameEncoding{EncodingBase64, EncodingBase32} {
+		opts := &chunksOptions{encoding: encoding, uriScheme: "airgap://v1/"}
+		sender, err := NewChunks().SetEncoding(encoding).SetURIScheme("airgap://v1/").SetData(payload, opts.frameChunkSize(250))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewChunks().SetEncoding(encoding).SetURIScheme("airgap://v1/")
+		for _, frame := range sender.SerializeText() {
+			if !strings.HasPrefix(frame, "airgap://v1/") || strings.ContainsAny(frame, "+=") || len(frame) > 250 {
+				t.Fatal("incorrect frame", frame)
+			}
+
+			if _, err = receiver.ReadTextChunk(strings.Replace(frame, "airgap", "AirGap", 1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+
+		// frames without URI prefix are rejected
+		frame := strings.TrimPrefix(sender.SerializeText()[0], "airgap://v1/")
+		if _, err = NewChunks().SetEncoding(encoding).SetURIScheme("airgap://v1/").ReadTextChunk(frame); err == nil {
+			t.Fatal("frame without URI prefix is accepted")
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"errors"
+)
+
+// SetWideHeaders enables 32-bit chunk index and chunks count in frame headers,
+// so transfers aren't limited by 65535 chunks, e.g. firmware images and large
+// backups. Receiver must enable wide headers too.
+func (ch *Chunks) SetWideHeaders(enabled bool) *Chunks {
+	ch.opts.wide = enabled
+	return ch
+}
+
+// SetWideHeaders enables 32-bit chunk index and chunks count, see Chunks.SetWideHeaders
+func (a *AirGap) SetWideHeaders(enabled bool) *AirGap {
+	a.chunksOpts.wide = enabled
+	return a
+}
+
+// verifyChunksCount checks that frame headers fit chunks count and parity frames
+func (o chunksOptions) verifyChunksCount(count int) error {
+	if o.fountain && count > maxChunksCount {
+		return errors.New("max fountain chunks count 65535")
+	}
+
+	if !o.wide && count+o.parityGroups(uint32(count)) > maxChunksCount {
+		return errors.New("max chunks count 65535, see SetWideHeaders")
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunks_SetWideHeaders(t *testing.T) {
+	payload := make([]byte, 700000)
+	_, _ = rand.Read(payload)
+
+	if _, err := NewChunks().SetData(payload, 16); err == nil {
+		t.Fatal("chunks count overflow is accepted")
+	}
+
+	for _, compact := range []bool{false, true} {
+		sender, err := NewChunks().SetWideHeaders(true).SetCompactHeaders(compact).SetData(payload, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		receiver := NewChunks().SetWideHeaders(true).SetCompactHeaders(compact)
+		for _, frame := range sender.SerializeRaw() {
+			if _, err = receiver.ReadRawChunk(frame); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		received, total, _ := receiver.Progress()
+		if total <= maxChunksCount || received != total {
+			t.Fatal("incorrect chunks count", received, total)
+		}
+
+		if !bytes.Equal(receiver.Data(), payload) {
+			t.Fatal("incorrect received payload")
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	// X25519KeySize is size of X25519 public and private keys
+	X25519KeySize = 32
+
+	x25519KeyWrapInfo = "go-airgap x25519 key wrap"
+)
+
+// x25519P is 2^255 - 19
+var x25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// X25519PublicKey is Curve25519 Diffie-Hellman public key of RFC 7748
+type X25519PublicKey []byte
+
+// X25519PrivateKey is Curve25519 Diffie-Hellman private scalar of RFC 7748,
+// which is used by mobile stacks natively
+type X25519PrivateKey []byte
+
+// GenerateX25519Key generates X25519 private key with rand, e.g. crypto/rand.Reader
+func GenerateX25519Key(rand io.Reader) (X25519PrivateKey, error) {
+	priv := make([]byte, X25519KeySize)
+	if _, err := io.ReadFull(rand, priv); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// X25519PrivateKeyFromEd25519 converts ed25519 private key to X25519 key, so
+// device with ed25519 instance id decrypts messages wrapped to it
+func X25519PrivateKeyFromEd25519(priv ed25519.PrivateKey) X25519PrivateKey {
+	digest := sha512.Sum512(priv.Seed())
+	return append(X25519PrivateKey{}, digest[:X25519KeySize]...)
+}
+
+func (k X25519PrivateKey) Public() crypto.PublicKey {
+	pub, _ := x25519(k, curve25519.Basepoint)
+	return X25519PublicKey(pub)
+}
+
+// X25519PublicKeyFromInstanceId returns X25519 key of instance id with
+// InstanceTypeX25519 prefix, ed25519 keys of InstanceTypeEd25519 instance ids
+// are converted to Montgomery form
+func X25519PublicKeyFromInstanceId(instanceId []byte) (X25519PublicKey, error) {
+	if len(instanceId) != compressedPubKeySize {
+		return nil, errors.New("incorrect instance pub key size")
+	}
+
+	switch instanceId[0] {
+	case InstanceTypeX25519:
+		return append(X25519PublicKey{}, instanceId[1:]...), nil
+	case InstanceTypeEd25519:
+		return x25519FromEd25519(instanceId[1:])
+	}
+	return nil, errors.New("instance id is not curve25519 key")
+}
+
+// x25519FromEd25519 maps edwards y to montgomery u = (1 + y) / (1 - y)
+func x25519FromEd25519(pub []byte) (X25519PublicKey, error) {
+	y := x25519Decode(pub)
+
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, x25519P)
+	if denominator.Sign() == 0 {
+		return nil, errors.New("incorrect ed25519 public key")
+	}
+
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, new(big.Int).ModInverse(denominator, x25519P))
+	u.Mod(u, x25519P)
+
+	return x25519Encode(u), nil
+}
+
+// x25519 implements X25519 function of RFC 7748, low order points are rejected
+func x25519(scalar, point []byte) ([]byte, error) {
+	if len(scalar) != X25519KeySize || len(point) != X25519KeySize {
+		return nil, errors.New("incorrect x25519 key size")
+	}
+	return curve25519.X25519(scalar, point)
+}
+
+// x25519Decode decodes little-endian field element, the most significant bit is ignored
+func x25519Decode(data []byte) *big.Int {
+	be := make([]byte, X25519KeySize)
+	for i := range be {
+		be[i] = data[X25519KeySize-1-i]
+	}
+	be[0] &= 0x7F
+
+	result := new(big.Int).SetBytes(be)
+	return result.Mod(result, x25519P)
+}
+
+// x25519Encode encodes field element as little-endian
+func x25519Encode(n *big.Int) []byte {
+	be := n.FillBytes(make([]byte, X25519KeySize))
+	result := make([]byte, X25519KeySize)
+	for i := range result {
+		result[i] = be[X25519KeySize-1-i]
+	}
+	return result
+}
+
+// X25519Recipient wraps payload keys to the recipient X25519 public key
+// with ephemeral X25519, HKDF-SHA256 and AES-256-GCM
+type X25519Recipient struct {
+	pub X25519PublicKey
+}
+
+// X25519Identity unwraps payload keys wrapped with X25519Recipient
+type X25519Identity struct {
+	priv X25519PrivateKey
+}
+
+func NewX25519Recipient(pub X25519PublicKey) *X25519Recipient {
+	return &X25519Recipient{pub: pub}
+}
+
+func NewX25519Identity(priv X25519PrivateKey) *X25519Identity {
+	return &X25519Identity{priv: priv}
+}
+
+// NewX25519EncryptorDecryptor initiates encryption to the paired instances
+// with X25519 or ed25519 instance ids, see X25519PublicKeyFromInstanceId and
+// NewECIESEncryptorDecryptor
+func NewX25519EncryptorDecryptor(priv X25519PrivateKey, peerInstanceIds ...[]byte) (*HybridEncryptorDecryptor, error) {
+	if len(peerInstanceIds) == 0 || len(peerInstanceIds) > 255 {
+		return nil, errors.New("go-airgap x25519 requires 1-255 peer instance ids")
+	}
+
+	recipients := make([]KeyWrapper, len(peerInstanceIds))
+	for i := range peerInstanceIds {
+		peer, err := X25519PublicKeyFromInstanceId(peerInstanceIds[i])
+		if err != nil {
+			return nil, err
+		}
+		recipients[i] = NewX25519Recipient(peer)
+	}
+	return NewHybridEncryptorDecryptor(NewX25519Identity(priv), recipients...), nil
+}
+
+// WrapKey returns ephemeral_pub_key || AES-GCM(kek, payload_key)
+func (r *X25519Recipient) WrapKey(payloadKey []byte) ([]byte, error) {
+	ephemeral, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := x25519(ephemeral, r.pub)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPub := ephemeral.Public().(X25519PublicKey)
+
+	aead, err := keyWrapAEAD(x25519KeyWrapInfo, shared, ephemeralPub, r.pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(ephemeralPub, make([]byte, aead.NonceSize()), payloadKey, nil), nil
+}
+
+func (id *X25519Identity) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) < X25519KeySize {
+		return nil, errors.New("x25519 wrapped key to small")
+	}
+
+	shared, err := x25519(id.priv, wrappedKey[:X25519KeySize])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := keyWrapAEAD(x25519KeyWrapInfo, shared, wrappedKey[:X25519KeySize], id.priv.Public().(X25519PublicKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, make([]byte, aead.NonceSize()), wrappedKey[X25519KeySize:], nil)
+}
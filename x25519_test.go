@@ -0,0 +1,171 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package go_airgap
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestX25519(t *testing.T) {
+	// RFC 7748 test vectors
+	vectors := [][3]string{
+		{
+			"a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4",
+			"e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c",
+			"c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552",
+		},
+		{
+			"4b66e9d4d1b4673c5ad22691957d6af5c11b6421e0ea01d42ca4169e7918ba0d",
+			"e5210f12786811d3f4b7959d0538ae2c31dbe7106fc03c3efc4cd549c715a493",
+			"95cbde9476e8907d7aade45cb4b873f88b595a68799fa152e6f8f7647aac7957",
+		},
+	}
+
+	for _, vector := range vectors {
+		scalar, _ := hex.DecodeString(vector[0])
+		point, _ := hex.DecodeString(vector[1])
+
+		result, err := x25519(scalar, point)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(result) != vector[2] {
+			t.Fatal("incorrect x25519 result", hex.EncodeToString(result))
+		}
+	}
+
+	alice, _ := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	bob, _ := hex.DecodeString("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+
+	alicePub := X25519PrivateKey(alice).Public().(X25519PublicKey)
+	if hex.EncodeToString(alicePub) != "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a" {
+		t.Fatal("incorrect x25519 public key", hex.EncodeToString(alicePub))
+	}
+
+	shared, err := x25519(bob, alicePub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(shared) != "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742" {
+		t.Fatal("incorrect x25519 shared key", hex.EncodeToString(shared))
+	}
+
+	if _, err = x25519(bob, make([]byte, X25519KeySize)); err == nil {
+		t.Fatal("low order point is accepted")
+	}
+}
+
+func TestX25519PublicKeyFromInstanceId(t *testing.T) {
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate ed25519 key")
+	}
+
+	instanceId, err := InstanceIdFromPublicKey(edPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := X25519PublicKeyFromInstanceId(instanceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(pub, X25519PrivateKeyFromEd25519(edPriv).Public().(X25519PublicKey)) {
+		t.Fatal("mismatch converted ed25519 key")
+	}
+
+	priv, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceId, err = InstanceIdFromPublicKey(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if instanceId[0] != InstanceTypeX25519 {
+		t.Fatal("incorrect x25519 instance type")
+	}
+
+	pub, err = X25519PublicKeyFromInstanceId(instanceId)
+	if err != nil || !bytes.Equal(pub, priv.Public().(X25519PublicKey)) {
+		t.Fatal("mismatch x25519 instance key", err)
+	}
+
+	if _, err = X25519PublicKeyFromInstanceId(append([]byte{0x02}, pub...)); err == nil {
+		t.Fatal("EC instance id is accepted")
+	}
+}
+
+func TestNewX25519EncryptorDecryptor(t *testing.T) {
+	senderKey, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiverKey, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mobile signer with ed25519 identity
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal("cannot generate ed25519 key")
+	}
+
+	senderId, _ := InstanceIdFromPublicKey(senderKey.Public())
+	receiverId, _ := InstanceIdFromPublicKey(receiverKey.Public())
+	edId, _ := InstanceIdFromPublicKey(edPub)
+
+	senderED, err := NewX25519EncryptorDecryptor(senderKey, receiverId, edId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewAirGap(VersionDefault, receiverId).SetEncryptorDecryptor(senderED).
+		CreateMessage().AddOperation(opCodeTest1, []byte("secret message")).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, priv := range []X25519PrivateKey{receiverKey, X25519PrivateKeyFromEd25519(edPriv)} {
+		receiverED, err := NewX25519EncryptorDecryptor(priv, senderId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message, err := NewAirGap(VersionDefault, receiverId).SetEncryptorDecryptor(receiverED).Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(message.Operations[0].Data, []byte("secret message")) {
+			t.Fatal("mismatch decrypted data")
+		}
+	}
+
+	if _, err = senderED.Decrypt(data); err == nil {
+		t.Fatal("message decrypted by sender")
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/censync/go-airgap/zstdcompress
+
+go 1.18
+
+require (
+	github.com/censync/go-airgap v0.1.0
+	github.com/klauspost/compress v1.15.15
+)
+
+require golang.org/x/crypto v0.7.0 // indirect
@@ -0,0 +1,110 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zstdcompress implements go-airgap payload compression with zstd,
+// which is faster and denser than gzip for typical JSON payloads.
+package zstdcompress
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+var (
+	_ airgap.Compressor = Zstd{}
+	_ airgap.Compressor = (*ZstdDict)(nil)
+)
+
+// dictMagic is magic number of dictionaries trained with zstd --train
+var dictMagic = []byte{0x37, 0xa4, 0x30, 0xec}
+
+// user dictionary ids range, lower ids are reserved
+const (
+	minDictId = 1 << 15
+	maxDictId = 1<<31 - 1
+)
+
+// decoder limits of untrusted frames, writer window doesn't exceed 8 MiB
+const (
+	maxDecoderWindow = 8 << 20
+	maxDecoderMemory = 64 << 20
+)
+
+// decoderLimits bounds memory of decoder against crafted window size
+var decoderLimits = []zstd.DOption{
+	zstd.WithDecoderConcurrency(1),
+	zstd.WithDecoderMaxWindow(maxDecoderWindow),
+	zstd.WithDecoderMaxMemory(maxDecoderMemory),
+}
+
+// Zstd implements go_airgap.Compressor with zstd best compression level
+type Zstd struct{}
+
+func (Zstd) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+}
+
+func (Zstd) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r, decoderLimits...)
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// ZstdDict implements go_airgap.Compressor with zstd and preset dictionary
+type ZstdDict struct {
+	encoderOption zstd.EOption
+	decoderOption zstd.DOption
+}
+
+// NewZstdDict returns compressor with dictionary trained with zstd --train,
+// or raw content dictionary, e.g. typical JSON or PSBT payload. Receiver must
+// use the same dictionary.
+func NewZstdDict(dict []byte) *ZstdDict {
+	dict = append([]byte{}, dict...)
+
+	if bytes.HasPrefix(dict, dictMagic) {
+		return &ZstdDict{
+			encoderOption: zstd.WithEncoderDict(dict),
+			decoderOption: zstd.WithDecoderDicts(dict),
+		}
+	}
+
+	digest := sha256.Sum256(dict)
+	id := minDictId + binary.BigEndian.Uint32(digest[:])%(maxDictId-minDictId)
+
+	return &ZstdDict{
+		encoderOption: zstd.WithEncoderDictRaw(id, dict),
+		decoderOption: zstd.WithDecoderDictRaw(id, dict),
+	}
+}
+
+func (z *ZstdDict) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1), z.encoderOption)
+}
+
+func (z *ZstdDict) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r, append([]zstd.DOption{z.decoderOption}, decoderLimits...)...)
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
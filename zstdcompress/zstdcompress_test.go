@@ -0,0 +1,112 @@
+// Copyright 2022 Dmitry Mandrika
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zstdcompress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	airgap "github.com/censync/go-airgap"
+)
+
+func TestZstd(t *testing.T) {
+	var payload bytes.Buffer
+	for i := 0; i < 200; i++ {
+		_, _ = fmt.Fprintf(&payload, `{"id":%d,"to":"0x%040x","value":"%d","nonce":%d},`, i, i*7919, i*1e9, i)
+	}
+
+	sender, err := airgap.NewChunks().SetCompressor(Zstd{}).SetPayloadDigest(true).SetData(payload.Bytes(), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver := airgap.NewChunks().SetCompressor(Zstd{}).SetPayloadDigest(true)
+	for _, frame := range sender.SerializeB64() {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload.Bytes()) {
+		t.Fatal("incorrect received payload")
+	}
+
+	var streamed bytes.Buffer
+	if _, err = receiver.WriteDataTo(&streamed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(streamed.Bytes(), payload.Bytes()) {
+		t.Fatal("incorrect streamed payload")
+	}
+
+	gzipped, err := airgap.NewChunks().SetData(payload.Bytes(), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sender.Count() > gzipped.Count() {
+		t.Fatal("zstd payload is larger than gzip payload", sender.Count(), gzipped.Count())
+	}
+}
+
+func TestNewZstdDict(t *testing.T) {
+	dict := []byte(`{"jsonrpc":"2.0","method":"eth_sendTransaction","params":[{"from":"0x","to":"0x","value":"0x","gas":"0x"}]}`)
+	payload := []byte(`{"jsonrpc":"2.0","method":"eth_sendTransaction","params":[{"from":"0x01","to":"0x02","value":"0x10","gas":"0x5208"}]}`)
+
+	sender, err := airgap.NewChunks().SetCompressor(NewZstdDict(dict)).SetData(payload, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := airgap.NewChunks().SetCompressor(Zstd{}).SetData(payload, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sender.Count() >= plain.Count() {
+		t.Fatal("payload isn't compressed with dictionary", sender.Count(), plain.Count())
+	}
+
+	receiver := airgap.NewChunks().SetCompressor(NewZstdDict(dict))
+	for _, frame := range sender.SerializeB64() {
+		if _, err = receiver.ReadB64Chunk(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bytes.Equal(receiver.Data(), payload) {
+		t.Fatal("incorrect received payload")
+	}
+}
+
+func TestZstd_DecoderLimits(t *testing.T) {
+	// frame header declares 256 MiB window, followed by empty last raw block
+	frame := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 0x90, 0x01, 0x00, 0x00}
+
+	for _, compressor := range []airgap.Compressor{Zstd{}, NewZstdDict([]byte("dictionary"))} {
+		zr, err := compressor.NewReader(bytes.NewReader(frame))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = io.ReadAll(zr); err == nil {
+			t.Fatal("frame exceeding decoder window is accepted")
+		}
+		_ = zr.Close()
+	}
+}